@@ -0,0 +1,161 @@
+package bcs_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotaledger/bcs-go"
+)
+
+type AppendSample struct {
+	A uint32
+	B string
+	C []uint16
+	D uint64       `bcs:"compact"`
+	E AppendNested `bcs:"bytearr"`
+}
+
+type AppendNested struct {
+	X int8
+	Y string
+}
+
+// Values of "bytearr" fields are encoded using temporary buffer, so they are excluded.
+type AppendNoBytearr struct {
+	A uint32
+	B string
+	C []uint16
+	D uint64 `bcs:"compact"`
+	E AppendNested
+}
+
+type AppendNestedPtr struct {
+	P *AppendNested
+}
+
+func newAppendSample() AppendSample {
+	return AppendSample{A: 1, B: "hello", C: []uint16{1, 2, 3}, D: 300, E: AppendNested{X: -1, Y: "nested"}}
+}
+
+func TestMarshalAppend(t *testing.T) {
+	v := newAppendSample()
+
+	var stream bytes.Buffer
+	require.NoError(t, bcs.MarshalStream(&v, &stream))
+
+	b, err := bcs.MarshalAppend([]byte{0xAA, 0xBB}, &v)
+	require.NoError(t, err)
+	require.Equal(t, append([]byte{0xAA, 0xBB}, stream.Bytes()...), b)
+
+	buf := make([]byte, 0, 1024)
+	b, err = bcs.MarshalAppend(buf, &v)
+	require.NoError(t, err)
+	require.Equal(t, stream.Bytes(), b)
+	require.Same(t, &buf[:1][0], &b[0], "buffer with sufficient capacity must be reused")
+
+	var anyV any = v
+	b, err = bcs.MarshalAppend(nil, &anyV)
+	require.NoError(t, err)
+	require.Equal(t, stream.Bytes(), b)
+
+	dst := []byte{0xAA}
+	b, err = bcs.MarshalAppend(dst, &AppendNestedPtr{})
+	require.ErrorIs(t, err, bcs.ErrNilValue)
+	require.Equal(t, []byte{0xAA}, b)
+
+	// Result must not be affected by further usage of pooled encoders
+	b, err = bcs.MarshalAppend(nil, &v)
+	require.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		_, _ = bcs.MarshalAppend(nil, &AppendNested{X: 5, Y: "other"})
+		e := bcs.GetBytesEncoder()
+		e.WriteString("other")
+		bcs.PutBytesEncoder(e)
+	}
+	require.Equal(t, stream.Bytes(), b)
+}
+
+type AppendPanicking struct{}
+
+func (AppendPanicking) MarshalBCS(e *bcs.Encoder) error {
+	e.WriteString("partial")
+	panic("failed to encode")
+}
+
+func TestMarshalAppendPanic(t *testing.T) {
+	dst := make([]byte, 0, 64)
+
+	require.Panics(t, func() { _, _ = bcs.MarshalAppend(dst, &AppendPanicking{}) })
+	written := append([]byte(nil), dst[:cap(dst)]...)
+
+	// Pooled encoders must not keep writing into buffer of caller after panic
+	for i := 0; i < 10; i++ {
+		e := bcs.GetBytesEncoder()
+		e.WriteString("other")
+		bcs.PutBytesEncoder(e)
+	}
+	require.Equal(t, written, dst[:cap(dst)])
+}
+
+func TestBytesEncoderReset(t *testing.T) {
+	v := newAppendSample()
+	expected := bcs.MustMarshal(&v)
+
+	e := bcs.NewBytesEncoder()
+	e.Encode(&AppendNestedPtr{})
+	require.Error(t, e.Err())
+
+	e.Reset()
+	require.NoError(t, e.Err())
+	require.Empty(t, e.Bytes())
+
+	e.Encode(&v)
+	require.NoError(t, e.Err())
+	require.Equal(t, expected, e.Bytes())
+
+	e.Reset()
+	e.Encode(&v)
+	require.NoError(t, e.Err())
+	require.Equal(t, expected, e.Bytes())
+
+	e = bcs.GetBytesEncoder()
+	require.Empty(t, e.Bytes())
+	e.Encode(&v)
+	require.NoError(t, e.Err())
+	require.Equal(t, expected, e.Bytes())
+	bcs.PutBytesEncoder(e)
+}
+
+func TestBytesEncoderResetNoAllocs(t *testing.T) {
+	v := AppendNoBytearr{A: 1, B: "hello", C: []uint16{1, 2, 3}, D: 300}
+
+	e := bcs.NewBytesEncoder()
+	e.Encode(&v)
+	require.NoError(t, e.Err())
+
+	allocs := testing.AllocsPerRun(100, func() {
+		e.Reset()
+		e.Encode(&v)
+	})
+	require.Zero(t, allocs)
+	require.Equal(t, bcs.MustMarshal(&v), e.Bytes())
+}
+
+func TestMarshalAppendNoAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("sync.Pool drops encoders randomly under race detector")
+	}
+
+	v := AppendNoBytearr{A: 1, B: "hello", C: []uint16{1, 2, 3}, D: 300}
+	buf := make([]byte, 0, 1024)
+
+	_, err := bcs.MarshalAppend(buf, &v)
+	require.NoError(t, err)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		_, _ = bcs.MarshalAppend(buf[:0], &v)
+	})
+	require.Zero(t, allocs)
+}
@@ -0,0 +1,268 @@
+// Package bcstest contains helpers for testing of code generated by bcsgen.
+package bcstest
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+	"unsafe"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotaledger/bcs-go"
+)
+
+var (
+	bcsTypeT    = reflect.TypeOf((*bcs.BCSType)(nil)).Elem()
+	structEnumT = reflect.TypeOf((*bcs.Enum)(nil)).Elem()
+	noneT       = reflect.TypeOf(bcs.None{})
+)
+
+// TestGeneratedCodec checks that codec generated by bcsgen for type V produces same results as reflection-based codec.
+// R must be a type defined as "type R V", so that it has same fields, but does not have generated methods.
+// If no samples are provided, random ones are generated.
+// For each of the samples checks that:
+//   - encoded bytes are same for both codecs
+//   - decoded values are same for both codecs
+//   - encoding of decoded value results in same bytes
+//
+// If both codecs fail to encode a sample, it is skipped.
+func TestGeneratedCodec[V, R any](t *testing.T, samples ...V) {
+	if len(samples) == 0 {
+		samples = RandomSamples[V](20, 1)
+	}
+
+	rPtrT := reflect.TypeOf((*R)(nil))
+
+	toR := func(v *V) *R {
+		return reflect.ValueOf(v).Convert(rPtrT).Interface().(*R)
+	}
+
+	for i := range samples {
+		v := samples[i]
+
+		vEnc, err := bcs.Marshal(&v)
+		rEnc, rErr := bcs.Marshal(toR(&v))
+		if rErr != nil {
+			require.Error(t, err, "sample %v: reflection-based encoding failed, but generated did not: %v", i, rErr)
+			continue
+		}
+
+		require.NoError(t, err, "sample %v: %#v", i, v)
+		require.Equal(t, rEnc, vEnc, "sample %v: %#v", i, v)
+
+		rSize, err := bcs.EncodedSize(toR(&v))
+		require.NoError(t, err, "sample %v: %#v", i, v)
+		require.Equal(t, len(rEnc), rSize, "sample %v: encoded size", i)
+
+		vDec, err := bcs.Unmarshal[V](vEnc)
+		require.NoError(t, err, "sample %v: %x", i, vEnc)
+
+		rDec, err := bcs.Unmarshal[R](rEnc)
+		require.NoError(t, err, "sample %v: %x", i, rEnc)
+		require.Equal(t, rDec, *toR(&vDec), "sample %v: %x", i, vEnc)
+
+		vReEnc, err := bcs.Marshal(&vDec)
+		require.NoError(t, err, "sample %v: %#v", i, vDec)
+		require.Equal(t, vEnc, vReEnc, "sample %v: %#v", i, vDec)
+	}
+}
+
+// RandomSamples generates values of type V filled with pseudo-random data suitable for encoding: non-optional references are set,
+// integers fit into their encoded types, interface enums and struct enums have exactly one variant set, etc.
+// Values of types with custom encoders registered using AddCustomEncoder are left empty.
+// Results are deterministic for the same seed.
+func RandomSamples[V any](count int, seed int64) []V {
+	r := randomFiller{rnd: rand.New(rand.NewSource(seed))} //nolint:gosec
+
+	samples := make([]V, count)
+	for i := range samples {
+		r.fill(reflect.ValueOf(&samples[i]).Elem(), nil, 0)
+	}
+
+	return samples
+}
+
+type randomFiller struct {
+	rnd *rand.Rand
+}
+
+const randomFillerMaxDepth = 4
+
+//nolint:gocyclo,funlen
+func (r *randomFiller) fill(v reflect.Value, typeOptsFromTag *bcs.TypeOptions, depth int) {
+	t := v.Type()
+
+	if bcs.DefaultRegistry.HasCustomEncoder(t) {
+		return
+	}
+
+	if names, isIntEnum := bcs.DefaultRegistry.IntEnumVariants(t); isIntEnum {
+		ids := lo.Keys(names)
+		if len(ids) == 0 {
+			return
+		}
+		sort.Ints(ids)
+		id := ids[r.rnd.Intn(len(ids))]
+
+		if v.CanInt() {
+			v.SetInt(int64(id))
+		} else {
+			v.SetUint(uint64(id))
+		}
+
+		return
+	}
+
+	var typeOpts bcs.TypeOptions
+	if t.Kind() != reflect.Interface && t.Implements(bcsTypeT) {
+		typeOpts = v.Interface().(bcs.BCSType).BCSOptions()
+	}
+	if typeOptsFromTag != nil {
+		typeOpts.Update(*typeOptsFromTag)
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		v.SetBool(r.rnd.Intn(2) == 1)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		// Int63() has only 63 random bits, so the value always fits into signed type of given size.
+		val := r.rnd.Int63() >> (64 - min(t.Bits(), kindBits(typeOpts.UnderlyingType)))
+		if !isUnsignedKind(typeOpts.UnderlyingType) && r.rnd.Intn(2) == 1 {
+			val = -val
+		}
+		v.SetInt(val)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		bits := min(t.Bits(), kindBits(typeOpts.UnderlyingType))
+		if typeOpts.UnderlyingType != reflect.Invalid && !isUnsignedKind(typeOpts.UnderlyingType) {
+			bits--
+		}
+		v.SetUint(r.rnd.Uint64() >> (64 - bits))
+	case reflect.String:
+		b := make([]byte, r.rnd.Intn(8))
+		for i := range b {
+			b[i] = byte('a' + r.rnd.Intn(26))
+		}
+		v.SetString(string(b))
+	case reflect.Slice:
+		n := 0
+		if depth < randomFillerMaxDepth {
+			n = r.rnd.Intn(4)
+		}
+		if n == 0 && typeOpts.NilIfEmpty {
+			return
+		}
+
+		v.Set(reflect.MakeSlice(t, n, n))
+		r.fillElems(v, typeOpts, depth)
+	case reflect.Array:
+		r.fillElems(v, typeOpts, depth)
+	case reflect.Map:
+		v.Set(reflect.MakeMap(t))
+		if depth >= randomFillerMaxDepth {
+			return
+		}
+
+		for i := r.rnd.Intn(4); i > 0; i-- {
+			key := reflect.New(t.Key()).Elem()
+			r.fill(key, typeOpts.MapKey, depth+1)
+			value := reflect.New(t.Elem()).Elem()
+			r.fill(value, typeOpts.MapValue, depth+1)
+			v.SetMapIndex(key, value)
+		}
+	case reflect.Ptr:
+		v.Set(reflect.New(t.Elem()))
+		r.fill(v.Elem(), typeOptsFromTag, depth+1)
+	case reflect.Struct:
+		if t.Implements(structEnumT) {
+			// Some variants might remain unset, e.g. if field is an interface enum and None variant was chosen.
+			for _, i := range r.rnd.Perm(t.NumField()) {
+				field := v.Field(i)
+				if !field.CanSet() {
+					continue
+				}
+
+				r.fill(field, nil, depth+1)
+
+				if !field.IsZero() {
+					break
+				}
+			}
+
+			return
+		}
+
+		fieldOpts, _, err := bcs.FieldOptionsFromStruct(t, "bcs")
+		if err != nil {
+			panic(err)
+		}
+
+		for i := 0; i < t.NumField(); i++ {
+			field := v.Field(i)
+			opts := fieldOpts[i]
+
+			if opts.Skip || (!t.Field(i).IsExported() && !opts.ExportAnonymousField) {
+				continue
+			}
+			if !field.CanSet() {
+				field = reflect.NewAt(field.Type(), unsafe.Pointer(field.UnsafeAddr())).Elem()
+			}
+
+			switch field.Kind() {
+			case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+				if opts.Optional && (depth >= randomFillerMaxDepth || r.rnd.Intn(3) == 0) {
+					continue
+				}
+			}
+
+			r.fill(field, &opts.TypeOptions, depth+1)
+		}
+	case reflect.Interface:
+		variants, isEnum := bcs.DefaultRegistry.EnumVariants(t)
+		if !isEnum || typeOpts.InterfaceIsNotEnum || len(variants) == 0 {
+			return
+		}
+
+		ids := lo.Keys(variants)
+		sort.Ints(ids)
+
+		variantT := variants[ids[r.rnd.Intn(len(ids))]]
+		if variantT == noneT {
+			return
+		}
+
+		variant := reflect.New(variantT).Elem()
+		r.fill(variant, nil, depth+1)
+		v.Set(variant)
+	}
+}
+
+func (r *randomFiller) fillElems(v reflect.Value, typeOpts bcs.TypeOptions, depth int) {
+	var elemOpts *bcs.TypeOptions
+	if typeOpts.ArrayElement != nil {
+		elemOpts = &typeOpts.ArrayElement.TypeOptions
+	}
+
+	for i := 0; i < v.Len(); i++ {
+		r.fill(v.Index(i), elemOpts, depth+1)
+	}
+}
+
+func kindBits(k reflect.Kind) int {
+	switch k {
+	case reflect.Int8, reflect.Uint8:
+		return 8
+	case reflect.Int16, reflect.Uint16:
+		return 16
+	case reflect.Int32, reflect.Uint32:
+		return 32
+	default:
+		return 64
+	}
+}
+
+func isUnsignedKind(k reflect.Kind) bool {
+	return k >= reflect.Uint && k <= reflect.Uintptr
+}
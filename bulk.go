@@ -0,0 +1,148 @@
+package bcs
+
+import (
+	"encoding/binary"
+	"io"
+	"reflect"
+	"unsafe"
+)
+
+// Encoding of integers is little-endian, so on little-endian platforms memory of integer arrays is same as their encoding.
+var isLittleEndian = binary.NativeEndian.Uint16([]byte{1, 0}) == 1
+
+// Returns size of array element, if array could be encoded or decoded by copying its memory.
+// This is possible for integers and bools without customizations on little-endian platforms,
+// if size of element in memory is same as its encoded size (e.g. int is encoded as 8 bytes, but may have 4 bytes in memory).
+func bulkElemSize(elemType reflect.Type, tInfo *typeInfo, elemOpts *ArrayElemOptions) (int, bool) {
+	if !isLittleEndian || tInfo.HasCustomizations() || elemOpts.AsByteArray || !hasDefaultEncoding(&elemOpts.TypeOptions) {
+		return 0, false
+	}
+
+	var size int
+
+	switch elemType.Kind() {
+	case reflect.Bool, reflect.Int8, reflect.Uint8:
+		size = 1
+	case reflect.Int16, reflect.Uint16:
+		size = 2
+	case reflect.Int32, reflect.Uint32:
+		size = 4
+	case reflect.Int64, reflect.Uint64, reflect.Int, reflect.Uint:
+		size = 8
+	default:
+		return 0, false
+	}
+
+	if int(elemType.Size()) != size {
+		return 0, false
+	}
+
+	return size, true
+}
+
+// Returns memory of elements of addressable array or slice.
+func arrayMemory(v reflect.Value, elemSize int) []byte {
+	if v.Len() == 0 {
+		return nil
+	}
+
+	return unsafe.Slice((*byte)(v.Index(0).Addr().UnsafePointer()), v.Len()*elemSize)
+}
+
+// Writes elements of array or slice by copying their memory.
+func (e *Encoder) encodeArrayBulk(v reflect.Value, elemSize int) {
+	_, _ = e.Write(arrayMemory(v, elemSize))
+}
+
+// Reads n elements directly into memory of array or slice.
+// Slice is extended gradually to avoid large allocations in case of corrupted length.
+func (d *Decoder) decodeArrayBulk(v reflect.Value, n, elemSize int) error {
+	// Elements are not decoded one by one, but their depth is still checked same way.
+	if limit := d.cfg.Limits.MaxDepth; n > 0 && limit > 0 && d.depth >= limit {
+		d.path.pushIdx(0)
+		return d.handleErrorf("[%v]: %w", 0, d.limitErrorf("MaxDepth", d.depth+1, limit))
+	}
+
+	if v.Kind() == reflect.Array {
+		b := arrayMemory(v, elemSize)
+		if err := d.readBulk(v, b, 0, elemSize); err != nil {
+			return err
+		}
+
+		return d.checkBulkBools(v, b, 0)
+	}
+
+	for read := 0; read < n; {
+		chunk := min(n-read, max(read, decodeSliceMaxPreallocSize))
+
+		v.Grow(chunk)
+		v.SetLen(read + chunk)
+
+		b := arrayMemory(v.Slice(read, read+chunk), elemSize)
+		if err := d.readBulk(v, b, read, elemSize); err != nil {
+			return err
+		}
+		if err := d.checkBulkBools(v, b, read); err != nil {
+			return err
+		}
+
+		read += chunk
+	}
+
+	return nil
+}
+
+// Reads memory of elements starting from index offset.
+// If input ends, error is reported same way as if elements were decoded one by one.
+func (d *Decoder) readBulk(v reflect.Value, b []byte, offset, elemSize int) error {
+	if d.err != nil {
+		return d.err
+	}
+
+	n, err := io.ReadFull(d.r, b)
+	d.offset += int64(n)
+	if err == nil {
+		return nil
+	}
+
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+
+	elemType := v.Type().Elem()
+	idx := offset + n/elemSize
+
+	d.path.pushIdx(idx)
+	_ = d.setErr(nil, err)
+	d.failure.Type = elemType
+
+	return d.handleErrorf("[%v]: %v: %w", idx, elemType, err)
+}
+
+// Checks that bytes read into memory of bool array are valid bools.
+// Error is reported same way as if elements were decoded one by one.
+func (d *Decoder) checkBulkBools(v reflect.Value, b []byte, offset int) error {
+	elemType := v.Type().Elem()
+	if elemType.Kind() != reflect.Bool {
+		return nil
+	}
+
+	for i, val := range b {
+		if val <= 1 {
+			continue
+		}
+
+		// Invalid bools must not remain in memory.
+		clear(b)
+
+		d.path.pushIdx(offset + i)
+		d.offset -= int64(len(b) - i - 1)
+
+		err := d.kindErrorf(ErrInvalidBool, "invalid bool value: %v", val)
+		d.failure.Type = elemType
+
+		return d.handleErrorf("[%v]: %v: %w", offset+i, elemType, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,157 @@
+package bcs_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotaledger/bcs-go"
+)
+
+type BulkInt uint16
+
+type BulkStruct struct {
+	U64     []uint64
+	I16     [4]int16
+	Bools   []bool
+	Ints    []int
+	Named   []BulkInt
+	Narrow  []int64  `bcs_elem:"type=i8"`
+	Compact []uint32 `bcs_elem:"compact"`
+	Nested  [][2]uint32
+}
+
+func TestBulkArrays(t *testing.T) {
+	bcs.TestCodecAndBytes(t, []uint32{1, 2, 0x01020304}, []byte{0x3, 0x1, 0x0, 0x0, 0x0, 0x2, 0x0, 0x0, 0x0, 0x4, 0x3, 0x2, 0x1})
+	bcs.TestCodecAndBytes(t, [2]int16{-1, 2}, []byte{0xFF, 0xFF, 0x2, 0x0})
+	bcs.TestCodecAndBytes(t, []bool{true, false, true}, []byte{0x3, 0x1, 0x0, 0x1})
+	bcs.TestCodecAndBytes(t, []int{-2}, []byte{0x1, 0xFE, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF})
+	bcs.TestCodecAndBytes(t, []BulkInt{1, 0x0203}, []byte{0x2, 0x1, 0x0, 0x3, 0x2})
+
+	// Element options are still applied
+	bcs.TestCodecAndBytes(t, BulkStruct{
+		U64:     []uint64{1},
+		I16:     [4]int16{1, 2, 3, 4},
+		Bools:   []bool{true},
+		Ints:    []int{5},
+		Named:   []BulkInt{6},
+		Narrow:  []int64{-1, 2},
+		Compact: []uint32{300},
+		Nested:  [][2]uint32{{7, 8}},
+	}, []byte{
+		0x1, 0x1, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+		0x1, 0x0, 0x2, 0x0, 0x3, 0x0, 0x4, 0x0,
+		0x1, 0x1,
+		0x1, 0x5, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+		0x1, 0x6, 0x0,
+		0x2, 0xFF, 0x2,
+		0x1, 0xAC, 0x2,
+		0x1, 0x7, 0x0, 0x0, 0x0, 0x8, 0x0, 0x0, 0x0,
+	})
+
+	bcs.TestEncodeErr(t, BulkStruct{Narrow: []int64{1000}}, "out of range")
+
+	// Large slices are decoded in chunks
+	large := make([]uint64, 10000)
+	for i := range large {
+		large[i] = uint64(i) << 32
+	}
+	bcs.TestCodec(t, large)
+
+	bools := make([]bool, 1000)
+	for i := range bools {
+		bools[i] = i%3 == 0
+	}
+	bcs.TestCodec(t, bools)
+
+	// Array passed by value is not addressable
+	e := bcs.NewBytesEncoder()
+	e.Encode([3]uint16{1, 2, 3})
+	require.NoError(t, e.Err())
+	require.Equal(t, []byte{0x1, 0x0, 0x2, 0x0, 0x3, 0x0}, e.Bytes())
+}
+
+func TestBulkArraysErrors(t *testing.T) {
+	var decodeErr *bcs.DecodeError
+
+	bools := make([]byte, 302)
+	bools[0], bools[1] = 0xAC, 0x2 // length 300
+	bools[251] = 2
+
+	_, err := bcs.Unmarshal[[]bool](bools)
+	require.ErrorIs(t, err, bcs.ErrInvalidBool)
+	require.ErrorAs(t, err, &decodeErr)
+	require.Equal(t, "[]bool[249]", decodeErr.Path)
+	require.Equal(t, int64(252), decodeErr.Offset)
+
+	_, err = bcs.Unmarshal[[]uint32]([]byte{0x3, 0x1, 0x0, 0x0, 0x0, 0x2, 0x0})
+	require.ErrorIs(t, err, bcs.ErrUnexpectedEOF)
+	require.ErrorAs(t, err, &decodeErr)
+	require.Equal(t, "[]uint32[1]", decodeErr.Path)
+	require.Equal(t, int64(7), decodeErr.Offset)
+
+	// Huge length does not result in huge allocation
+	_, err = bcs.Unmarshal[[]uint64]([]byte{0xFF, 0xFF, 0xFF, 0xFF, 0x0F, 0x1})
+	require.ErrorIs(t, err, bcs.ErrUnexpectedEOF)
+}
+
+type BulkPerElement struct {
+	V []uint64 `bcs_elem:"type=u64"` // overriding type with the same type disables bulk encoding
+}
+
+type BulkBulk struct {
+	V []uint64
+}
+
+func benchmarkEncode[V any](b *testing.B, v V) {
+	benchmarkEncodeIn(b, bcs.DefaultRegistry, v)
+}
+
+func benchmarkEncodeIn[V any](b *testing.B, r *bcs.Registry, v V) {
+	e := bcs.NewBytesEncoderWithOpts(bcs.EncoderConfig{Registry: r})
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		e.Reset()
+		e.Encode(&v)
+	}
+}
+
+func benchmarkDecode[V any](b *testing.B, v V) {
+	benchmarkDecodeIn(b, bcs.DefaultRegistry, v)
+}
+
+func benchmarkDecodeIn[V any](b *testing.B, r *bcs.Registry, v V) {
+	e := bcs.NewBytesEncoderWithOpts(bcs.EncoderConfig{Registry: r})
+	e.Encode(&v)
+	require.NoError(b, e.Err())
+
+	enc := e.Bytes()
+	b.SetBytes(int64(len(enc)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		d := bcs.NewBytesDecoderWithOpts(enc, bcs.DecoderConfig{Registry: r})
+		_ = bcs.Decode[V](&d.Decoder)
+	}
+}
+
+func BenchmarkEncodeUint64Slice(b *testing.B) {
+	v := make([]uint64, 10000)
+	b.Run("bulk", func(b *testing.B) { benchmarkEncode(b, BulkBulk{V: v}) })
+	b.Run("per-element", func(b *testing.B) { benchmarkEncode(b, BulkPerElement{V: v}) })
+}
+
+func BenchmarkDecodeUint64Slice(b *testing.B) {
+	v := make([]uint64, 10000)
+	b.Run("bulk", func(b *testing.B) { benchmarkDecode(b, BulkBulk{V: v}) })
+	b.Run("per-element", func(b *testing.B) { benchmarkDecode(b, BulkPerElement{V: v}) })
+}
+
+func BenchmarkCodecBoolSlice(b *testing.B) {
+	v := make([]bool, 10000)
+	b.Run("encode", func(b *testing.B) { benchmarkEncode(b, v) })
+	b.Run("decode", func(b *testing.B) { benchmarkDecode(b, v) })
+}
@@ -0,0 +1,230 @@
+// Package example demonstrates usage of bcsgen and is used to test it.
+package example
+
+import (
+	"math/big"
+	"reflect"
+
+	"github.com/iotaledger/bcs-go"
+)
+
+//go:generate go run github.com/iotaledger/bcs-go/cmd/bcsgen
+
+type Basic struct {
+	A bool
+	B int8
+	C uint8
+	D int16
+	E uint16
+	F int32
+	G uint32
+	H int64
+	I uint64
+	J int
+	K uint
+	L string
+}
+
+type Options struct {
+	Compact     uint64           `bcs:"compact"`
+	CompactInt  int32            `bcs:"compact"`
+	ZigZag      int16            `bcs:"compact=zigzag"`
+	Narrowed    int64            `bcs:"type=i16"`
+	Widened     uint8            `bcs:"type=u32"`
+	ToUnsigned  int              `bcs:"type=u16"`
+	Len2        []int16          `bcs:"len_bytes=2"`
+	NilIfEmpty  []string         `bcs:"nil_if_empty"`
+	ElemCompact [3]uint32        `bcs_elem:"compact"`
+	KeyValue    map[uint32]int64 `bcs_key:"compact" bcs_value:"type=i8"`
+	ByteArr     []uint64         `bcs:"bytearr"`
+	ElemByteArr [][]byte         `bcs_elem:"bytearr"`
+	Optional    *Basic           `bcs:"optional"`
+	OptionalMap map[string]bool  `bcs:"optional"`
+	Skipped     string           `bcs:"-"`
+	unexported  int
+	exported    uint16 `bcs:"export"`
+	TypeOptions Compact
+}
+
+type Compact uint32
+
+func (Compact) BCSOptions() bcs.TypeOptions {
+	return bcs.TypeOptions{IsCompactInt: true}
+}
+
+type Bytes []byte
+
+type MyByte uint8
+
+type Collections struct {
+	Bytes       []byte
+	NamedBytes  Bytes
+	MyBytes     []MyByte
+	ByteArray   [4]byte
+	Nested      [][]string
+	Map         map[string][]uint16
+	NestedMap   map[int16]map[bool]string
+	Ptr         *int32
+	PtrPtr      **string
+	StructSlice []Basic
+	Array       [2]Nested
+}
+
+type Nested struct {
+	Name  string
+	Inner *Nested `bcs:"optional"`
+}
+
+type Enum interface {
+	isEnum()
+}
+
+type VariantA struct{ V uint16 }
+
+type VariantB string
+
+func (VariantA) isEnum()  {}
+func (*VariantB) isEnum() {}
+
+var _ = bcs.RegisterEnumType3[Enum, bcs.None, VariantA, *VariantB]()
+
+// Enum with gaps between variant ids, e.g. after removal of deprecated variants.
+type SparseEnum interface {
+	isSparseEnum()
+}
+
+func (VariantA) isSparseEnum()  {}
+func (*VariantB) isSparseEnum() {}
+
+var _ = bcs.RegisterEnumTypeWithIDs[SparseEnum](map[bcs.EnumVariantID]any{
+	1: VariantA{},
+	5: (*VariantB)(nil),
+})
+
+// Enum, which keeps variants unknown to this version of code.
+type ExtensibleEnum interface {
+	isExtensibleEnum()
+}
+
+type UnknownVariant bcs.UnknownEnumVariant
+
+func (VariantA) isExtensibleEnum()       {}
+func (UnknownVariant) isExtensibleEnum() {}
+
+var (
+	_ = bcs.RegisterEnumType1[ExtensibleEnum, VariantA]()
+	_ = bcs.RegisterEnumTypeUnknownVariant[ExtensibleEnum](UnknownVariant{})
+)
+
+type StructEnum struct {
+	A *int32
+	B *Basic
+	C []string
+	D Enum
+}
+
+func (StructEnum) IsBcsEnum() {}
+
+// Struct enum with explicit variant ids and unit variants.
+type StructEnumWithIDs struct {
+	A     *int32 `bcs:"variant=2"`
+	Empty *struct{}
+	None  *bcs.None `bcs:"variant=5"`
+	B     *Basic
+}
+
+func (StructEnumWithIDs) IsBcsEnum() {}
+
+// Struct enum with options of variant values.
+type StructEnumWithOpts struct {
+	A *uint64  `bcs:"compact"`
+	B *[]int16 `bcs:"len_bytes=2,variant=3"`
+	C *Basic   `bcs:"bytearr"`
+}
+
+func (StructEnumWithOpts) IsBcsEnum() {}
+
+// Enum registered using builder, which is not limited in number of variants.
+type BuiltEnum interface {
+	isBuiltEnum()
+}
+
+func (VariantA) isBuiltEnum()  {}
+func (*VariantB) isBuiltEnum() {}
+
+var _ = bcs.NewEnum[BuiltEnum]().Add(
+	bcs.NoneVariant(0),
+	bcs.Variant[VariantA](1, "A"),
+	bcs.Variant[*VariantB](3, "B"),
+).Register()
+
+// Integer enum: encoded as variant index instead of fixed-width integer.
+type Color uint8
+
+const (
+	Red Color = iota
+	Green
+	Blue Color = 10
+)
+
+var _ = bcs.RegisterIntEnumType(map[Color]string{Red: "Red", Green: "Green", Blue: "Blue"})
+
+func (c Color) String() string {
+	return bcs.IntEnumName(c)
+}
+
+type WithEnums struct {
+	Enum       Enum
+	Enums      []Enum
+	Sparse     SparseEnum
+	StructEnum StructEnum
+	Optional   *StructEnum `bcs:"optional"`
+	WithIDs    StructEnumWithIDs
+	Color      Color
+	Colors     []Color
+	Built      BuiltEnum
+}
+
+// Types, which are not known to the generator, are encoded using reflection.
+type WithFallback struct {
+	Big     big.Int
+	Big64   big.Int `bcs:"bigint=u64"`
+	Kind    reflect.Kind
+	Custom  Custom
+	Generic Generic[uint8]
+	Any     any `bcs:"optional,not_enum"`
+	// Unknown variant consumes the rest of input, so it must be the last.
+	Extensible ExtensibleEnum
+}
+
+type Custom struct {
+	V uint16
+}
+
+func (c *Custom) MarshalBCS(e *bcs.Encoder) error {
+	e.WriteCompactUint64(uint64(c.V))
+	return nil
+}
+
+func (c *Custom) UnmarshalBCS(d *bcs.Decoder) error {
+	c.V = uint16(d.ReadCompactUint64()) //nolint:gosec
+	return nil
+}
+
+type Generic[T any] struct {
+	V T
+}
+
+type WithInit struct {
+	V       uint32
+	Doubled uint32 `bcs:"-"`
+}
+
+func (w *WithInit) BCSInit() error {
+	w.Doubled = w.V * 2
+	return nil
+}
+
+type HasInit struct {
+	V WithInit
+}
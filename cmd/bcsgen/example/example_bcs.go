@@ -0,0 +1,1274 @@
+// Code generated by bcsgen. DO NOT EDIT.
+
+package example
+
+import (
+	"bytes"
+	"reflect"
+	"sort"
+	"unsafe"
+
+	"github.com/iotaledger/bcs-go"
+)
+
+var bcsFieldOptionsOptions = func() []bcs.FieldOptions {
+	opts, _, err := bcs.FieldOptionsFromStruct(reflect.TypeOf((*Options)(nil)).Elem(), "bcs")
+	if err != nil {
+		panic(err)
+	}
+	return opts
+}()
+
+var bcsFieldOptionsNested = func() []bcs.FieldOptions {
+	opts, _, err := bcs.FieldOptionsFromStruct(reflect.TypeOf((*Nested)(nil)).Elem(), "bcs")
+	if err != nil {
+		panic(err)
+	}
+	return opts
+}()
+
+var bcsFieldOptionsStructEnumWithIDs = func() []bcs.FieldOptions {
+	opts, _, err := bcs.FieldOptionsFromStruct(reflect.TypeOf((*StructEnumWithIDs)(nil)).Elem(), "bcs")
+	if err != nil {
+		panic(err)
+	}
+	return opts
+}()
+
+var bcsFieldOptionsStructEnumWithOpts = func() []bcs.FieldOptions {
+	opts, _, err := bcs.FieldOptionsFromStruct(reflect.TypeOf((*StructEnumWithOpts)(nil)).Elem(), "bcs")
+	if err != nil {
+		panic(err)
+	}
+	return opts
+}()
+
+var bcsFieldOptionsWithEnums = func() []bcs.FieldOptions {
+	opts, _, err := bcs.FieldOptionsFromStruct(reflect.TypeOf((*WithEnums)(nil)).Elem(), "bcs")
+	if err != nil {
+		panic(err)
+	}
+	return opts
+}()
+
+var bcsFieldOptionsWithFallback = func() []bcs.FieldOptions {
+	opts, _, err := bcs.FieldOptionsFromStruct(reflect.TypeOf((*WithFallback)(nil)).Elem(), "bcs")
+	if err != nil {
+		panic(err)
+	}
+	return opts
+}()
+
+// MarshalBCS implements bcs.Encodable.
+func (v *Basic) MarshalBCS(e *bcs.Encoder) error {
+	e.WriteBool(v.A)
+	e.WriteInt8(v.B)
+	e.WriteUint8(v.C)
+	e.WriteInt16(v.D)
+	e.WriteUint16(v.E)
+	e.WriteInt32(v.F)
+	e.WriteUint32(v.G)
+	e.WriteInt64(v.H)
+	e.WriteUint64(v.I)
+	e.WriteInt64(int64(v.J))
+	e.WriteUint64(uint64(v.K))
+	e.WriteString(v.L)
+	return e.Err()
+}
+
+// UnmarshalBCS implements bcs.Decodable.
+func (v *Basic) UnmarshalBCS(d *bcs.Decoder) error {
+	v.A = d.ReadBool()
+	v.B = d.ReadInt8()
+	v.C = d.ReadUint8()
+	v.D = d.ReadInt16()
+	v.E = d.ReadUint16()
+	v.F = d.ReadInt32()
+	v.G = d.ReadUint32()
+	v.H = d.ReadInt64()
+	v.I = d.ReadUint64()
+	v.J = int(d.ReadInt64())
+	v.K = uint(d.ReadUint64())
+	v.L = d.ReadString()
+	return d.Err()
+}
+
+// MarshalBCS implements bcs.Encodable.
+func (v *Options) MarshalBCS(e *bcs.Encoder) error {
+	e.WriteCompactUint64(uint64(v.Compact))
+	e.WriteCompactUint64(uint64(v.CompactInt))
+	e.WriteCompactInt64(int64(v.ZigZag))
+	c1 := int16(v.Narrowed)
+	if int64(c1) != int64(v.Narrowed) {
+		return e.KindErrorf(bcs.ErrOverflow, "value %v is out of range of type %T", int64(v.Narrowed), c1)
+	}
+	e.WriteInt16(c1)
+	c2 := uint32(v.Widened)
+	if uint64(c2) != uint64(v.Widened) {
+		return e.KindErrorf(bcs.ErrOverflow, "value %v is out of range of type %T", uint64(v.Widened), c2)
+	}
+	e.WriteUint32(c2)
+	c3 := uint16(v.ToUnsigned)
+	if int64(c3) != int64(v.ToUnsigned) {
+		return e.KindErrorf(bcs.ErrOverflow, "value %v is out of range of type %T", int64(v.ToUnsigned), c3)
+	}
+	e.WriteUint16(c3)
+	if len(v.Len2) > 0xFFFF {
+		return e.KindErrorf(bcs.ErrOverflow, "slice length %v exceeds 2 bytes", len(v.Len2))
+	}
+	e.WriteLen(len(v.Len2))
+	for i4 := range v.Len2 {
+		e.WriteInt16(v.Len2[i4])
+	}
+	e.WriteLen(len(v.NilIfEmpty))
+	for i5 := range v.NilIfEmpty {
+		e.WriteString(v.NilIfEmpty[i5])
+	}
+	for i6 := range v.ElemCompact {
+		e.WriteCompactUint64(uint64(v.ElemCompact[i6]))
+	}
+	if v.KeyValue == nil {
+		return e.KindErrorf(bcs.ErrNilValue, "attempt to encode non-optional nil-map")
+	}
+	e.WriteLen(len(v.KeyValue))
+	keyBuf10 := bcs.NewBytesEncoderWithOpts(e.Config())
+	keyEnc11 := &keyBuf10.Encoder
+	entries7 := make([]struct {
+		key   []byte
+		value int64
+	}, 0, len(v.KeyValue))
+	for k8, val9 := range v.KeyValue {
+		keyStart12 := len(keyBuf10.Bytes())
+		keyEnc11.WriteCompactUint64(uint64(k8))
+		if err := keyEnc11.Err(); err != nil {
+			return err
+		}
+		entries7 = append(entries7, struct {
+			key   []byte
+			value int64
+		}{keyBuf10.Bytes()[keyStart12:], val9})
+	}
+	sort.Slice(entries7, func(i, j int) bool {
+		return bytes.Compare(entries7[i].key, entries7[j].key) < 0
+	})
+	for j13 := range entries7 {
+		_, _ = e.Write(entries7[j13].key)
+		c14 := int8(entries7[j13].value)
+		if int64(c14) != int64(entries7[j13].value) {
+			return e.KindErrorf(bcs.ErrOverflow, "value %v is out of range of type %T", int64(entries7[j13].value), c14)
+		}
+		e.WriteInt8(c14)
+	}
+	if err := e.EncodeAsByteArray(func() error {
+		e.WriteLen(len(v.ByteArr))
+		for i15 := range v.ByteArr {
+			e.WriteUint64(v.ByteArr[i15])
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	e.WriteLen(len(v.ElemByteArr))
+	for i16 := range v.ElemByteArr {
+		if err := e.EncodeAsByteArray(func() error {
+			e.WriteLen(len(v.ElemByteArr[i16]))
+			_, _ = e.Write(v.ElemByteArr[i16])
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	if v.Optional == nil {
+		e.WriteOptionalFlag(false)
+	} else {
+		e.WriteOptionalFlag(true)
+		if err := v.Optional.MarshalBCS(e); err != nil {
+			return err
+		}
+	}
+	if v.OptionalMap == nil {
+		e.WriteOptionalFlag(false)
+	} else {
+		e.WriteOptionalFlag(true)
+		e.WriteLen(len(v.OptionalMap))
+		keyBuf20 := bcs.NewBytesEncoderWithOpts(e.Config())
+		keyEnc21 := &keyBuf20.Encoder
+		entries17 := make([]struct {
+			key   []byte
+			value bool
+		}, 0, len(v.OptionalMap))
+		for k18, val19 := range v.OptionalMap {
+			keyStart22 := len(keyBuf20.Bytes())
+			keyEnc21.WriteString(k18)
+			if err := keyEnc21.Err(); err != nil {
+				return err
+			}
+			entries17 = append(entries17, struct {
+				key   []byte
+				value bool
+			}{keyBuf20.Bytes()[keyStart22:], val19})
+		}
+		sort.Slice(entries17, func(i, j int) bool {
+			return bytes.Compare(entries17[i].key, entries17[j].key) < 0
+		})
+		for j23 := range entries17 {
+			_, _ = e.Write(entries17[j23].key)
+			e.WriteBool(entries17[j23].value)
+		}
+	}
+	e.WriteUint16(v.exported)
+	e.WriteCompactUint64(uint64(v.TypeOptions))
+	return e.Err()
+}
+
+// UnmarshalBCS implements bcs.Decodable.
+func (v *Options) UnmarshalBCS(d *bcs.Decoder) error {
+	v.Compact = d.ReadCompactUint64()
+	r1 := int64(d.ReadCompactUint64())
+	if int64(int32(r1)) != r1 {
+		return d.KindErrorf(bcs.ErrOverflow, "value %v is out of range of type %T", r1, int32(0))
+	}
+	v.CompactInt = int32(r1)
+	r2 := d.ReadCompactInt64()
+	if int64(int16(r2)) != r2 {
+		return d.KindErrorf(bcs.ErrOverflow, "value %v is out of range of type %T", r2, int16(0))
+	}
+	v.ZigZag = int16(r2)
+	r3 := d.ReadInt16()
+	if int16(int64(r3)) != r3 {
+		return d.KindErrorf(bcs.ErrOverflow, "value %v is out of range of type %T", r3, int64(0))
+	}
+	v.Narrowed = int64(r3)
+	r4 := d.ReadUint32()
+	if uint32(uint8(r4)) != r4 {
+		return d.KindErrorf(bcs.ErrOverflow, "value %v is out of range of type %T", r4, uint8(0))
+	}
+	v.Widened = uint8(r4)
+	r5 := d.ReadUint16()
+	if uint16(int64(r5)) != r5 {
+		return d.KindErrorf(bcs.ErrOverflow, "value %v is out of range of type %T", r5, int64(0))
+	}
+	v.ToUnsigned = int(r5)
+	n6 := d.ReadCollectionLen(int(unsafe.Sizeof(*new(int16))))
+	if n6 > 0xFFFF {
+		return d.KindErrorf(bcs.ErrOverflow, "array size exceeds 2 bytes: %v", n6)
+	}
+	{
+		v.Len2 = make([]int16, 0, min(n6, 100))
+		for i7 := 0; i7 < n6; i7++ {
+			v.Len2 = append(v.Len2, *new(int16))
+			v.Len2[i7] = d.ReadInt16()
+			if err := d.Err(); err != nil {
+				return err
+			}
+		}
+	}
+	n8 := d.ReadCollectionLen(int(unsafe.Sizeof(*new(string))))
+	if n8 > 0 {
+		v.NilIfEmpty = make([]string, 0, min(n8, 100))
+		for i9 := 0; i9 < n8; i9++ {
+			v.NilIfEmpty = append(v.NilIfEmpty, *new(string))
+			v.NilIfEmpty[i9] = d.ReadString()
+			if err := d.Err(); err != nil {
+				return err
+			}
+		}
+	}
+	for i10 := range v.ElemCompact {
+		r11 := d.ReadCompactUint64()
+		if uint64(uint32(r11)) != r11 {
+			return d.KindErrorf(bcs.ErrOverflow, "value %v is out of range of type %T", r11, uint32(0))
+		}
+		v.ElemCompact[i10] = uint32(r11)
+		if err := d.Err(); err != nil {
+			return err
+		}
+	}
+	n12 := d.ReadCollectionLen(int(unsafe.Sizeof(*new(uint32)) + unsafe.Sizeof(*new(int64))))
+	v.KeyValue = make(map[uint32]int64, min(n12, 100))
+	var prevKey13 []byte
+	for i14 := 0; i14 < n12; i14++ {
+		var k15 uint32
+		var err error
+		prevKey13, err = d.DecodeMapKey(i14, prevKey13, func() error {
+			r17 := d.ReadCompactUint64()
+			if uint64(uint32(r17)) != r17 {
+				return d.KindErrorf(bcs.ErrOverflow, "value %v is out of range of type %T", r17, uint32(0))
+			}
+			k15 = uint32(r17)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		var val16 int64
+		r18 := d.ReadInt8()
+		if int8(int64(r18)) != r18 {
+			return d.KindErrorf(bcs.ErrOverflow, "value %v is out of range of type %T", r18, int64(0))
+		}
+		val16 = int64(r18)
+		if err := d.Err(); err != nil {
+			return err
+		}
+		v.KeyValue[k15] = val16
+	}
+	if err := d.DecodeAsByteArray(func() error {
+		n19 := d.ReadCollectionLen(int(unsafe.Sizeof(*new(uint64))))
+		{
+			v.ByteArr = make([]uint64, 0, min(n19, 100))
+			for i20 := 0; i20 < n19; i20++ {
+				v.ByteArr = append(v.ByteArr, *new(uint64))
+				v.ByteArr[i20] = d.ReadUint64()
+				if err := d.Err(); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	n21 := d.ReadCollectionLen(int(unsafe.Sizeof(*new([]byte))))
+	{
+		v.ElemByteArr = make([][]byte, 0, min(n21, 100))
+		for i22 := 0; i22 < n21; i22++ {
+			v.ElemByteArr = append(v.ElemByteArr, *new([]byte))
+			if err := d.DecodeAsByteArray(func() error {
+				b23 := d.ReadBytes()
+				v.ElemByteArr[i22] = b23
+				return nil
+			}); err != nil {
+				return err
+			}
+			if err := d.Err(); err != nil {
+				return err
+			}
+		}
+	}
+	if d.ReadOptionalFlag() {
+		if v.Optional == nil {
+			v.Optional = new(Basic)
+		}
+		if err := d.DecodeNested(func() error { return v.Optional.UnmarshalBCS(d) }); err != nil {
+			return err
+		}
+	}
+	if d.ReadOptionalFlag() {
+		n24 := d.ReadCollectionLen(int(unsafe.Sizeof(*new(string)) + unsafe.Sizeof(*new(bool))))
+		v.OptionalMap = make(map[string]bool, min(n24, 100))
+		var prevKey25 []byte
+		for i26 := 0; i26 < n24; i26++ {
+			var k27 string
+			var err error
+			prevKey25, err = d.DecodeMapKey(i26, prevKey25, func() error {
+				k27 = d.ReadString()
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			var val28 bool
+			val28 = d.ReadBool()
+			if err := d.Err(); err != nil {
+				return err
+			}
+			v.OptionalMap[k27] = val28
+		}
+	}
+	v.exported = d.ReadUint16()
+	r29 := d.ReadCompactUint64()
+	if uint64(uint32(r29)) != r29 {
+		return d.KindErrorf(bcs.ErrOverflow, "value %v is out of range of type %T", r29, uint32(0))
+	}
+	v.TypeOptions = Compact(r29)
+	return d.Err()
+}
+
+// MarshalBCS implements bcs.Encodable.
+func (v *Collections) MarshalBCS(e *bcs.Encoder) error {
+	e.WriteLen(len(v.Bytes))
+	_, _ = e.Write(v.Bytes)
+	e.WriteLen(len(v.NamedBytes))
+	_, _ = e.Write(v.NamedBytes)
+	e.WriteLen(len(v.MyBytes))
+	for i1 := range v.MyBytes {
+		e.WriteUint8(uint8(v.MyBytes[i1]))
+	}
+	_, _ = e.Write(v.ByteArray[:])
+	e.WriteLen(len(v.Nested))
+	for i2 := range v.Nested {
+		e.WriteLen(len(v.Nested[i2]))
+		for i3 := range v.Nested[i2] {
+			e.WriteString(v.Nested[i2][i3])
+		}
+	}
+	if v.Map == nil {
+		return e.KindErrorf(bcs.ErrNilValue, "attempt to encode non-optional nil-map")
+	}
+	e.WriteLen(len(v.Map))
+	keyBuf7 := bcs.NewBytesEncoderWithOpts(e.Config())
+	keyEnc8 := &keyBuf7.Encoder
+	entries4 := make([]struct {
+		key   []byte
+		value []uint16
+	}, 0, len(v.Map))
+	for k5, val6 := range v.Map {
+		keyStart9 := len(keyBuf7.Bytes())
+		keyEnc8.WriteString(k5)
+		if err := keyEnc8.Err(); err != nil {
+			return err
+		}
+		entries4 = append(entries4, struct {
+			key   []byte
+			value []uint16
+		}{keyBuf7.Bytes()[keyStart9:], val6})
+	}
+	sort.Slice(entries4, func(i, j int) bool {
+		return bytes.Compare(entries4[i].key, entries4[j].key) < 0
+	})
+	for j10 := range entries4 {
+		_, _ = e.Write(entries4[j10].key)
+		e.WriteLen(len(entries4[j10].value))
+		for i11 := range entries4[j10].value {
+			e.WriteUint16(entries4[j10].value[i11])
+		}
+	}
+	if v.NestedMap == nil {
+		return e.KindErrorf(bcs.ErrNilValue, "attempt to encode non-optional nil-map")
+	}
+	e.WriteLen(len(v.NestedMap))
+	keyBuf15 := bcs.NewBytesEncoderWithOpts(e.Config())
+	keyEnc16 := &keyBuf15.Encoder
+	entries12 := make([]struct {
+		key   []byte
+		value map[bool]string
+	}, 0, len(v.NestedMap))
+	for k13, val14 := range v.NestedMap {
+		keyStart17 := len(keyBuf15.Bytes())
+		keyEnc16.WriteInt16(k13)
+		if err := keyEnc16.Err(); err != nil {
+			return err
+		}
+		entries12 = append(entries12, struct {
+			key   []byte
+			value map[bool]string
+		}{keyBuf15.Bytes()[keyStart17:], val14})
+	}
+	sort.Slice(entries12, func(i, j int) bool {
+		return bytes.Compare(entries12[i].key, entries12[j].key) < 0
+	})
+	for j18 := range entries12 {
+		_, _ = e.Write(entries12[j18].key)
+		if entries12[j18].value == nil {
+			return e.KindErrorf(bcs.ErrNilValue, "attempt to encode non-optional nil-map")
+		}
+		e.WriteLen(len(entries12[j18].value))
+		keyBuf22 := bcs.NewBytesEncoderWithOpts(e.Config())
+		keyEnc23 := &keyBuf22.Encoder
+		entries19 := make([]struct {
+			key   []byte
+			value string
+		}, 0, len(entries12[j18].value))
+		for k20, val21 := range entries12[j18].value {
+			keyStart24 := len(keyBuf22.Bytes())
+			keyEnc23.WriteBool(k20)
+			if err := keyEnc23.Err(); err != nil {
+				return err
+			}
+			entries19 = append(entries19, struct {
+				key   []byte
+				value string
+			}{keyBuf22.Bytes()[keyStart24:], val21})
+		}
+		sort.Slice(entries19, func(i, j int) bool {
+			return bytes.Compare(entries19[i].key, entries19[j].key) < 0
+		})
+		for j25 := range entries19 {
+			_, _ = e.Write(entries19[j25].key)
+			e.WriteString(entries19[j25].value)
+		}
+	}
+	if v.Ptr == nil {
+		return e.KindErrorf(bcs.ErrNilValue, "attempt to encode non-optional nil value of type %T", v.Ptr)
+	}
+	e.WriteInt32(*v.Ptr)
+	if v.PtrPtr == nil {
+		return e.KindErrorf(bcs.ErrNilValue, "attempt to encode non-optional nil value of type %T", v.PtrPtr)
+	}
+	if *v.PtrPtr == nil {
+		return e.KindErrorf(bcs.ErrNilValue, "attempt to encode non-optional nil value of type %T", *v.PtrPtr)
+	}
+	e.WriteString(*(*v.PtrPtr))
+	e.WriteLen(len(v.StructSlice))
+	for i26 := range v.StructSlice {
+		if err := v.StructSlice[i26].MarshalBCS(e); err != nil {
+			return err
+		}
+	}
+	for i27 := range v.Array {
+		if err := v.Array[i27].MarshalBCS(e); err != nil {
+			return err
+		}
+	}
+	return e.Err()
+}
+
+// UnmarshalBCS implements bcs.Decodable.
+func (v *Collections) UnmarshalBCS(d *bcs.Decoder) error {
+	b1 := d.ReadBytes()
+	v.Bytes = b1
+	b2 := d.ReadBytes()
+	v.NamedBytes = Bytes(b2)
+	b3 := d.ReadBytes()
+	v.MyBytes = make([]MyByte, len(b3))
+	for i4 := range b3 {
+		v.MyBytes[i4] = MyByte(b3[i4])
+	}
+	_, _ = d.Read(v.ByteArray[:])
+	n6 := d.ReadCollectionLen(int(unsafe.Sizeof(*new([]string))))
+	{
+		v.Nested = make([][]string, 0, min(n6, 100))
+		for i7 := 0; i7 < n6; i7++ {
+			v.Nested = append(v.Nested, *new([]string))
+			n8 := d.ReadCollectionLen(int(unsafe.Sizeof(*new(string))))
+			{
+				v.Nested[i7] = make([]string, 0, min(n8, 100))
+				for i9 := 0; i9 < n8; i9++ {
+					v.Nested[i7] = append(v.Nested[i7], *new(string))
+					v.Nested[i7][i9] = d.ReadString()
+					if err := d.Err(); err != nil {
+						return err
+					}
+				}
+			}
+			if err := d.Err(); err != nil {
+				return err
+			}
+		}
+	}
+	n10 := d.ReadCollectionLen(int(unsafe.Sizeof(*new(string)) + unsafe.Sizeof(*new([]uint16))))
+	v.Map = make(map[string][]uint16, min(n10, 100))
+	var prevKey11 []byte
+	for i12 := 0; i12 < n10; i12++ {
+		var k13 string
+		var err error
+		prevKey11, err = d.DecodeMapKey(i12, prevKey11, func() error {
+			k13 = d.ReadString()
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		var val14 []uint16
+		n15 := d.ReadCollectionLen(int(unsafe.Sizeof(*new(uint16))))
+		{
+			val14 = make([]uint16, 0, min(n15, 100))
+			for i16 := 0; i16 < n15; i16++ {
+				val14 = append(val14, *new(uint16))
+				val14[i16] = d.ReadUint16()
+				if err := d.Err(); err != nil {
+					return err
+				}
+			}
+		}
+		if err := d.Err(); err != nil {
+			return err
+		}
+		v.Map[k13] = val14
+	}
+	n17 := d.ReadCollectionLen(int(unsafe.Sizeof(*new(int16)) + unsafe.Sizeof(*new(map[bool]string))))
+	v.NestedMap = make(map[int16]map[bool]string, min(n17, 100))
+	var prevKey18 []byte
+	for i19 := 0; i19 < n17; i19++ {
+		var k20 int16
+		var err error
+		prevKey18, err = d.DecodeMapKey(i19, prevKey18, func() error {
+			k20 = d.ReadInt16()
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		var val21 map[bool]string
+		n22 := d.ReadCollectionLen(int(unsafe.Sizeof(*new(bool)) + unsafe.Sizeof(*new(string))))
+		val21 = make(map[bool]string, min(n22, 100))
+		var prevKey23 []byte
+		for i24 := 0; i24 < n22; i24++ {
+			var k25 bool
+			var err error
+			prevKey23, err = d.DecodeMapKey(i24, prevKey23, func() error {
+				k25 = d.ReadBool()
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			var val26 string
+			val26 = d.ReadString()
+			if err := d.Err(); err != nil {
+				return err
+			}
+			val21[k25] = val26
+		}
+		if err := d.Err(); err != nil {
+			return err
+		}
+		v.NestedMap[k20] = val21
+	}
+	if v.Ptr == nil {
+		v.Ptr = new(int32)
+	}
+	*v.Ptr = d.ReadInt32()
+	if v.PtrPtr == nil {
+		v.PtrPtr = new(*string)
+	}
+	if *v.PtrPtr == nil {
+		*v.PtrPtr = new(string)
+	}
+	*(*v.PtrPtr) = d.ReadString()
+	n27 := d.ReadCollectionLen(int(unsafe.Sizeof(*new(Basic))))
+	{
+		v.StructSlice = make([]Basic, 0, min(n27, 100))
+		for i28 := 0; i28 < n27; i28++ {
+			v.StructSlice = append(v.StructSlice, *new(Basic))
+			if err := d.DecodeNested(func() error { return v.StructSlice[i28].UnmarshalBCS(d) }); err != nil {
+				return err
+			}
+			if err := d.Err(); err != nil {
+				return err
+			}
+		}
+	}
+	for i29 := range v.Array {
+		if err := d.DecodeNested(func() error { return v.Array[i29].UnmarshalBCS(d) }); err != nil {
+			return err
+		}
+		if err := d.Err(); err != nil {
+			return err
+		}
+	}
+	return d.Err()
+}
+
+// MarshalBCS implements bcs.Encodable.
+func (v *Nested) MarshalBCS(e *bcs.Encoder) error {
+	e.WriteString(v.Name)
+	if v.Inner == nil {
+		e.WriteOptionalFlag(false)
+	} else {
+		e.WriteOptionalFlag(true)
+		if err := v.Inner.MarshalBCS(e); err != nil {
+			return err
+		}
+	}
+	return e.Err()
+}
+
+// UnmarshalBCS implements bcs.Decodable.
+func (v *Nested) UnmarshalBCS(d *bcs.Decoder) error {
+	v.Name = d.ReadString()
+	if d.ReadOptionalFlag() {
+		if v.Inner == nil {
+			v.Inner = new(Nested)
+		}
+		if err := d.DecodeNested(func() error { return v.Inner.UnmarshalBCS(d) }); err != nil {
+			return err
+		}
+	}
+	return d.Err()
+}
+
+// MarshalBCS implements bcs.Encodable.
+func (v *VariantA) MarshalBCS(e *bcs.Encoder) error {
+	e.WriteUint16(v.V)
+	return e.Err()
+}
+
+// UnmarshalBCS implements bcs.Decodable.
+func (v *VariantA) UnmarshalBCS(d *bcs.Decoder) error {
+	v.V = d.ReadUint16()
+	return d.Err()
+}
+
+// MarshalBCS implements bcs.Encodable.
+func (v *StructEnum) MarshalBCS(e *bcs.Encoder) error {
+	fieldIdx := -1
+	if v.A != nil {
+		if fieldIdx != -1 {
+			return e.KindErrorf(bcs.ErrInvalidEnumVariant, "multiple options are set in enum struct %v", "StructEnum")
+		}
+		fieldIdx = 0
+	}
+	if v.B != nil {
+		if fieldIdx != -1 {
+			return e.KindErrorf(bcs.ErrInvalidEnumVariant, "multiple options are set in enum struct %v", "StructEnum")
+		}
+		fieldIdx = 1
+	}
+	if v.C != nil {
+		if fieldIdx != -1 {
+			return e.KindErrorf(bcs.ErrInvalidEnumVariant, "multiple options are set in enum struct %v", "StructEnum")
+		}
+		fieldIdx = 2
+	}
+	if v.D != nil {
+		if fieldIdx != -1 {
+			return e.KindErrorf(bcs.ErrInvalidEnumVariant, "multiple options are set in enum struct %v", "StructEnum")
+		}
+		fieldIdx = 3
+	}
+	switch fieldIdx {
+	case 0:
+		e.WriteEnumIdx(0)
+		e.WriteInt32(*v.A)
+	case 1:
+		e.WriteEnumIdx(1)
+		if err := v.B.MarshalBCS(e); err != nil {
+			return err
+		}
+	case 2:
+		e.WriteEnumIdx(2)
+		e.WriteLen(len(v.C))
+		for i1 := range v.C {
+			e.WriteString(v.C[i1])
+		}
+	case 3:
+		e.WriteEnumIdx(3)
+		switch variant2 := v.D.(type) {
+		case nil:
+			e.WriteEnumIdx(0)
+		case VariantA:
+			e.WriteEnumIdx(1)
+			if err := variant2.MarshalBCS(e); err != nil {
+				return err
+			}
+		case *VariantB:
+			e.WriteEnumIdx(2)
+			if variant2 == nil {
+				return e.KindErrorf(bcs.ErrNilValue, "attempt to encode non-optional nil value of type %T", variant2)
+			}
+			e.WriteString(string(*variant2))
+		default:
+			return e.KindErrorf(bcs.ErrInvalidEnumVariant, "variant %T is not registered as part of enum type %v", v.D, "Enum")
+		}
+	default:
+		return e.KindErrorf(bcs.ErrInvalidEnumVariant, "no options are set in enum struct %v", "StructEnum")
+	}
+	return e.Err()
+}
+
+// UnmarshalBCS implements bcs.Decodable.
+func (v *StructEnum) UnmarshalBCS(d *bcs.Decoder) error {
+	variantIdx := d.ReadEnumIdx()
+	if err := d.Err(); err != nil {
+		return err
+	}
+	switch variantIdx {
+	case 0:
+		if v.A == nil {
+			v.A = new(int32)
+		}
+		*v.A = d.ReadInt32()
+	case 1:
+		if v.B == nil {
+			v.B = new(Basic)
+		}
+		if err := d.DecodeNested(func() error { return v.B.UnmarshalBCS(d) }); err != nil {
+			return err
+		}
+	case 2:
+		n1 := d.ReadCollectionLen(int(unsafe.Sizeof(*new(string))))
+		{
+			v.C = make([]string, 0, min(n1, 100))
+			for i2 := 0; i2 < n1; i2++ {
+				v.C = append(v.C, *new(string))
+				v.C[i2] = d.ReadString()
+				if err := d.Err(); err != nil {
+					return err
+				}
+			}
+		}
+	case 3:
+		variantIdx3 := d.ReadEnumIdx()
+		if err := d.Err(); err != nil {
+			return err
+		}
+		switch variantIdx3 {
+		case 0:
+		case 1:
+			var variant4 VariantA
+			if err := d.DecodeNested(func() error { return variant4.UnmarshalBCS(d) }); err != nil {
+				return err
+			}
+			v.D = variant4
+		case 2:
+			var variant5 *VariantB
+			if variant5 == nil {
+				variant5 = new(VariantB)
+			}
+			*variant5 = VariantB(d.ReadString())
+			v.D = variant5
+		default:
+			return d.KindErrorf(bcs.ErrInvalidEnumVariant, "invalid variant index %v for enum %v", variantIdx3, "Enum")
+		}
+	default:
+		return d.KindErrorf(bcs.ErrInvalidEnumVariant, "invalid variant index %v for enum %v", variantIdx, "StructEnum")
+	}
+	return d.Err()
+}
+
+// MarshalBCS implements bcs.Encodable.
+func (v *StructEnumWithIDs) MarshalBCS(e *bcs.Encoder) error {
+	fieldIdx := -1
+	if v.A != nil {
+		if fieldIdx != -1 {
+			return e.KindErrorf(bcs.ErrInvalidEnumVariant, "multiple options are set in enum struct %v", "StructEnumWithIDs")
+		}
+		fieldIdx = 0
+	}
+	if v.Empty != nil {
+		if fieldIdx != -1 {
+			return e.KindErrorf(bcs.ErrInvalidEnumVariant, "multiple options are set in enum struct %v", "StructEnumWithIDs")
+		}
+		fieldIdx = 1
+	}
+	if v.None != nil {
+		if fieldIdx != -1 {
+			return e.KindErrorf(bcs.ErrInvalidEnumVariant, "multiple options are set in enum struct %v", "StructEnumWithIDs")
+		}
+		fieldIdx = 2
+	}
+	if v.B != nil {
+		if fieldIdx != -1 {
+			return e.KindErrorf(bcs.ErrInvalidEnumVariant, "multiple options are set in enum struct %v", "StructEnumWithIDs")
+		}
+		fieldIdx = 3
+	}
+	switch fieldIdx {
+	case 0:
+		e.WriteEnumIdx(2)
+		e.WriteInt32(*v.A)
+	case 1:
+		e.WriteEnumIdx(1)
+	case 2:
+		e.WriteEnumIdx(5)
+	case 3:
+		e.WriteEnumIdx(3)
+		if err := v.B.MarshalBCS(e); err != nil {
+			return err
+		}
+	default:
+		return e.KindErrorf(bcs.ErrInvalidEnumVariant, "no options are set in enum struct %v", "StructEnumWithIDs")
+	}
+	return e.Err()
+}
+
+// UnmarshalBCS implements bcs.Decodable.
+func (v *StructEnumWithIDs) UnmarshalBCS(d *bcs.Decoder) error {
+	variantIdx := d.ReadEnumIdx()
+	if err := d.Err(); err != nil {
+		return err
+	}
+	switch variantIdx {
+	case 2:
+		if v.A == nil {
+			v.A = new(int32)
+		}
+		*v.A = d.ReadInt32()
+	case 1:
+		v.Empty = new(struct{})
+	case 5:
+		v.None = new(bcs.None)
+	case 3:
+		if v.B == nil {
+			v.B = new(Basic)
+		}
+		if err := d.DecodeNested(func() error { return v.B.UnmarshalBCS(d) }); err != nil {
+			return err
+		}
+	default:
+		return d.KindErrorf(bcs.ErrInvalidEnumVariant, "invalid variant index %v for enum %v", variantIdx, "StructEnumWithIDs")
+	}
+	return d.Err()
+}
+
+// MarshalBCS implements bcs.Encodable.
+func (v *StructEnumWithOpts) MarshalBCS(e *bcs.Encoder) error {
+	fieldIdx := -1
+	if v.A != nil {
+		if fieldIdx != -1 {
+			return e.KindErrorf(bcs.ErrInvalidEnumVariant, "multiple options are set in enum struct %v", "StructEnumWithOpts")
+		}
+		fieldIdx = 0
+	}
+	if v.B != nil {
+		if fieldIdx != -1 {
+			return e.KindErrorf(bcs.ErrInvalidEnumVariant, "multiple options are set in enum struct %v", "StructEnumWithOpts")
+		}
+		fieldIdx = 1
+	}
+	if v.C != nil {
+		if fieldIdx != -1 {
+			return e.KindErrorf(bcs.ErrInvalidEnumVariant, "multiple options are set in enum struct %v", "StructEnumWithOpts")
+		}
+		fieldIdx = 2
+	}
+	switch fieldIdx {
+	case 0:
+		e.WriteEnumIdx(0)
+		e.WriteCompactUint64(uint64((*v.A)))
+	case 1:
+		e.WriteEnumIdx(3)
+		if len((*v.B)) > 0xFFFF {
+			return e.KindErrorf(bcs.ErrOverflow, "slice length %v exceeds 2 bytes", len((*v.B)))
+		}
+		e.WriteLen(len((*v.B)))
+		for i1 := range *v.B {
+			e.WriteInt16((*v.B)[i1])
+		}
+	case 2:
+		e.WriteEnumIdx(2)
+		if err := e.EncodeAsByteArray(func() error {
+			if err := v.C.MarshalBCS(e); err != nil {
+				return err
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+	default:
+		return e.KindErrorf(bcs.ErrInvalidEnumVariant, "no options are set in enum struct %v", "StructEnumWithOpts")
+	}
+	return e.Err()
+}
+
+// UnmarshalBCS implements bcs.Decodable.
+func (v *StructEnumWithOpts) UnmarshalBCS(d *bcs.Decoder) error {
+	variantIdx := d.ReadEnumIdx()
+	if err := d.Err(); err != nil {
+		return err
+	}
+	switch variantIdx {
+	case 0:
+		if v.A == nil {
+			v.A = new(uint64)
+		}
+		*v.A = d.ReadCompactUint64()
+	case 3:
+		if v.B == nil {
+			v.B = new([]int16)
+		}
+		n1 := d.ReadCollectionLen(int(unsafe.Sizeof(*new(int16))))
+		if n1 > 0xFFFF {
+			return d.KindErrorf(bcs.ErrOverflow, "array size exceeds 2 bytes: %v", n1)
+		}
+		{
+			*v.B = make([]int16, 0, min(n1, 100))
+			for i2 := 0; i2 < n1; i2++ {
+				(*v.B) = append((*v.B), *new(int16))
+				(*v.B)[i2] = d.ReadInt16()
+				if err := d.Err(); err != nil {
+					return err
+				}
+			}
+		}
+	case 2:
+		if err := d.DecodeAsByteArray(func() error {
+			if v.C == nil {
+				v.C = new(Basic)
+			}
+			if err := d.DecodeNested(func() error { return v.C.UnmarshalBCS(d) }); err != nil {
+				return err
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+	default:
+		return d.KindErrorf(bcs.ErrInvalidEnumVariant, "invalid variant index %v for enum %v", variantIdx, "StructEnumWithOpts")
+	}
+	return d.Err()
+}
+
+// MarshalBCS implements bcs.Encodable.
+func (v *WithEnums) MarshalBCS(e *bcs.Encoder) error {
+	switch variant1 := v.Enum.(type) {
+	case nil:
+		e.WriteEnumIdx(0)
+	case VariantA:
+		e.WriteEnumIdx(1)
+		if err := variant1.MarshalBCS(e); err != nil {
+			return err
+		}
+	case *VariantB:
+		e.WriteEnumIdx(2)
+		if variant1 == nil {
+			return e.KindErrorf(bcs.ErrNilValue, "attempt to encode non-optional nil value of type %T", variant1)
+		}
+		e.WriteString(string(*variant1))
+	default:
+		return e.KindErrorf(bcs.ErrInvalidEnumVariant, "variant %T is not registered as part of enum type %v", v.Enum, "Enum")
+	}
+	e.WriteLen(len(v.Enums))
+	for i2 := range v.Enums {
+		switch variant3 := v.Enums[i2].(type) {
+		case nil:
+			e.WriteEnumIdx(0)
+		case VariantA:
+			e.WriteEnumIdx(1)
+			if err := variant3.MarshalBCS(e); err != nil {
+				return err
+			}
+		case *VariantB:
+			e.WriteEnumIdx(2)
+			if variant3 == nil {
+				return e.KindErrorf(bcs.ErrNilValue, "attempt to encode non-optional nil value of type %T", variant3)
+			}
+			e.WriteString(string(*variant3))
+		default:
+			return e.KindErrorf(bcs.ErrInvalidEnumVariant, "variant %T is not registered as part of enum type %v", v.Enums[i2], "Enum")
+		}
+	}
+	switch variant4 := v.Sparse.(type) {
+	case VariantA:
+		e.WriteEnumIdx(1)
+		if err := variant4.MarshalBCS(e); err != nil {
+			return err
+		}
+	case *VariantB:
+		e.WriteEnumIdx(5)
+		if variant4 == nil {
+			return e.KindErrorf(bcs.ErrNilValue, "attempt to encode non-optional nil value of type %T", variant4)
+		}
+		e.WriteString(string(*variant4))
+	default:
+		return e.KindErrorf(bcs.ErrInvalidEnumVariant, "variant %T is not registered as part of enum type %v", v.Sparse, "SparseEnum")
+	}
+	if err := v.StructEnum.MarshalBCS(e); err != nil {
+		return err
+	}
+	if v.Optional == nil {
+		e.WriteOptionalFlag(false)
+	} else {
+		e.WriteOptionalFlag(true)
+		if err := v.Optional.MarshalBCS(e); err != nil {
+			return err
+		}
+	}
+	if err := v.WithIDs.MarshalBCS(e); err != nil {
+		return err
+	}
+	e.Encode(&v.Color)
+	e.WriteLen(len(v.Colors))
+	for i5 := range v.Colors {
+		e.Encode(&v.Colors[i5])
+	}
+	switch variant6 := v.Built.(type) {
+	case nil:
+		e.WriteEnumIdx(0)
+	case VariantA:
+		e.WriteEnumIdx(1)
+		if err := variant6.MarshalBCS(e); err != nil {
+			return err
+		}
+	case *VariantB:
+		e.WriteEnumIdx(3)
+		if variant6 == nil {
+			return e.KindErrorf(bcs.ErrNilValue, "attempt to encode non-optional nil value of type %T", variant6)
+		}
+		e.WriteString(string(*variant6))
+	default:
+		return e.KindErrorf(bcs.ErrInvalidEnumVariant, "variant %T is not registered as part of enum type %v", v.Built, "BuiltEnum")
+	}
+	return e.Err()
+}
+
+// UnmarshalBCS implements bcs.Decodable.
+func (v *WithEnums) UnmarshalBCS(d *bcs.Decoder) error {
+	variantIdx1 := d.ReadEnumIdx()
+	if err := d.Err(); err != nil {
+		return err
+	}
+	switch variantIdx1 {
+	case 0:
+	case 1:
+		var variant2 VariantA
+		if err := d.DecodeNested(func() error { return variant2.UnmarshalBCS(d) }); err != nil {
+			return err
+		}
+		v.Enum = variant2
+	case 2:
+		var variant3 *VariantB
+		if variant3 == nil {
+			variant3 = new(VariantB)
+		}
+		*variant3 = VariantB(d.ReadString())
+		v.Enum = variant3
+	default:
+		return d.KindErrorf(bcs.ErrInvalidEnumVariant, "invalid variant index %v for enum %v", variantIdx1, "Enum")
+	}
+	n4 := d.ReadCollectionLen(int(unsafe.Sizeof(*new(Enum))))
+	{
+		v.Enums = make([]Enum, 0, min(n4, 100))
+		for i5 := 0; i5 < n4; i5++ {
+			v.Enums = append(v.Enums, *new(Enum))
+			variantIdx6 := d.ReadEnumIdx()
+			if err := d.Err(); err != nil {
+				return err
+			}
+			switch variantIdx6 {
+			case 0:
+			case 1:
+				var variant7 VariantA
+				if err := d.DecodeNested(func() error { return variant7.UnmarshalBCS(d) }); err != nil {
+					return err
+				}
+				v.Enums[i5] = variant7
+			case 2:
+				var variant8 *VariantB
+				if variant8 == nil {
+					variant8 = new(VariantB)
+				}
+				*variant8 = VariantB(d.ReadString())
+				v.Enums[i5] = variant8
+			default:
+				return d.KindErrorf(bcs.ErrInvalidEnumVariant, "invalid variant index %v for enum %v", variantIdx6, "Enum")
+			}
+			if err := d.Err(); err != nil {
+				return err
+			}
+		}
+	}
+	variantIdx9 := d.ReadEnumIdx()
+	if err := d.Err(); err != nil {
+		return err
+	}
+	switch variantIdx9 {
+	case 1:
+		var variant10 VariantA
+		if err := d.DecodeNested(func() error { return variant10.UnmarshalBCS(d) }); err != nil {
+			return err
+		}
+		v.Sparse = variant10
+	case 5:
+		var variant11 *VariantB
+		if variant11 == nil {
+			variant11 = new(VariantB)
+		}
+		*variant11 = VariantB(d.ReadString())
+		v.Sparse = variant11
+	default:
+		return d.KindErrorf(bcs.ErrInvalidEnumVariant, "invalid variant index %v for enum %v", variantIdx9, "SparseEnum")
+	}
+	if err := d.DecodeNested(func() error { return v.StructEnum.UnmarshalBCS(d) }); err != nil {
+		return err
+	}
+	if d.ReadOptionalFlag() {
+		if v.Optional == nil {
+			v.Optional = new(StructEnum)
+		}
+		if err := d.DecodeNested(func() error { return v.Optional.UnmarshalBCS(d) }); err != nil {
+			return err
+		}
+	}
+	if err := d.DecodeNested(func() error { return v.WithIDs.UnmarshalBCS(d) }); err != nil {
+		return err
+	}
+	d.Decode(&v.Color)
+	n12 := d.ReadCollectionLen(int(unsafe.Sizeof(*new(Color))))
+	{
+		v.Colors = make([]Color, 0, min(n12, 100))
+		for i13 := 0; i13 < n12; i13++ {
+			v.Colors = append(v.Colors, *new(Color))
+			d.Decode(&v.Colors[i13])
+			if err := d.Err(); err != nil {
+				return err
+			}
+		}
+	}
+	variantIdx14 := d.ReadEnumIdx()
+	if err := d.Err(); err != nil {
+		return err
+	}
+	switch variantIdx14 {
+	case 0:
+	case 1:
+		var variant15 VariantA
+		if err := d.DecodeNested(func() error { return variant15.UnmarshalBCS(d) }); err != nil {
+			return err
+		}
+		v.Built = variant15
+	case 3:
+		var variant16 *VariantB
+		if variant16 == nil {
+			variant16 = new(VariantB)
+		}
+		*variant16 = VariantB(d.ReadString())
+		v.Built = variant16
+	default:
+		return d.KindErrorf(bcs.ErrInvalidEnumVariant, "invalid variant index %v for enum %v", variantIdx14, "BuiltEnum")
+	}
+	return d.Err()
+}
+
+// MarshalBCS implements bcs.Encodable.
+func (v *WithFallback) MarshalBCS(e *bcs.Encoder) error {
+	e.Encode(&v.Big)
+	e.EncodeWithOptions(&v.Big64, &bcsFieldOptionsWithFallback[1].TypeOptions)
+	e.Encode(&v.Kind)
+	e.Encode(&v.Custom)
+	e.Encode(&v.Generic)
+	if v.Any == nil {
+		e.WriteOptionalFlag(false)
+	} else {
+		e.WriteOptionalFlag(true)
+		e.EncodeWithOptions(&v.Any, &bcsFieldOptionsWithFallback[5].TypeOptions)
+	}
+	e.Encode(&v.Extensible)
+	return e.Err()
+}
+
+// UnmarshalBCS implements bcs.Decodable.
+func (v *WithFallback) UnmarshalBCS(d *bcs.Decoder) error {
+	d.Decode(&v.Big)
+	d.DecodeWithOptions(&v.Big64, &bcsFieldOptionsWithFallback[1].TypeOptions)
+	d.Decode(&v.Kind)
+	d.Decode(&v.Custom)
+	d.Decode(&v.Generic)
+	if d.ReadOptionalFlag() {
+		d.DecodeWithOptions(&v.Any, &bcsFieldOptionsWithFallback[5].TypeOptions)
+	}
+	d.Decode(&v.Extensible)
+	return d.Err()
+}
+
+// MarshalBCS implements bcs.Encodable.
+func (v *WithInit) MarshalBCS(e *bcs.Encoder) error {
+	e.WriteUint32(v.V)
+	return e.Err()
+}
+
+// UnmarshalBCS implements bcs.Decodable.
+func (v *WithInit) UnmarshalBCS(d *bcs.Decoder) error {
+	v.V = d.ReadUint32()
+	return d.Err()
+}
+
+// MarshalBCS implements bcs.Encodable.
+func (v *HasInit) MarshalBCS(e *bcs.Encoder) error {
+	if err := v.V.MarshalBCS(e); err != nil {
+		return err
+	}
+	return e.Err()
+}
+
+// UnmarshalBCS implements bcs.Decodable.
+func (v *HasInit) UnmarshalBCS(d *bcs.Decoder) error {
+	if err := d.DecodeNested(func() error { return v.V.UnmarshalBCS(d) }); err != nil {
+		return err
+	}
+	if err := v.V.BCSInit(); err != nil {
+		return err
+	}
+	return d.Err()
+}
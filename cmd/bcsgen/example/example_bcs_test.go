@@ -0,0 +1,101 @@
+// Code generated by bcsgen. DO NOT EDIT.
+
+package example
+
+import (
+	"testing"
+
+	"github.com/iotaledger/bcs-go/bcstest"
+)
+
+// Same type without generated methods - it is encoded using reflection.
+type basicBCSReflective Basic
+
+func TestBasicBCSGenerated(t *testing.T) {
+	bcstest.TestGeneratedCodec[Basic, basicBCSReflective](t)
+}
+
+// Same type without generated methods - it is encoded using reflection.
+type optionsBCSReflective Options
+
+func TestOptionsBCSGenerated(t *testing.T) {
+	bcstest.TestGeneratedCodec[Options, optionsBCSReflective](t)
+}
+
+// Same type without generated methods - it is encoded using reflection.
+type collectionsBCSReflective Collections
+
+func TestCollectionsBCSGenerated(t *testing.T) {
+	bcstest.TestGeneratedCodec[Collections, collectionsBCSReflective](t)
+}
+
+// Same type without generated methods - it is encoded using reflection.
+type nestedBCSReflective Nested
+
+func TestNestedBCSGenerated(t *testing.T) {
+	bcstest.TestGeneratedCodec[Nested, nestedBCSReflective](t)
+}
+
+// Same type without generated methods - it is encoded using reflection.
+type variantABCSReflective VariantA
+
+func TestVariantABCSGenerated(t *testing.T) {
+	bcstest.TestGeneratedCodec[VariantA, variantABCSReflective](t)
+}
+
+// Same type without generated methods - it is encoded using reflection.
+type structEnumBCSReflective StructEnum
+
+func (structEnumBCSReflective) IsBcsEnum() {}
+
+func TestStructEnumBCSGenerated(t *testing.T) {
+	bcstest.TestGeneratedCodec[StructEnum, structEnumBCSReflective](t)
+}
+
+// Same type without generated methods - it is encoded using reflection.
+type structEnumWithIDsBCSReflective StructEnumWithIDs
+
+func (structEnumWithIDsBCSReflective) IsBcsEnum() {}
+
+func TestStructEnumWithIDsBCSGenerated(t *testing.T) {
+	bcstest.TestGeneratedCodec[StructEnumWithIDs, structEnumWithIDsBCSReflective](t)
+}
+
+// Same type without generated methods - it is encoded using reflection.
+type structEnumWithOptsBCSReflective StructEnumWithOpts
+
+func (structEnumWithOptsBCSReflective) IsBcsEnum() {}
+
+func TestStructEnumWithOptsBCSGenerated(t *testing.T) {
+	bcstest.TestGeneratedCodec[StructEnumWithOpts, structEnumWithOptsBCSReflective](t)
+}
+
+// Same type without generated methods - it is encoded using reflection.
+type withEnumsBCSReflective WithEnums
+
+func TestWithEnumsBCSGenerated(t *testing.T) {
+	bcstest.TestGeneratedCodec[WithEnums, withEnumsBCSReflective](t)
+}
+
+// Same type without generated methods - it is encoded using reflection.
+type withFallbackBCSReflective WithFallback
+
+func TestWithFallbackBCSGenerated(t *testing.T) {
+	bcstest.TestGeneratedCodec[WithFallback, withFallbackBCSReflective](t)
+}
+
+// Same type without generated methods - it is encoded using reflection.
+type withInitBCSReflective WithInit
+
+func (v *withInitBCSReflective) BCSInit() error { return (*WithInit)(v).BCSInit() }
+
+func TestWithInitBCSGenerated(t *testing.T) {
+	bcstest.TestGeneratedCodec[WithInit, withInitBCSReflective](t)
+}
+
+// Same type without generated methods - it is encoded using reflection.
+type hasInitBCSReflective HasInit
+
+func TestHasInitBCSGenerated(t *testing.T) {
+	bcstest.TestGeneratedCodec[HasInit, hasInitBCSReflective](t)
+}
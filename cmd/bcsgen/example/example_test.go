@@ -0,0 +1,56 @@
+package example_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotaledger/bcs-go"
+	"github.com/iotaledger/bcs-go/cmd/bcsgen/example"
+)
+
+func TestGeneratedDecoderMaxDepth(t *testing.T) {
+	v := &example.Nested{Name: "0"}
+	for i := 1; i < 1000; i++ {
+		v = &example.Nested{Name: "x", Inner: v}
+	}
+
+	b := bcs.MustMarshal(v)
+
+	decodeWithMaxDepth := func(maxDepth int) (example.Nested, error) {
+		d := bcs.NewBytesDecoderWithOpts(b, bcs.DecoderConfig{Limits: bcs.DecoderLimits{MaxDepth: maxDepth}})
+		return bcs.Decode[example.Nested](&d.Decoder), d.Err()
+	}
+
+	_, err := decodeWithMaxDepth(10)
+	require.ErrorIs(t, err, bcs.ErrLimitExceeded)
+	require.Contains(t, err.Error(), "MaxDepth exceeded: 11 > 10")
+
+	// Depth of generated decoder is same as of reflection-based one.
+	vDec, err := decodeWithMaxDepth(1000)
+	require.NoError(t, err)
+	require.Equal(t, *v, vDec)
+
+	_, err = decodeWithMaxDepth(999)
+	require.Contains(t, err.Error(), "MaxDepth exceeded: 1000 > 999")
+}
+
+func TestGeneratedCodecErrorKinds(t *testing.T) {
+	_, err := bcs.Marshal(&example.Options{Narrowed: 1 << 20, KeyValue: map[uint32]int64{}})
+	require.ErrorIs(t, err, bcs.ErrOverflow)
+	require.Contains(t, err.Error(), "value 1048576 is out of range of type int16")
+
+	var encErr *bcs.EncodeError
+	require.ErrorAs(t, err, &encErr)
+	require.Equal(t, "Options", encErr.Path)
+
+	_, err = bcs.Marshal(&example.StructEnum{})
+	require.ErrorIs(t, err, bcs.ErrInvalidEnumVariant)
+
+	_, err = bcs.Unmarshal[example.StructEnum]([]byte{0x9})
+	require.ErrorIs(t, err, bcs.ErrInvalidEnumVariant)
+
+	var decErr *bcs.DecodeError
+	require.ErrorAs(t, err, &decErr)
+	require.Equal(t, int64(1), decErr.Offset)
+}
@@ -0,0 +1,1372 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	bcs "github.com/iotaledger/bcs-go"
+)
+
+type generator struct {
+	pkg     *pkgInfo
+	tagName string
+	// Types, for which methods are generated
+	generated map[string]bool
+	// Variable names of encoder and decoder in the current scope
+	enc, dec string
+
+	buf    bytes.Buffer
+	varIdx int
+	// Values, which are already checked to be not nil
+	notNil  map[string]bool
+	imports map[string]string
+	// Types, which need runtime-parsed options of their fields
+	fieldOptsVars map[string]bool
+}
+
+func generate(pkg *pkgInfo, typeNames []string, tagName string) (code, testCode []byte, _ error) {
+	g := &generator{
+		pkg:           pkg,
+		tagName:       tagName,
+		generated:     make(map[string]bool),
+		imports:       make(map[string]string),
+		fieldOptsVars: make(map[string]bool),
+		notNil:        make(map[string]bool),
+	}
+
+	decls, err := g.selectTypes(typeNames)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, decl := range decls {
+		g.generated[decl.name] = true
+	}
+
+	var body bytes.Buffer
+
+	for _, decl := range decls {
+		g.buf.Reset()
+
+		if err := g.genType(decl); err != nil {
+			return nil, nil, fmt.Errorf("type %v: %w", decl.name, err)
+		}
+
+		body.Write(g.buf.Bytes())
+	}
+
+	g.buf.Reset()
+	for _, decl := range decls {
+		if g.fieldOptsVars[decl.name] {
+			g.useImport("reflect", "reflect")
+			g.p("var %v = func() []%v.FieldOptions {", fieldOptsVarName(decl.name), g.pkg.bcsName)
+			g.p("opts, _, err := %v.FieldOptionsFromStruct(reflect.TypeOf((*%v)(nil)).Elem(), %q)", g.pkg.bcsName, decl.name, g.tagName)
+			g.p("if err != nil {")
+			g.p("panic(err)")
+			g.p("}")
+			g.p("return opts")
+			g.p("}()")
+			g.p("")
+		}
+	}
+	optsVars := g.buf.String()
+
+	g.useImport(g.pkg.bcsName, bcsImportPath)
+	for name, path := range pkg.imports {
+		if strings.Contains(body.String(), name+".") {
+			g.useImport(name, path)
+		}
+	}
+
+	var out bytes.Buffer
+	out.WriteString(generatedByHeader + "\n\n")
+	fmt.Fprintf(&out, "package %v\n\n", pkg.name)
+	out.WriteString(g.importsBlock())
+	out.WriteString(optsVars)
+	out.Write(body.Bytes())
+
+	code, err = format.Source(out.Bytes())
+	if err != nil {
+		return nil, nil, fmt.Errorf("formatting generated code: %w\n%s", err, out.String())
+	}
+
+	testCode, err = g.genTests(decls)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return code, testCode, nil
+}
+
+func (g *generator) selectTypes(typeNames []string) ([]*typeDecl, error) {
+	if len(typeNames) == 0 {
+		var decls []*typeDecl
+
+		for _, f := range g.pkg.files {
+			for _, decl := range f.Decls {
+				ast.Inspect(decl, func(n ast.Node) bool {
+					spec, ok := n.(*ast.TypeSpec)
+					if !ok {
+						return true
+					}
+
+					d := g.pkg.decls[spec.Name.Name]
+					if d.spec == spec && g.checkGeneratable(d) == nil {
+						decls = append(decls, d)
+					}
+
+					return false
+				})
+			}
+		}
+
+		if len(decls) == 0 {
+			return nil, fmt.Errorf("no suitable struct types found in package %v", g.pkg.name)
+		}
+
+		return decls, nil
+	}
+
+	decls := make([]*typeDecl, 0, len(typeNames))
+
+	for _, name := range typeNames {
+		d := g.pkg.decls[strings.TrimSpace(name)]
+		if d == nil {
+			return nil, fmt.Errorf("type %v not found", name)
+		}
+		if err := g.checkGeneratable(d); err != nil {
+			return nil, fmt.Errorf("type %v: %w", name, err)
+		}
+
+		decls = append(decls, d)
+	}
+
+	return decls, nil
+}
+
+func (g *generator) checkGeneratable(d *typeDecl) error {
+	if _, ok := d.spec.Type.(*ast.StructType); !ok || d.spec.Assign.IsValid() {
+		return fmt.Errorf("not a struct type")
+	}
+	if d.spec.TypeParams != nil {
+		return fmt.Errorf("generic types are not supported")
+	}
+	for _, m := range []string{"MarshalBCS", "UnmarshalBCS", "Write", "Read"} {
+		if d.hasMethod(m) {
+			return fmt.Errorf("type already has method %v", m)
+		}
+	}
+
+	return nil
+}
+
+func (g *generator) p(format string, args ...any) {
+	fmt.Fprintf(&g.buf, format, args...)
+	g.buf.WriteByte('\n')
+}
+
+func (g *generator) newVar(prefix string) string {
+	g.varIdx++
+	return prefix + strconv.Itoa(g.varIdx)
+}
+
+func (g *generator) useImport(name, path string) {
+	g.imports[name] = path
+}
+
+func (g *generator) bcs(ident string) string {
+	return g.pkg.bcsName + "." + ident
+}
+
+// Returns expression, which records failure of given kind in encoder and returns it.
+// Failures are always recorded in the encoder of the method, even while map keys are encoded into separate buffer.
+func (g *generator) encodeErrorf(kind, format string, args ...string) string {
+	return g.errorf("e", kind, format, args...)
+}
+
+// Returns expression, which records failure of given kind in decoder and returns it.
+func (g *generator) decodeErrorf(kind, format string, args ...string) string {
+	return g.errorf("d", kind, format, args...)
+}
+
+func (g *generator) errorf(coder, kind, format string, args ...string) string {
+	s := fmt.Sprintf("%v.KindErrorf(%v, %v", coder, g.bcs(kind), strconv.Quote(format))
+	for _, arg := range args {
+		s += ", " + arg
+	}
+
+	return s + ")"
+}
+
+func (g *generator) importsBlock() string {
+	var std, other []string
+
+	for name, path := range g.imports {
+		line := strconv.Quote(path)
+		if name != importNameFromPath(path) {
+			line = name + " " + line
+		}
+
+		if strings.Contains(strings.Split(path, "/")[0], ".") {
+			other = append(other, line)
+		} else {
+			std = append(std, line)
+		}
+	}
+
+	sort.Strings(std)
+	sort.Strings(other)
+
+	var b strings.Builder
+	b.WriteString("import (\n")
+	for _, line := range std {
+		b.WriteString("\t" + line + "\n")
+	}
+	if len(std) > 0 && len(other) > 0 {
+		b.WriteString("\n")
+	}
+	for _, line := range other {
+		b.WriteString("\t" + line + "\n")
+	}
+	b.WriteString(")\n\n")
+
+	return b.String()
+}
+
+func fieldOptsVarName(typeName string) string {
+	return "bcsFieldOptions" + strings.ToUpper(typeName[:1]) + typeName[1:]
+}
+
+type fieldInfo struct {
+	name    string
+	idx     int
+	t       *typeRef
+	opts    bcs.FieldOptions
+	hasTag  bool
+	optsRef string
+}
+
+func (g *generator) structFields(decl *typeDecl) ([]fieldInfo, error) {
+	st := decl.spec.Type.(*ast.StructType)
+
+	var fields []fieldInfo
+
+	for _, field := range st.Fields.List {
+		t, err := g.pkg.resolveType(field.Type, decl.file, g.generated)
+		if err != nil {
+			return nil, err
+		}
+
+		names := make([]string, 0, len(field.Names))
+		for _, name := range field.Names {
+			names = append(names, name.Name)
+		}
+		if len(names) == 0 {
+			names = append(names, embeddedFieldName(field.Type))
+		}
+
+		opts, hasTag, err := fieldOptions(field, t, g.tagName)
+		if err != nil {
+			return nil, fmt.Errorf("field %v: %w", names[0], err)
+		}
+
+		for _, name := range names {
+			fields = append(fields, fieldInfo{name: name, idx: len(fields), t: t, opts: opts, hasTag: hasTag})
+		}
+	}
+
+	return fields, nil
+}
+
+func embeddedFieldName(e ast.Expr) string {
+	switch e := e.(type) {
+	case *ast.StarExpr:
+		return embeddedFieldName(e.X)
+	case *ast.SelectorExpr:
+		return e.Sel.Name
+	case *ast.IndexExpr:
+		return embeddedFieldName(e.X)
+	case *ast.IndexListExpr:
+		return embeddedFieldName(e.X)
+	case *ast.Ident:
+		return e.Name
+	default:
+		panic(fmt.Sprintf("unexpected embedded field type %T", e))
+	}
+}
+
+func (g *generator) genType(decl *typeDecl) error {
+	fields, err := g.structFields(decl)
+	if err != nil {
+		return err
+	}
+
+	isEnum := decl.hasMethod("IsBcsEnum")
+
+	g.varIdx = 0
+	g.notNil = make(map[string]bool)
+
+	g.p("// MarshalBCS implements %v.", g.bcs("Encodable"))
+	g.p("func (v *%v) MarshalBCS(e *%v) error {", decl.name, g.bcs("Encoder"))
+	g.enc = "e"
+	if isEnum {
+		err = g.genStructEnumEncode(decl, fields)
+	} else {
+		err = g.genStructEncode(decl, fields)
+	}
+	if err != nil {
+		return err
+	}
+	g.p("return e.Err()")
+	g.p("}")
+	g.p("")
+
+	g.varIdx = 0
+
+	g.p("// UnmarshalBCS implements %v.", g.bcs("Decodable"))
+	g.p("func (v *%v) UnmarshalBCS(d *%v) error {", decl.name, g.bcs("Decoder"))
+	g.dec = "d"
+	if isEnum {
+		err = g.genStructEnumDecode(decl, fields)
+	} else {
+		err = g.genStructDecode(decl, fields)
+	}
+	if err != nil {
+		return err
+	}
+	g.p("return d.Err()")
+	g.p("}")
+	g.p("")
+
+	return nil
+}
+
+// Returns fields, which are encoded, and sets reference to runtime options for fields, which have tags.
+func (g *generator) encodedFields(decl *typeDecl, fields []fieldInfo) ([]fieldInfo, error) {
+	var res []fieldInfo
+
+	for _, f := range fields {
+		if f.opts.Skip {
+			continue
+		}
+
+		if !ast.IsExported(f.name) {
+			if !f.opts.ExportAnonymousField {
+				if f.hasTag {
+					return nil, fmt.Errorf("unexported field %v has BCS tag, but is not marked for export", f.name)
+				}
+
+				continue
+			}
+		} else if f.opts.ExportAnonymousField {
+			return nil, fmt.Errorf("field %v is already exported, but is marked for export", f.name)
+		}
+
+		if err := checkFieldOptions(f.opts); err != nil {
+			return nil, fmt.Errorf("field %v: %w", f.name, err)
+		}
+
+		if f.hasTag {
+			g.fieldOptsVars[decl.name] = true
+			f.optsRef = fmt.Sprintf("%v[%v].TypeOptions", fieldOptsVarName(decl.name), f.idx)
+		}
+
+		if f.opts.Optional && !f.t.nullableKnown {
+			return nil, fmt.Errorf("field %v: cannot determine if type %v is nullable to encode it as optional", f.name, f.t.expr)
+		}
+
+		res = append(res, f)
+	}
+
+	return res, nil
+}
+
+// Checks that generator knows about all field options, which are set.
+func checkFieldOptions(opts bcs.FieldOptions) error {
+	opts.TypeOptions = bcs.TypeOptions{}
+	opts.Skip = false
+	opts.Optional = false
+	opts.AsByteArray = false
+
+	if !reflect.DeepEqual(opts, bcs.FieldOptions{}) {
+		return fmt.Errorf("unsupported field options: %+v", opts)
+	}
+
+	return nil
+}
+
+// Checks that generator knows how to apply all type options, which are set.
+// Values with unsupported options are encoded using reflection.
+func supportedTypeOptions(opts bcs.TypeOptions) bool {
+	opts.LenSizeInBytes = 0
+	opts.UnderlyingType = reflect.Invalid
+	opts.IsCompactInt = false
+	opts.IsCompactZigZag = false
+	opts.InterfaceIsNotEnum = false
+	opts.ExportAnonymousField = false
+	opts.NilIfEmpty = false
+	opts.ArrayElement = nil
+	opts.MapKey = nil
+	opts.MapValue = nil
+
+	return reflect.DeepEqual(opts, bcs.TypeOptions{})
+}
+
+func isOptional(f fieldInfo) bool {
+	return f.opts.Optional && f.t.nullable
+}
+
+func (g *generator) genStructEncode(decl *typeDecl, fields []fieldInfo) error {
+	fields, err := g.encodedFields(decl, fields)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range fields {
+		x := "v." + f.name
+
+		if isOptional(f) {
+			g.p("if %v == nil {", x)
+			g.p("e.WriteOptionalFlag(false)")
+			g.p("} else {")
+			g.p("e.WriteOptionalFlag(true)")
+			g.notNil[x] = true
+		}
+
+		err := g.asByteArray(f.opts.AsByteArray, true, func() error {
+			return g.encode(x, f.t, f.opts.TypeOptions, f.optsRef)
+		})
+		if err != nil {
+			return fmt.Errorf("field %v: %w", f.name, err)
+		}
+
+		if isOptional(f) {
+			g.p("}")
+		}
+	}
+
+	return nil
+}
+
+func (g *generator) genStructDecode(decl *typeDecl, fields []fieldInfo) error {
+	fields, err := g.encodedFields(decl, fields)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range fields {
+		x := "v." + f.name
+
+		if isOptional(f) {
+			g.p("if d.ReadOptionalFlag() {")
+		}
+
+		err := g.asByteArray(f.opts.AsByteArray, false, func() error {
+			return g.decode(x, f.t, f.opts.TypeOptions, f.optsRef)
+		})
+		if err != nil {
+			return fmt.Errorf("field %v: %w", f.name, err)
+		}
+
+		if isOptional(f) {
+			g.p("}")
+		}
+	}
+
+	return nil
+}
+
+// Returns variant ids of struct enum fields: either index of the field or value of "variant" tag.
+func structEnumVariantIDs(decl *typeDecl, fields []fieldInfo) ([]bcs.EnumVariantID, error) {
+	ids := make([]bcs.EnumVariantID, len(fields))
+	fieldsByID := make(map[bcs.EnumVariantID]string, len(fields))
+
+	for i, f := range fields {
+		if !f.t.nullable || !f.t.nullableKnown {
+			return nil, fmt.Errorf("field %v of enum %v is of non-nullable type %v", f.name, decl.name, f.t.expr)
+		}
+
+		ids[i] = f.idx
+		if f.opts.HasVariantID {
+			ids[i] = f.opts.VariantID
+		}
+
+		if prevField, duplicate := fieldsByID[ids[i]]; duplicate {
+			return nil, fmt.Errorf("fields %v and %v of enum %v have same variant id %v", prevField, f.name, decl.name, ids[i])
+		}
+
+		fieldsByID[ids[i]] = f.name
+	}
+
+	return ids, nil
+}
+
+// Unit variant of struct enum has no payload: it is a field of type *struct{} or *bcs.None.
+func (g *generator) isUnitVariant(t *typeRef) bool {
+	return t.kind == kindPtr && (t.elem.expr == "struct{}" || t.elem.expr == g.bcs("None"))
+}
+
+func (g *generator) genStructEnumEncode(decl *typeDecl, fields []fieldInfo) error {
+	ids, err := structEnumVariantIDs(decl, fields)
+	if err != nil {
+		return err
+	}
+
+	g.p("fieldIdx := -1")
+	for _, f := range fields {
+		g.p("if v.%v != nil {", f.name)
+		g.p("if fieldIdx != -1 {")
+		g.p("return %v", g.encodeErrorf("ErrInvalidEnumVariant", "multiple options are set in enum struct %v", strconv.Quote(decl.name)))
+		g.p("}")
+		g.p("fieldIdx = %v", f.idx)
+		g.p("}")
+	}
+
+	g.p("switch fieldIdx {")
+	for i, f := range fields {
+		g.p("case %v:", f.idx)
+		g.p("e.WriteEnumIdx(%v)", ids[i])
+		if g.isUnitVariant(f.t) {
+			continue
+		}
+		g.notNil["v."+f.name] = true
+
+		f, err := g.variantField(decl, f)
+		if err != nil {
+			return err
+		}
+
+		err = g.asByteArray(f.opts.AsByteArray, true, func() error {
+			return g.encode("v."+f.name, f.t, f.opts.TypeOptions, f.optsRef)
+		})
+		if err != nil {
+			return fmt.Errorf("field %v: %w", f.name, err)
+		}
+	}
+	g.p("default:")
+	g.p("return %v", g.encodeErrorf("ErrInvalidEnumVariant", "no options are set in enum struct %v", strconv.Quote(decl.name)))
+	g.p("}")
+
+	return nil
+}
+
+func (g *generator) genStructEnumDecode(decl *typeDecl, fields []fieldInfo) error {
+	ids, err := structEnumVariantIDs(decl, fields)
+	if err != nil {
+		return err
+	}
+
+	g.p("variantIdx := d.ReadEnumIdx()")
+	g.p("if err := d.Err(); err != nil {")
+	g.p("return err")
+	g.p("}")
+
+	g.p("switch variantIdx {")
+	for i, f := range fields {
+		g.p("case %v:", ids[i])
+		if g.isUnitVariant(f.t) {
+			g.p("v.%v = new(%v)", f.name, f.t.elem.expr)
+			continue
+		}
+
+		f, err := g.variantField(decl, f)
+		if err != nil {
+			return err
+		}
+
+		err = g.asByteArray(f.opts.AsByteArray, false, func() error {
+			return g.decode("v."+f.name, f.t, f.opts.TypeOptions, f.optsRef)
+		})
+		if err != nil {
+			return fmt.Errorf("field %v: %w", f.name, err)
+		}
+	}
+	g.p("default:")
+	g.p("return %v", g.decodeErrorf("ErrInvalidEnumVariant", "invalid variant index %v for enum %v", "variantIdx", strconv.Quote(decl.name)))
+	g.p("}")
+
+	return nil
+}
+
+// Checks options of variant field of struct enum and sets reference to its runtime options, if it has tag.
+// Options are applied to the variant value same way as for fields of regular structs.
+func (g *generator) variantField(decl *typeDecl, f fieldInfo) (fieldInfo, error) {
+	opts := f.opts
+	opts.HasVariantID, opts.VariantID = false, 0
+
+	if err := checkFieldOptions(opts); err != nil {
+		return f, fmt.Errorf("field %v: %w", f.name, err)
+	}
+
+	if f.hasTag {
+		g.fieldOptsVars[decl.name] = true
+		f.optsRef = fmt.Sprintf("%v[%v].TypeOptions", fieldOptsVarName(decl.name), f.idx)
+	}
+
+	return f, nil
+}
+
+func (g *generator) asByteArray(enabled, encode bool, body func() error) error {
+	if !enabled {
+		return body()
+	}
+
+	if encode {
+		g.p("if err := %v.EncodeAsByteArray(func() error {", g.enc)
+	} else {
+		g.p("if err := %v.DecodeAsByteArray(func() error {", g.dec)
+	}
+
+	if err := body(); err != nil {
+		return err
+	}
+
+	g.p("return nil")
+	g.p("}); err != nil {")
+	g.p("return err")
+	g.p("}")
+
+	return nil
+}
+
+func (g *generator) fallback(x string, opts bcs.TypeOptions, optsRef string, encode bool) {
+	coder, method := g.dec, "Decode"
+	if encode {
+		coder, method = g.enc, "Encode"
+	}
+
+	if optsRef != "" && !reflect.DeepEqual(opts, bcs.TypeOptions{}) {
+		g.p("%v.%vWithOptions(%v, &%v)", coder, method, addr(x), optsRef)
+	} else {
+		g.p("%v.%v(%v)", coder, method, addr(x))
+	}
+}
+
+// Returns references to options of element, key or value, if runtime options are available.
+func elemOptsRef(opts bcs.TypeOptions, optsRef string) string {
+	if optsRef == "" || opts.ArrayElement == nil {
+		return ""
+	}
+	return optsRef + ".ArrayElement.TypeOptions"
+}
+
+func keyOptsRef(opts bcs.TypeOptions, optsRef string) string {
+	if optsRef == "" || opts.MapKey == nil {
+		return ""
+	}
+	return "(*" + optsRef + ".MapKey)"
+}
+
+func valueOptsRef(opts bcs.TypeOptions, optsRef string) string {
+	if optsRef == "" || opts.MapValue == nil {
+		return ""
+	}
+	return "(*" + optsRef + ".MapValue)"
+}
+
+func derefOpts(opts *bcs.TypeOptions) bcs.TypeOptions {
+	if opts == nil {
+		return bcs.TypeOptions{}
+	}
+	return *opts
+}
+
+// Are elements of the collection written with a single Write() call by reflection-based encoder.
+func isBytesFastPath(elem *typeRef, elemOpts bcs.ArrayElemOptions) bool {
+	if elem.kind != kindBasic || elem.basic != "uint8" || elemOpts.AsByteArray {
+		return false
+	}
+
+	return elem.decl == nil || !elem.decl.hasMethod("BCSOptions")
+}
+
+func intKindName(basic string) string {
+	switch basic {
+	case "int":
+		return "int64"
+	case "uint":
+		return "uint64"
+	}
+	return basic
+}
+
+func isSigned(basic string) bool {
+	return strings.HasPrefix(basic, "int")
+}
+
+func methodSuffix(basic string) string {
+	return strings.ToUpper(basic[:1]) + basic[1:]
+}
+
+func (g *generator) convert(x string, t *typeRef, to string) string {
+	if t.named || t.basic != to {
+		return to + "(" + unparen(x) + ")"
+	}
+	return unparen(x)
+}
+
+// Dereferences pointer expression. Result is parenthesized to be usable as operand.
+func deref(x string) string {
+	return "(*" + x + ")"
+}
+
+func unparen(x string) string {
+	if strings.HasPrefix(x, "(*") && strings.HasSuffix(x, ")") {
+		return x[1 : len(x)-1]
+	}
+	return x
+}
+
+// Returns expression of pointer to the value.
+func addr(x string) string {
+	if strings.HasPrefix(x, "(*") && strings.HasSuffix(x, ")") {
+		return x[2 : len(x)-1]
+	}
+	return "&" + x
+}
+
+// Returns expression, on which methods with pointer receiver could be called.
+func receiver(x string) string {
+	if strings.HasPrefix(x, "(*") && strings.HasSuffix(x, ")") {
+		return x[2 : len(x)-1]
+	}
+	return x
+}
+
+func (g *generator) checkLen(x string, lenSize bcs.LenBytesCount, errFormat string) error {
+	switch lenSize {
+	case 0:
+	case bcs.Len2Bytes:
+		g.p("if len(%v) > 0xFFFF {", x)
+		g.p("return %v", g.encodeErrorf("ErrOverflow", fmt.Sprintf(errFormat, 2), "len("+x+")"))
+		g.p("}")
+	case bcs.Len4Bytes:
+		g.p("if uint64(len(%v)) > 0xFFFFFFFF {", x)
+		g.p("return %v", g.encodeErrorf("ErrOverflow", fmt.Sprintf(errFormat, 4), "len("+x+")"))
+		g.p("}")
+	default:
+		return fmt.Errorf("invalid collection size type: %v", lenSize)
+	}
+
+	return nil
+}
+
+func (g *generator) encode(x string, t *typeRef, opts bcs.TypeOptions, optsRef string) error {
+	eff := t.typeOpts
+	eff.Update(opts)
+
+	if t.kind == kindOpaque || !supportedTypeOptions(eff) || (t.kind == kindEnum && eff.InterfaceIsNotEnum) {
+		g.fallback(x, opts, optsRef, true)
+		return nil
+	}
+
+	e := g.enc
+
+	switch t.kind {
+	case kindBasic:
+		return g.encodeBasic(x, t, eff)
+	case kindSlice:
+		if err := g.checkLen(x, eff.LenSizeInBytes, "slice length %%v exceeds %v bytes"); err != nil {
+			return err
+		}
+		g.p("%v.WriteLen(len(%v))", e, x)
+		return g.encodeElems(x, t, eff, optsRef)
+	case kindArray:
+		return g.encodeElems(x, t, eff, optsRef)
+	case kindMap:
+		return g.encodeMap(x, t, eff, optsRef)
+	case kindPtr:
+		if !g.notNil[x] {
+			g.p("if %v == nil {", unparen(x))
+			g.p("return %v", g.encodeErrorf("ErrNilValue", "attempt to encode non-optional nil value of type %T", unparen(x)))
+			g.p("}")
+		}
+		return g.encode(deref(x), t.elem, opts, optsRef)
+	case kindStruct:
+		g.p("if err := %v.MarshalBCS(%v); err != nil {", receiver(x), e)
+		g.p("return err")
+		g.p("}")
+		return nil
+	case kindEnum:
+		return g.encodeEnum(x, t)
+	default:
+		panic(fmt.Sprintf("unexpected kind %v", t.kind))
+	}
+}
+
+func (g *generator) encodeBasic(x string, t *typeRef, opts bcs.TypeOptions) error {
+	e := g.enc
+
+	switch t.basic {
+	case "bool":
+		g.p("%v.WriteBool(%v)", e, g.convert(x, t, "bool"))
+		return nil
+	case "string":
+		g.p("%v.WriteString(%v)", e, g.convert(x, t, "string"))
+		return nil
+	}
+
+	if opts.IsCompactInt && opts.IsCompactZigZag {
+		if !isSigned(t.basic) {
+			return fmt.Errorf("zigzag encoding is applicable only to signed integers, got %v", t.expr)
+		}
+
+		g.p("%v.WriteCompactInt64(int64(%v))", e, x)
+		return nil
+	}
+
+	if opts.IsCompactInt {
+		g.p("%v.WriteCompactUint64(uint64(%v))", e, x)
+		return nil
+	}
+
+	kind := intKindName(t.basic)
+
+	if opts.UnderlyingType != reflect.Invalid && opts.UnderlyingType.String() != t.basic {
+		to := intKindName(opts.UnderlyingType.String())
+		from := "uint64"
+		if isSigned(t.basic) {
+			from = "int64"
+		}
+
+		c := g.newVar("c")
+		g.p("%v := %v(%v)", c, to, x)
+		g.p("if %v(%v) != %v(%v) {", from, c, from, x)
+		g.p("return %v", g.encodeErrorf("ErrOverflow", "value %v is out of range of type %T", from+"("+x+")", c))
+		g.p("}")
+		g.p("%v.Write%v(%v)", e, methodSuffix(to), c)
+
+		return nil
+	}
+
+	g.p("%v.Write%v(%v)", e, methodSuffix(kind), g.convert(x, t, kind))
+
+	return nil
+}
+
+func (g *generator) encodeElems(x string, t *typeRef, opts bcs.TypeOptions, optsRef string) error {
+	e := g.enc
+	elemOpts := bcs.ArrayElemOptions{}
+	if opts.ArrayElement != nil {
+		elemOpts = *opts.ArrayElement
+	}
+
+	if isBytesFastPath(t.elem, elemOpts) {
+		switch {
+		case t.elem.named:
+			i := g.newVar("i")
+			g.p("for %v := range %v {", i, x)
+			g.p("%v.WriteUint8(uint8(%v[%v]))", e, x, i)
+			g.p("}")
+		case t.kind == kindArray:
+			g.p("_, _ = %v.Write(%v[:])", e, x)
+		default:
+			g.p("_, _ = %v.Write(%v)", e, x)
+		}
+
+		return nil
+	}
+
+	i := g.newVar("i")
+	g.p("for %v := range %v {", i, x)
+
+	err := g.asByteArray(elemOpts.AsByteArray, true, func() error {
+		return g.encode(x+"["+i+"]", t.elem, elemOpts.TypeOptions, elemOptsRef(opts, optsRef))
+	})
+	if err != nil {
+		return err
+	}
+
+	g.p("}")
+
+	return nil
+}
+
+func (g *generator) encodeMap(x string, t *typeRef, opts bcs.TypeOptions, optsRef string) error {
+	e := g.enc
+
+	if !g.notNil[x] {
+		g.p("if %v == nil {", unparen(x))
+		g.p("return %v", g.encodeErrorf("ErrNilValue", "attempt to encode non-optional nil-map"))
+		g.p("}")
+	}
+
+	if err := g.checkLen(x, opts.LenSizeInBytes, "map length %%v exceeds %v bytes"); err != nil {
+		return err
+	}
+
+	g.p("%v.WriteLen(len(%v))", e, x)
+
+	g.useImport("sort", "sort")
+	g.useImport("bytes", "bytes")
+
+	entries, k, val := g.newVar("entries"), g.newVar("k"), g.newVar("val")
+	keyBuf, keyEnc, keyStart := g.newVar("keyBuf"), g.newVar("keyEnc"), g.newVar("keyStart")
+	entryT := fmt.Sprintf("struct {\nkey []byte\nvalue %v\n}", t.elem.expr)
+
+	// Keys are written one after another into single buffer, which has same config as the encoder.
+	// Appending does not modify bytes of previous keys, so their slices stay valid.
+	g.p("%v := %v(%v.Config())", keyBuf, g.bcs("NewBytesEncoderWithOpts"), e)
+	g.p("%v := &%v.Encoder", keyEnc, keyBuf)
+	g.p("%v := make([]%v, 0, len(%v))", entries, entryT, x)
+	g.p("for %v, %v := range %v {", k, val, x)
+	g.p("%v := len(%v.Bytes())", keyStart, keyBuf)
+
+	g.enc = keyEnc
+	if err := g.encode(k, t.key, derefOpts(opts.MapKey), keyOptsRef(opts, optsRef)); err != nil {
+		return err
+	}
+	g.enc = e
+
+	g.p("if err := %v.Err(); err != nil {", keyEnc)
+	g.p("return err")
+	g.p("}")
+	g.p("%v = append(%v, %v{%v.Bytes()[%v:], %v})", entries, entries, entryT, keyBuf, keyStart, val)
+	g.p("}")
+
+	g.p("sort.Slice(%v, func(i, j int) bool {", entries)
+	g.p("return bytes.Compare(%v[i].key, %v[j].key) < 0", entries, entries)
+	g.p("})")
+
+	j := g.newVar("j")
+	g.p("for %v := range %v {", j, entries)
+	g.p("_, _ = %v.Write(%v[%v].key)", e, entries, j)
+
+	if err := g.encode(entries+"["+j+"].value", t.elem, derefOpts(opts.MapValue), valueOptsRef(opts, optsRef)); err != nil {
+		return err
+	}
+
+	g.p("}")
+
+	return nil
+}
+
+type enumVariant struct {
+	id int
+	t  *typeRef
+}
+
+// Variants are taken from registrations in the sources of the package, so ids of variants are frozen
+// at generation time and registry of encoder/decoder is not consulted by generated code.
+func (g *generator) enumVariants(t *typeRef) ([]enumVariant, error) {
+	variants := g.pkg.enums[t.decl.name]
+	res := make([]enumVariant, 0, len(variants))
+
+	for id, v := range variants {
+		if v == nil {
+			res = append(res, enumVariant{id: id})
+			continue
+		}
+
+		vt, err := g.pkg.resolveType(v.expr, v.file, g.generated)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, enumVariant{id: id, t: vt})
+	}
+
+	sort.Slice(res, func(i, j int) bool { return res[i].id < res[j].id })
+
+	return res, nil
+}
+
+func (g *generator) encodeEnum(x string, t *typeRef) error {
+	e := g.enc
+
+	variants, err := g.enumVariants(t)
+	if err != nil {
+		return err
+	}
+
+	hasPayload := false
+	for _, v := range variants {
+		hasPayload = hasPayload || v.t != nil
+	}
+
+	val := g.newVar("variant")
+	if hasPayload {
+		g.p("switch %v := %v.(type) {", val, x)
+	} else {
+		g.p("switch %v.(type) {", x)
+	}
+
+	for _, v := range variants {
+		if v.t == nil {
+			if iface, ok := t.decl.spec.Type.(*ast.InterfaceType); ok && len(iface.Methods.List) == 0 {
+				g.p("case nil, %v:", g.bcs("None"))
+			} else {
+				// Interfaces with methods cannot hold bcs.None
+				g.p("case nil:")
+			}
+			g.p("%v.WriteEnumIdx(%v)", e, v.id)
+			continue
+		}
+
+		g.p("case %v:", v.t.expr)
+		g.p("%v.WriteEnumIdx(%v)", e, v.id)
+		if err := g.encode(val, v.t, bcs.TypeOptions{}, ""); err != nil {
+			return err
+		}
+	}
+
+	g.p("default:")
+	g.p("return %v", g.encodeErrorf("ErrInvalidEnumVariant", "variant %T is not registered as part of enum type %v", x, strconv.Quote(t.expr)))
+	g.p("}")
+
+	return nil
+}
+
+func (g *generator) decode(x string, t *typeRef, opts bcs.TypeOptions, optsRef string) error {
+	eff := t.typeOpts
+	eff.Update(opts)
+
+	if t.kind == kindOpaque || !supportedTypeOptions(eff) || (t.kind == kindEnum && eff.InterfaceIsNotEnum) {
+		g.fallback(x, opts, optsRef, false)
+		return nil
+	}
+
+	d := g.dec
+
+	switch t.kind {
+	case kindBasic:
+		return g.decodeBasic(x, t, eff)
+	case kindSlice:
+		return g.decodeSlice(x, t, eff, optsRef)
+	case kindArray:
+		return g.decodeArray(x, t, eff, optsRef)
+	case kindMap:
+		return g.decodeMap(x, t, eff, optsRef)
+	case kindPtr:
+		g.p("if %v == nil {", unparen(x))
+		g.p("%v = new(%v)", unparen(x), t.elem.expr)
+		g.p("}")
+		return g.decode(deref(x), t.elem, opts, optsRef)
+	case kindStruct:
+		// Nested values are accounted in nesting depth same way as by reflection-based decoder.
+		g.p("if err := %v.DecodeNested(func() error { return %v.UnmarshalBCS(%v) }); err != nil {", d, receiver(x), d)
+		g.p("return err")
+		g.p("}")
+		if t.decl.hasMethod("BCSInit") {
+			g.p("if err := %v.BCSInit(); err != nil {", receiver(x))
+			g.p("return err")
+			g.p("}")
+		}
+		return nil
+	case kindEnum:
+		return g.decodeEnum(x, t)
+	default:
+		panic(fmt.Sprintf("unexpected kind %v", t.kind))
+	}
+}
+
+func (g *generator) decodeBasic(x string, t *typeRef, opts bcs.TypeOptions) error {
+	d := g.dec
+
+	switch t.basic {
+	case "bool":
+		g.p("%v = %v", unparen(x), g.convert(d+".ReadBool()", t, t.expr))
+		return nil
+	case "string":
+		g.p("%v = %v", unparen(x), g.convert(d+".ReadString()", t, t.expr))
+		return nil
+	}
+
+	if opts.IsCompactInt {
+		read, from := d+".ReadCompactUint64()", "uint64"
+
+		switch {
+		case opts.IsCompactZigZag:
+			if !isSigned(t.basic) {
+				return fmt.Errorf("zigzag encoding is applicable only to signed integers, got %v", t.expr)
+			}
+
+			read, from = d+".ReadCompactInt64()", "int64"
+		case isSigned(t.basic):
+			// Negative values are encoded as their two's complement
+			read, from = "int64("+read+")", "int64"
+		}
+
+		if t.basic == from {
+			g.p("%v = %v", unparen(x), g.convert(read, t, t.expr))
+			return nil
+		}
+
+		r := g.newVar("r")
+		g.p("%v := %v", r, read)
+		g.p("if %v(%v(%v)) != %v {", from, t.basic, r, r)
+		g.p("return %v", g.decodeErrorf("ErrOverflow", "value %v is out of range of type %T", r, t.basic+"(0)"))
+		g.p("}")
+		g.p("%v = %v(%v)", unparen(x), t.expr, r)
+
+		return nil
+	}
+
+	kind := intKindName(t.basic)
+
+	if opts.UnderlyingType != reflect.Invalid && opts.UnderlyingType.String() != t.basic {
+		from := intKindName(opts.UnderlyingType.String())
+
+		r := g.newVar("r")
+		g.p("%v := %v.Read%v()", r, d, methodSuffix(from))
+		g.p("if %v(%v(%v)) != %v {", from, kind, r, r)
+		g.p("return %v", g.decodeErrorf("ErrOverflow", "value %v is out of range of type %T", r, kind+"(0)"))
+		g.p("}")
+		g.p("%v = %v(%v)", unparen(x), t.expr, r)
+
+		return nil
+	}
+
+	read := fmt.Sprintf("%v.Read%v()", d, methodSuffix(kind))
+	if t.named || kind != t.basic {
+		read = t.expr + "(" + read + ")"
+	}
+
+	g.p("%v = %v", unparen(x), read)
+
+	return nil
+}
+
+func (g *generator) checkDecodedLen(n string, lenSize bcs.LenBytesCount, errFormat string) error {
+	switch lenSize {
+	case 0:
+	case bcs.Len2Bytes:
+		g.p("if %v > 0xFFFF {", n)
+		g.p("return %v", g.decodeErrorf("ErrOverflow", fmt.Sprintf(errFormat, 2), n))
+		g.p("}")
+	case bcs.Len4Bytes:
+		g.p("if uint64(%v) > 0xFFFFFFFF {", n)
+		g.p("return %v", g.decodeErrorf("ErrOverflow", fmt.Sprintf(errFormat, 4), n))
+		g.p("}")
+	default:
+		return fmt.Errorf("invalid collection size type: %v", lenSize)
+	}
+
+	return nil
+}
+
+func (g *generator) decodeSlice(x string, t *typeRef, opts bcs.TypeOptions, optsRef string) error {
+	d := g.dec
+	elemOpts := bcs.ArrayElemOptions{}
+	if opts.ArrayElement != nil {
+		elemOpts = *opts.ArrayElement
+	}
+
+	if isBytesFastPath(t.elem, elemOpts) {
+		b := g.newVar("b")
+		g.p("%v := %v.ReadBytes()", b, d)
+		if err := g.checkDecodedLen("len("+b+")", opts.LenSizeInBytes, "array size exceeds %v bytes: %%v"); err != nil {
+			return err
+		}
+
+		if opts.NilIfEmpty {
+			g.p("if len(%v) > 0 {", b)
+		}
+
+		if t.elem.named {
+			i := g.newVar("i")
+			g.p("%v = make(%v, len(%v))", unparen(x), t.expr, b)
+			g.p("for %v := range %v {", i, b)
+			g.p("%v[%v] = %v(%v[%v])", x, i, t.elem.expr, b, i)
+			g.p("}")
+		} else if t.named {
+			g.p("%v = %v(%v)", unparen(x), t.expr, b)
+		} else {
+			g.p("%v = %v", unparen(x), b)
+		}
+
+		if opts.NilIfEmpty {
+			g.p("}")
+		}
+
+		return nil
+	}
+
+	g.useImport("unsafe", "unsafe")
+
+	n := g.newVar("n")
+	g.p("%v := %v.ReadCollectionLen(int(unsafe.Sizeof(*new(%v))))", n, d, t.elem.expr)
+	if err := g.checkDecodedLen(n, opts.LenSizeInBytes, "array size exceeds %v bytes: %%v"); err != nil {
+		return err
+	}
+
+	if opts.NilIfEmpty {
+		g.p("if %v > 0 {", n)
+	} else {
+		g.p("{")
+	}
+
+	g.p("%v = make(%v, 0, min(%v, 100))", unparen(x), t.expr, n)
+
+	i := g.newVar("i")
+	g.p("for %v := 0; %v < %v; %v++ {", i, i, n, i)
+	g.p("%v = append(%v, *new(%v))", x, x, t.elem.expr)
+
+	err := g.asByteArray(elemOpts.AsByteArray, false, func() error {
+		return g.decode(x+"["+i+"]", t.elem, elemOpts.TypeOptions, elemOptsRef(opts, optsRef))
+	})
+	if err != nil {
+		return err
+	}
+
+	g.p("if err := %v.Err(); err != nil {", d)
+	g.p("return err")
+	g.p("}")
+	g.p("}")
+	g.p("}")
+
+	return nil
+}
+
+func (g *generator) decodeArray(x string, t *typeRef, opts bcs.TypeOptions, optsRef string) error {
+	d := g.dec
+	elemOpts := bcs.ArrayElemOptions{}
+	if opts.ArrayElement != nil {
+		elemOpts = *opts.ArrayElement
+	}
+
+	i := g.newVar("i")
+
+	if isBytesFastPath(t.elem, elemOpts) {
+		if t.elem.named {
+			g.p("for %v := range %v {", i, x)
+			g.p("%v[%v] = %v(%v.ReadUint8())", x, i, t.elem.expr, d)
+			g.p("}")
+		} else {
+			g.p("_, _ = %v.Read(%v[:])", d, x)
+		}
+
+		return nil
+	}
+
+	g.p("for %v := range %v {", i, x)
+
+	err := g.asByteArray(elemOpts.AsByteArray, false, func() error {
+		return g.decode(x+"["+i+"]", t.elem, elemOpts.TypeOptions, elemOptsRef(opts, optsRef))
+	})
+	if err != nil {
+		return err
+	}
+
+	g.p("if err := %v.Err(); err != nil {", d)
+	g.p("return err")
+	g.p("}")
+	g.p("}")
+
+	return nil
+}
+
+func (g *generator) decodeMap(x string, t *typeRef, opts bcs.TypeOptions, optsRef string) error {
+	d := g.dec
+
+	g.useImport("unsafe", "unsafe")
+
+	n := g.newVar("n")
+	g.p("%v := %v.ReadCollectionLen(int(unsafe.Sizeof(*new(%v)) + unsafe.Sizeof(*new(%v))))", n, d, t.key.expr, t.elem.expr)
+	if err := g.checkDecodedLen(n, opts.LenSizeInBytes, "map size exceeds %v bytes: %%v"); err != nil {
+		return err
+	}
+
+	g.p("%v = make(%v, min(%v, 100))", unparen(x), t.expr, n)
+
+	prevKey, i, k, val := g.newVar("prevKey"), g.newVar("i"), g.newVar("k"), g.newVar("val")
+
+	g.p("var %v []byte", prevKey)
+	g.p("for %v := 0; %v < %v; %v++ {", i, i, n, i)
+	g.p("var %v %v", k, t.key.expr)
+	g.p("var err error")
+	g.p("%v, err = %v.DecodeMapKey(%v, %v, func() error {", prevKey, d, i, prevKey)
+	if err := g.decode(k, t.key, derefOpts(opts.MapKey), keyOptsRef(opts, optsRef)); err != nil {
+		return err
+	}
+	g.p("return nil")
+	g.p("})")
+	g.p("if err != nil {")
+	g.p("return err")
+	g.p("}")
+
+	g.p("var %v %v", val, t.elem.expr)
+	if err := g.decode(val, t.elem, derefOpts(opts.MapValue), valueOptsRef(opts, optsRef)); err != nil {
+		return err
+	}
+	g.p("if err := %v.Err(); err != nil {", d)
+	g.p("return err")
+	g.p("}")
+	g.p("%v[%v] = %v", x, k, val)
+	g.p("}")
+
+	return nil
+}
+
+func (g *generator) decodeEnum(x string, t *typeRef) error {
+	d := g.dec
+
+	variants, err := g.enumVariants(t)
+	if err != nil {
+		return err
+	}
+
+	idx := g.newVar("variantIdx")
+	g.p("%v := %v.ReadEnumIdx()", idx, d)
+	g.p("if err := %v.Err(); err != nil {", d)
+	g.p("return err")
+	g.p("}")
+	g.p("switch %v {", idx)
+
+	for _, v := range variants {
+		g.p("case %v:", v.id)
+		if v.t == nil {
+			continue
+		}
+
+		val := g.newVar("variant")
+		g.p("var %v %v", val, v.t.expr)
+		if err := g.decode(val, v.t, bcs.TypeOptions{}, ""); err != nil {
+			return err
+		}
+		g.p("%v = %v", unparen(x), val)
+	}
+
+	g.p("default:")
+	g.p("return %v", g.decodeErrorf("ErrInvalidEnumVariant", "invalid variant index %v for enum %v", idx, strconv.Quote(t.expr)))
+	g.p("}")
+
+	return nil
+}
+
+func (g *generator) genTests(decls []*typeDecl) ([]byte, error) {
+	var b bytes.Buffer
+
+	b.WriteString(generatedByHeader + "\n\n")
+	fmt.Fprintf(&b, "package %v\n\n", g.pkg.name)
+	fmt.Fprintf(&b, "import (\n\t\"testing\"\n\n\t%q\n)\n\n", bcstestImportPath)
+
+	for _, decl := range decls {
+		refl := strings.ToLower(decl.name[:1]) + decl.name[1:] + "BCSReflective"
+
+		b.WriteString("// Same type without generated methods - it is encoded using reflection.\n")
+		fmt.Fprintf(&b, "type %v %v\n\n", refl, decl.name)
+
+		if decl.hasMethod("IsBcsEnum") {
+			fmt.Fprintf(&b, "func (%v) IsBcsEnum() {}\n\n", refl)
+		} else if decl.hasMethod("BCSInit") {
+			fmt.Fprintf(&b, "func (v *%v) BCSInit() error { return (*%v)(v).BCSInit() }\n\n", refl, decl.name)
+		}
+
+		fmt.Fprintf(&b, "func Test%vBCSGenerated(t *testing.T) {\n", strings.ToUpper(decl.name[:1])+decl.name[1:])
+		fmt.Fprintf(&b, "\tbcstest.TestGeneratedCodec[%v, %v](t)\n}\n\n", decl.name, refl)
+	}
+
+	code, err := format.Source(b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated tests: %w", err)
+	}
+
+	return code, nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExampleIsUpToDate(t *testing.T) {
+	pkg, err := loadPackage("example")
+	require.NoError(t, err)
+
+	code, testCode, err := generate(pkg, nil, "bcs")
+	require.NoError(t, err)
+
+	expectedCode, err := os.ReadFile(filepath.Join("example", "example_bcs.go"))
+	require.NoError(t, err)
+	require.Equal(t, string(expectedCode), string(code), "run go generate ./cmd/bcsgen/example")
+
+	expectedTestCode, err := os.ReadFile(filepath.Join("example", "example_bcs_test.go"))
+	require.NoError(t, err)
+	require.Equal(t, string(expectedTestCode), string(testCode), "run go generate ./cmd/bcsgen/example")
+}
+
+func TestGenerateErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	src := `package p
+
+import "github.com/iotaledger/bcs-go"
+
+type NotStruct []int
+
+type WithCodec struct{}
+
+func (w *WithCodec) MarshalBCS(e *bcs.Encoder) error { return nil }
+
+type UnexportedWithTag struct {
+	a int ` + "`bcs:\"compact\"`" + `
+}
+
+type BadEnum struct {
+	A int
+}
+
+func (BadEnum) IsBcsEnum() {}
+
+type DuplicateVariant struct {
+	A *int ` + "`bcs:\"variant=1\"`" + `
+	B *int
+}
+
+func (DuplicateVariant) IsBcsEnum() {}
+
+type OptionalExternal struct {
+	A bcs.Encoder ` + "`bcs:\"optional\"`" + `
+}
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "p.go"), []byte(src), 0o600))
+
+	pkg, err := loadPackage(dir)
+	require.NoError(t, err)
+
+	testErr := func(typeName, errMustContain string) {
+		_, _, err := generate(pkg, []string{typeName}, "bcs")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), errMustContain)
+	}
+
+	testErr("Missing", "not found")
+	testErr("NotStruct", "not a struct type")
+	testErr("WithCodec", "already has method MarshalBCS")
+	testErr("UnexportedWithTag", "unexported field a has BCS tag")
+	testErr("BadEnum", "non-nullable type int")
+	testErr("DuplicateVariant", "fields A and B of enum DuplicateVariant have same variant id 1")
+	testErr("OptionalExternal", "cannot determine if type bcs.Encoder is nullable")
+}
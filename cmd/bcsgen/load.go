@@ -0,0 +1,821 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	bcs "github.com/iotaledger/bcs-go"
+)
+
+const (
+	bcsImportPath     = "github.com/iotaledger/bcs-go"
+	bcstestImportPath = bcsImportPath + "/bcstest"
+	generatedByHeader = "// Code generated by bcsgen. DO NOT EDIT."
+)
+
+type pkgInfo struct {
+	name  string
+	fset  *token.FileSet
+	files []*ast.File
+	decls map[string]*typeDecl
+	// Enum interface name -> variant id -> variant type. Nil variant type means bcs.None.
+	enums map[string]map[int]*variantInfo
+	// Enum interfaces, which keep unknown variants. Their payload is read until end of input, so they are encoded using reflection.
+	enumsWithUnknownVariant map[string]bool
+	// Integer types registered as enums. Set of their values is known only at runtime, so they are encoded using reflection.
+	intEnums map[string]bool
+	// Imports used by the package: name -> path
+	imports map[string]string
+	// Name, under which bcs package is imported by the package.
+	bcsName string
+	// Named types, which are being resolved at the moment. Used to detect recursive types.
+	resolving map[string]bool
+}
+
+type typeDecl struct {
+	name    string
+	spec    *ast.TypeSpec
+	file    *ast.File
+	methods map[string]*ast.FuncDecl
+}
+
+func (d *typeDecl) hasMethod(name string) bool {
+	_, ok := d.methods[name]
+	return ok
+}
+
+type variantInfo struct {
+	expr ast.Expr
+	file *ast.File
+}
+
+type typeKind int
+
+const (
+	kindBasic typeKind = iota
+	kindSlice
+	kindArray
+	kindMap
+	kindPtr
+	// Struct, for which methods are generated
+	kindStruct
+	// Interface registered as enum
+	kindEnum
+	// Anything else: encoded using reflection
+	kindOpaque
+)
+
+type typeRef struct {
+	kind typeKind
+	// Go expression of the type
+	expr string
+	// Name of basic type: bool, string, int8, ..., uint64
+	basic string
+	// Is type defined using "type X ..."
+	named bool
+	// Options returned by BCSOptions() method of the type
+	typeOpts bcs.TypeOptions
+	elem     *typeRef
+	key      *typeRef
+	decl     *typeDecl
+	// Is nil a valid value for this type. For opaque types it might be unknown.
+	nullable      bool
+	nullableKnown bool
+}
+
+func loadPackage(dir string) (*pkgInfo, error) {
+	fset := token.NewFileSet()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	pkg := &pkgInfo{
+		fset:      fset,
+		decls:     make(map[string]*typeDecl),
+		enums:     make(map[string]map[int]*variantInfo),
+		imports:   make(map[string]string),
+		bcsName:   "bcs",
+		resolving: make(map[string]bool),
+
+		enumsWithUnknownVariant: make(map[string]bool),
+		intEnums:                make(map[string]bool),
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+
+		src, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		if bytes.HasPrefix(src, []byte(generatedByHeader)) {
+			// Skipping previously generated files - methods declared there must not affect generation.
+			continue
+		}
+
+		f, err := parser.ParseFile(fset, name, src, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+
+		if pkg.name == "" {
+			pkg.name = f.Name.Name
+		} else if pkg.name != f.Name.Name {
+			return nil, fmt.Errorf("multiple packages in directory %v: %v and %v", dir, pkg.name, f.Name.Name)
+		}
+
+		pkg.files = append(pkg.files, f)
+	}
+
+	if len(pkg.files) == 0 {
+		return nil, fmt.Errorf("no Go files found in %v", dir)
+	}
+
+	for _, f := range pkg.files {
+		for _, imp := range f.Imports {
+			path, _ := strconv.Unquote(imp.Path.Value)
+			if path == bcsImportPath && imp.Name != nil {
+				pkg.bcsName = imp.Name.Name
+			}
+		}
+	}
+
+	for _, f := range pkg.files {
+		for _, decl := range f.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok || genDecl.Tok != token.TYPE {
+				continue
+			}
+
+			for _, spec := range genDecl.Specs {
+				typeSpec := spec.(*ast.TypeSpec)
+				pkg.decls[typeSpec.Name.Name] = &typeDecl{
+					name:    typeSpec.Name.Name,
+					spec:    typeSpec,
+					file:    f,
+					methods: make(map[string]*ast.FuncDecl),
+				}
+			}
+		}
+	}
+
+	for _, f := range pkg.files {
+		for _, decl := range f.Decls {
+			funcDecl, ok := decl.(*ast.FuncDecl)
+			if !ok || funcDecl.Recv == nil || len(funcDecl.Recv.List) != 1 {
+				continue
+			}
+
+			recvType := funcDecl.Recv.List[0].Type
+			if star, ok := recvType.(*ast.StarExpr); ok {
+				recvType = star.X
+			}
+
+			recvIdent, ok := recvType.(*ast.Ident)
+			if !ok {
+				// Generic receiver
+				continue
+			}
+
+			if d := pkg.decls[recvIdent.Name]; d != nil {
+				d.methods[funcDecl.Name.Name] = funcDecl
+			}
+		}
+	}
+
+	for _, f := range pkg.files {
+		pkg.collectEnumRegistrations(f)
+	}
+
+	return pkg, nil
+}
+
+// Finds calls of bcs.RegisterEnumType* functions to know variants of interface enums.
+// Only calls with statically known arguments are recognized.
+func (p *pkgInfo) collectEnumRegistrations(f *ast.File) {
+	bcsName := p.fileImportName(f, bcsImportPath)
+	if bcsName == "" {
+		return
+	}
+
+	ast.Inspect(f, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+
+		if enumName := intEnumRegistration(call, bcsName); enumName != "" {
+			p.intEnums[enumName] = true
+			return true
+		}
+
+		var enumName string
+
+		addVariant := func(id int, variantT ast.Expr) {
+			variants := p.enums[enumName]
+			if variants == nil {
+				variants = make(map[int]*variantInfo)
+				p.enums[enumName] = variants
+			}
+
+			if sel, ok := variantT.(*ast.SelectorExpr); ok && isIdent(sel.X, bcsName) && (sel.Sel.Name == "None" || sel.Sel.Name == "Nil") {
+				variants[id] = nil
+			} else {
+				variants[id] = &variantInfo{expr: variantT, file: f}
+			}
+		}
+
+		if builderEnumName, variants, ok := p.enumBuilderRegistration(call, bcsName); builderEnumName != "" {
+			enumName = builderEnumName
+			if !ok {
+				delete(p.enums, enumName)
+				return true
+			}
+			for id, t := range variants {
+				addVariant(id, t)
+			}
+			return true
+		}
+
+		funcName, typeArgs := p.parseGenericCall(call.Fun, bcsName)
+		if funcName == "" || len(typeArgs) == 0 {
+			return true
+		}
+
+		enumIdent, ok := typeArgs[0].(*ast.Ident)
+		if !ok {
+			return true
+		}
+		enumName = enumIdent.Name
+
+		switch {
+		case funcName == "RegisterEnumType":
+			for i, arg := range call.Args {
+				if t := variantTypeFromValue(arg); t != nil {
+					addVariant(i, t)
+				} else {
+					delete(p.enums, enumName)
+					return true
+				}
+			}
+		case funcName == "RegisterEnumTypeWithIDs":
+			if len(call.Args) != 1 {
+				return true
+			}
+			lit, ok := call.Args[0].(*ast.CompositeLit)
+			if !ok {
+				return true
+			}
+			for _, elt := range lit.Elts {
+				kv, ok := elt.(*ast.KeyValueExpr)
+				if !ok {
+					return true
+				}
+				id, err := intLiteral(kv.Key)
+				t := variantTypeFromValue(kv.Value)
+				if err != nil || t == nil {
+					delete(p.enums, enumName)
+					return true
+				}
+				addVariant(id, t)
+			}
+		case funcName == "RegisterEnumTypeVariant":
+			if len(call.Args) != 2 {
+				return true
+			}
+			id, err := intLiteral(call.Args[0])
+			t := variantTypeFromValue(call.Args[1])
+			if err != nil || t == nil {
+				return true
+			}
+			addVariant(id, t)
+		case funcName == "RegisterEnumTypeUnknownVariant":
+			p.enumsWithUnknownVariant[enumName] = true
+		case strings.HasPrefix(funcName, "RegisterEnumType"):
+			if _, err := strconv.Atoi(strings.TrimPrefix(funcName, "RegisterEnumType")); err != nil {
+				return true
+			}
+			for i, t := range typeArgs[1:] {
+				addVariant(i, t)
+			}
+		}
+
+		return true
+	})
+}
+
+func (p *pkgInfo) parseGenericCall(fun ast.Expr, bcsName string) (funcName string, typeArgs []ast.Expr) {
+	var x ast.Expr
+
+	switch f := fun.(type) {
+	case *ast.IndexExpr:
+		x, typeArgs = f.X, []ast.Expr{f.Index}
+	case *ast.IndexListExpr:
+		x, typeArgs = f.X, f.Indices
+	default:
+		return "", nil
+	}
+
+	sel, ok := x.(*ast.SelectorExpr)
+	if !ok || !isIdent(sel.X, bcsName) {
+		return "", nil
+	}
+
+	return sel.Sel.Name, typeArgs
+}
+
+// Recognizes registrations like bcs.NewEnum[E]().Add(bcs.Variant[T](1, "T"), bcs.NoneVariant(0)).Register().
+// Returns name of enum and its variants. If enum name is found, but variants cannot be determined statically, ok is false.
+func (p *pkgInfo) enumBuilderRegistration(call *ast.CallExpr, bcsName string) (enumName string, variants map[int]ast.Expr, ok bool) {
+	sel, isSel := call.Fun.(*ast.SelectorExpr)
+	if !isSel || (sel.Sel.Name != "Register" && sel.Sel.Name != "RegisterIn") {
+		return "", nil, false
+	}
+
+	var variantArgs []ast.Expr
+
+	// Walking the chain of Add() calls down to NewEnum[E]()
+	for x := sel.X; ; {
+		c, isCall := x.(*ast.CallExpr)
+		if !isCall {
+			return "", nil, false
+		}
+
+		if funcName, typeArgs := p.parseGenericCall(c.Fun, bcsName); funcName == "NewEnum" && len(typeArgs) == 1 {
+			enumIdent, isIdent := typeArgs[0].(*ast.Ident)
+			if !isIdent {
+				return "", nil, false
+			}
+			enumName = enumIdent.Name
+			break
+		}
+
+		add, isSel := c.Fun.(*ast.SelectorExpr)
+		if !isSel || add.Sel.Name != "Add" {
+			return "", nil, false
+		}
+
+		variantArgs = append(variantArgs, c.Args...)
+		x = add.X
+	}
+
+	variants = make(map[int]ast.Expr, len(variantArgs))
+
+	for _, arg := range variantArgs {
+		c, isCall := arg.(*ast.CallExpr)
+		if !isCall || len(c.Args) == 0 {
+			return enumName, nil, false
+		}
+
+		id, err := intLiteral(c.Args[0])
+		if err != nil {
+			return enumName, nil, false
+		}
+
+		if funcName, typeArgs := p.parseGenericCall(c.Fun, bcsName); funcName == "Variant" && len(typeArgs) == 1 {
+			variants[id] = typeArgs[0]
+		} else if none, isSel := c.Fun.(*ast.SelectorExpr); isSel && isIdent(none.X, bcsName) && none.Sel.Name == "NoneVariant" {
+			variants[id] = &ast.SelectorExpr{X: ast.NewIdent(bcsName), Sel: ast.NewIdent("None")}
+		} else {
+			return enumName, nil, false
+		}
+	}
+
+	return enumName, variants, true
+}
+
+// Returns name of type registered by call of bcs.RegisterIntEnumType*, which is either explicit type argument
+// or key type of map literal.
+func intEnumRegistration(call *ast.CallExpr, bcsName string) string {
+	fun := call.Fun
+	var typeArg ast.Expr
+
+	if index, ok := fun.(*ast.IndexExpr); ok {
+		fun, typeArg = index.X, index.Index
+	}
+
+	sel, ok := fun.(*ast.SelectorExpr)
+	if !ok || !isIdent(sel.X, bcsName) || !strings.HasPrefix(sel.Sel.Name, "RegisterIntEnumType") {
+		return ""
+	}
+
+	if typeArg == nil && len(call.Args) > 0 {
+		if lit, ok := call.Args[len(call.Args)-1].(*ast.CompositeLit); ok {
+			if m, ok := lit.Type.(*ast.MapType); ok {
+				typeArg = m.Key
+			}
+		}
+	}
+
+	if ident, ok := typeArg.(*ast.Ident); ok {
+		return ident.Name
+	}
+
+	return ""
+}
+
+// Returns type of expressions like T{}, &T{} or (*T)(nil).
+func variantTypeFromValue(v ast.Expr) ast.Expr {
+	switch v := v.(type) {
+	case *ast.CompositeLit:
+		return v.Type
+	case *ast.UnaryExpr:
+		if lit, ok := v.X.(*ast.CompositeLit); ok && v.Op == token.AND {
+			return &ast.StarExpr{X: lit.Type}
+		}
+	case *ast.CallExpr:
+		if len(v.Args) == 1 && isIdent(v.Args[0], "nil") {
+			if paren, ok := v.Fun.(*ast.ParenExpr); ok {
+				return paren.X
+			}
+		}
+	}
+
+	return nil
+}
+
+func intLiteral(e ast.Expr) (int, error) {
+	lit, ok := e.(*ast.BasicLit)
+	if !ok || lit.Kind != token.INT {
+		return 0, fmt.Errorf("not an integer literal")
+	}
+
+	v, err := strconv.ParseInt(lit.Value, 0, 64)
+	return int(v), err
+}
+
+func isIdent(e ast.Expr, name string) bool {
+	ident, ok := e.(*ast.Ident)
+	return ok && ident.Name == name
+}
+
+func (p *pkgInfo) fileImportName(f *ast.File, path string) string {
+	for _, imp := range f.Imports {
+		impPath, _ := strconv.Unquote(imp.Path.Value)
+		if impPath != path {
+			continue
+		}
+		if imp.Name != nil {
+			return imp.Name.Name
+		}
+		return importNameFromPath(path)
+	}
+
+	return ""
+}
+
+func (p *pkgInfo) fileImportPath(f *ast.File, name string) string {
+	for _, imp := range f.Imports {
+		path, _ := strconv.Unquote(imp.Path.Value)
+		if (imp.Name != nil && imp.Name.Name == name) || (imp.Name == nil && importNameFromPath(path) == name) {
+			return path
+		}
+	}
+
+	return ""
+}
+
+func importNameFromPath(path string) string {
+	name := filepath.Base(path)
+	if strings.HasPrefix(name, "v") {
+		if _, err := strconv.Atoi(name[1:]); err == nil {
+			// Major version suffix
+			name = filepath.Base(filepath.Dir(path))
+		}
+	}
+
+	return strings.TrimSuffix(strings.TrimPrefix(name, "go-"), "-go")
+}
+
+func (p *pkgInfo) exprString(e ast.Expr) string {
+	var b bytes.Buffer
+	_ = printer.Fprint(&b, p.fset, e)
+	return b.String()
+}
+
+// Records packages referenced by the expression, so that they could be imported by generated code.
+func (p *pkgInfo) useImports(e ast.Expr, f *ast.File) error {
+	var err error
+
+	ast.Inspect(e, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		ident, ok := sel.X.(*ast.Ident)
+		if !ok {
+			return true
+		}
+
+		path := p.fileImportPath(f, ident.Name)
+		if path == "" {
+			err = fmt.Errorf("cannot find import of package %v", ident.Name)
+			return false
+		}
+
+		if existing, ok := p.imports[ident.Name]; ok && existing != path {
+			err = fmt.Errorf("package name %v is used for different imports: %v and %v", ident.Name, existing, path)
+			return false
+		}
+
+		p.imports[ident.Name] = path
+
+		return false
+	})
+
+	return err
+}
+
+var basicTypes = map[string]string{
+	"bool":   "bool",
+	"string": "string",
+	"int":    "int",
+	"int8":   "int8",
+	"int16":  "int16",
+	"int32":  "int32",
+	"rune":   "int32",
+	"int64":  "int64",
+	"uint":   "uint",
+	"uint8":  "uint8",
+	"byte":   "uint8",
+	"uint16": "uint16",
+	"uint32": "uint32",
+	"uint64": "uint64",
+}
+
+// Names of methods, presence of which means that the type is encoded in a special way.
+var customizationMethods = []string{"MarshalBCS", "UnmarshalBCS", "Write", "Read", "BCSInit"}
+
+// Resolves type expression found in file f into typeRef.
+// Types from generated set are encoded by calling their generated methods.
+func (p *pkgInfo) resolveType(e ast.Expr, f *ast.File, generated map[string]bool) (*typeRef, error) {
+	switch e := e.(type) {
+	case *ast.ParenExpr:
+		return p.resolveType(e.X, f, generated)
+	case *ast.Ident:
+		if basic, ok := basicTypes[e.Name]; ok {
+			return &typeRef{kind: kindBasic, expr: e.Name, basic: basic, nullableKnown: true}, nil
+		}
+
+		decl := p.decls[e.Name]
+		if decl == nil {
+			// Predeclared types like any or error
+			return &typeRef{kind: kindOpaque, expr: e.Name, nullable: true, nullableKnown: e.Name == "any" || e.Name == "error"}, nil
+		}
+
+		return p.resolveDecl(decl, generated)
+	case *ast.StarExpr:
+		elem, err := p.resolveType(e.X, f, generated)
+		if err != nil {
+			return nil, err
+		}
+
+		return &typeRef{kind: kindPtr, expr: "*" + elem.expr, elem: elem, nullable: true, nullableKnown: true}, nil
+	case *ast.ArrayType:
+		elem, err := p.resolveType(e.Elt, f, generated)
+		if err != nil {
+			return nil, err
+		}
+
+		if e.Len == nil {
+			return &typeRef{kind: kindSlice, expr: "[]" + elem.expr, elem: elem, nullable: true, nullableKnown: true}, nil
+		}
+
+		if err := p.useImports(e.Len, f); err != nil {
+			return nil, err
+		}
+
+		return &typeRef{kind: kindArray, expr: "[" + p.exprString(e.Len) + "]" + elem.expr, elem: elem, nullableKnown: true}, nil
+	case *ast.MapType:
+		key, err := p.resolveType(e.Key, f, generated)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := p.resolveType(e.Value, f, generated)
+		if err != nil {
+			return nil, err
+		}
+
+		return &typeRef{kind: kindMap, expr: "map[" + key.expr + "]" + value.expr, key: key, elem: value, nullable: true, nullableKnown: true}, nil
+	case *ast.InterfaceType:
+		return &typeRef{kind: kindOpaque, expr: p.exprString(e), nullable: true, nullableKnown: true}, nil
+	default:
+		if err := p.useImports(e, f); err != nil {
+			return nil, err
+		}
+
+		// Types from other packages, generic instantiations etc. Their kind is unknown.
+		return &typeRef{kind: kindOpaque, expr: p.exprString(e)}, nil
+	}
+}
+
+func (p *pkgInfo) resolveDecl(decl *typeDecl, generated map[string]bool) (*typeRef, error) {
+	name := decl.name
+	opaque := &typeRef{kind: kindOpaque, expr: name, named: true, decl: decl}
+	opaque.nullable, opaque.nullableKnown = nullability(decl.spec.Type)
+
+	if decl.spec.TypeParams != nil {
+		return opaque, nil
+	}
+
+	if decl.spec.Assign.IsValid() {
+		// Alias
+		return p.resolveType(decl.spec.Type, decl.file, generated)
+	}
+
+	if generated[name] {
+		return &typeRef{kind: kindStruct, expr: name, named: true, decl: decl, nullableKnown: true}, nil
+	}
+
+	for _, m := range customizationMethods {
+		if decl.hasMethod(m) {
+			return opaque, nil
+		}
+	}
+
+	if p.intEnums[name] {
+		return opaque, nil
+	}
+
+	if p.resolving[name] {
+		// Recursive type, which is not struct, e.g. type A []A
+		return opaque, nil
+	}
+
+	switch decl.spec.Type.(type) {
+	case *ast.StructType:
+		return opaque, nil
+	case *ast.InterfaceType:
+		if _, isEnum := p.enums[name]; isEnum && !p.enumsWithUnknownVariant[name] {
+			return &typeRef{kind: kindEnum, expr: name, named: true, decl: decl, nullable: true, nullableKnown: true}, nil
+		}
+
+		return opaque, nil
+	}
+
+	var typeOpts bcs.TypeOptions
+	if m := decl.methods["BCSOptions"]; m != nil {
+		var ok bool
+		if typeOpts, ok = p.evalTypeOptions(m, decl.file); !ok {
+			return opaque, nil
+		}
+	}
+
+	p.resolving[name] = true
+	defer delete(p.resolving, name)
+
+	underlying, err := p.resolveType(decl.spec.Type, decl.file, generated)
+	if err != nil {
+		return nil, err
+	}
+
+	if underlying.kind == kindOpaque {
+		return opaque, nil
+	}
+
+	ref := *underlying
+	ref.expr = name
+	ref.named = true
+	ref.decl = decl
+	ref.typeOpts = typeOpts
+
+	return &ref, nil
+}
+
+// Detects if nil is a valid value of type with given definition.
+func nullability(e ast.Expr) (nullable, known bool) {
+	switch e := e.(type) {
+	case *ast.StructType:
+		return false, true
+	case *ast.InterfaceType, *ast.MapType, *ast.StarExpr, *ast.FuncType, *ast.ChanType:
+		return true, true
+	case *ast.ArrayType:
+		return e.Len == nil, true
+	case *ast.Ident:
+		_, isBasic := basicTypes[e.Name]
+		return false, isBasic
+	}
+
+	return false, false
+}
+
+// Statically evaluates body of BCSOptions() method, if it is as simple as "return bcs.TypeOptions{...}".
+// Nested options of elements, keys and values are not supported.
+func (p *pkgInfo) evalTypeOptions(m *ast.FuncDecl, f *ast.File) (opts bcs.TypeOptions, ok bool) {
+	if m.Body == nil || len(m.Body.List) != 1 {
+		return opts, false
+	}
+
+	ret, isRet := m.Body.List[0].(*ast.ReturnStmt)
+	if !isRet || len(ret.Results) != 1 {
+		return opts, false
+	}
+
+	lit, isLit := ret.Results[0].(*ast.CompositeLit)
+	if !isLit {
+		return opts, false
+	}
+
+	bcsName := p.fileImportName(f, bcsImportPath)
+	reflectName := p.fileImportName(f, "reflect")
+
+	for _, elt := range lit.Elts {
+		kv, isKV := elt.(*ast.KeyValueExpr)
+		if !isKV {
+			return opts, false
+		}
+
+		key, isKeyIdent := kv.Key.(*ast.Ident)
+		if !isKeyIdent {
+			return opts, false
+		}
+
+		switch key.Name {
+		case "IsCompactInt", "IsCompactZigZag", "InterfaceIsNotEnum", "NilIfEmpty", "ExportAnonymousField":
+			var val bool
+			switch {
+			case isIdent(kv.Value, "true"):
+				val = true
+			case isIdent(kv.Value, "false"):
+			default:
+				return opts, false
+			}
+
+			reflect.ValueOf(&opts).Elem().FieldByName(key.Name).SetBool(val)
+		case "LenSizeInBytes":
+			sel, isSel := kv.Value.(*ast.SelectorExpr)
+			switch {
+			case isSel && isIdent(sel.X, bcsName) && sel.Sel.Name == "Len2Bytes":
+				opts.LenSizeInBytes = bcs.Len2Bytes
+			case isSel && isIdent(sel.X, bcsName) && sel.Sel.Name == "Len4Bytes":
+				opts.LenSizeInBytes = bcs.Len4Bytes
+			default:
+				v, err := intLiteral(kv.Value)
+				if err != nil {
+					return opts, false
+				}
+				opts.LenSizeInBytes = bcs.LenBytesCount(v) //nolint:gosec
+			}
+		case "UnderlyingType":
+			sel, isSel := kv.Value.(*ast.SelectorExpr)
+			if !isSel || !isIdent(sel.X, reflectName) {
+				return opts, false
+			}
+
+			kind, known := kindsByName[sel.Sel.Name]
+			if !known {
+				return opts, false
+			}
+
+			opts.UnderlyingType = kind
+		default:
+			return opts, false
+		}
+	}
+
+	return opts, true
+}
+
+var kindsByName = map[string]reflect.Kind{
+	"Int": reflect.Int, "Int8": reflect.Int8, "Int16": reflect.Int16, "Int32": reflect.Int32, "Int64": reflect.Int64,
+	"Uint": reflect.Uint, "Uint8": reflect.Uint8, "Uint16": reflect.Uint16, "Uint32": reflect.Uint32, "Uint64": reflect.Uint64,
+}
+
+// Parses options of struct fields from their tags same way as bcs.FieldOptionsFromField does.
+func fieldOptions(field *ast.Field, t *typeRef, tagName string) (opts bcs.FieldOptions, hasTag bool, err error) {
+	var tag reflect.StructTag
+	if field.Tag != nil {
+		s, err := strconv.Unquote(field.Tag.Value)
+		if err != nil {
+			return opts, false, err
+		}
+		tag = reflect.StructTag(s)
+	}
+
+	structField := reflect.StructField{Tag: tag, Type: reflect.TypeOf(0)}
+
+	switch t.kind {
+	case kindSlice, kindArray:
+		structField.Type = reflect.TypeOf([]int{})
+	case kindMap:
+		structField.Type = reflect.TypeOf(map[int]int{})
+	}
+
+	return bcs.FieldOptionsFromField(structField, tagName)
+}
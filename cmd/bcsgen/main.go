@@ -0,0 +1,79 @@
+// Command bcsgen generates reflection-free MarshalBCS/UnmarshalBCS methods for struct types.
+//
+// Usage:
+//
+//	//go:generate go run github.com/iotaledger/bcs-go/cmd/bcsgen -type Foo,Bar
+//
+// The tool parses Go files of the package in the current directory and generates
+// methods, which call primitives of bcs.Encoder/bcs.Decoder directly instead of
+// walking the types using reflection. Tags "bcs", "bcs_elem", "bcs_key", "bcs_value",
+// struct enums and interface enums registered in the same package using RegisterEnumTypeN
+// are supported. Values of types, which cannot be analyzed statically (e.g. types from other
+// packages or types with custom codecs), are encoded through the regular reflection-based path.
+//
+// Variants of interface enums are frozen at generation time: generated code uses variant ids from
+// registrations found in the sources, which correspond to the default registry. Registrations in other
+// registries set using EncoderConfig.Registry or DecoderConfig.Registry are not used by generated code.
+//
+// Encoded bytes are identical to the ones produced by the reflection-based path. This is checked
+// by generated round-trip tests.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	typesList := flag.String("type", "", "comma-separated list of type names; all struct types of the package by default")
+	output := flag.String("output", "", "output file name; default <package>_bcs.go")
+	tagName := flag.String("tag", "bcs", "name of struct field tag")
+	withTests := flag.Bool("tests", true, "generate round-trip tests into <output>_test.go")
+	flag.Parse()
+
+	dir := "."
+	if args := flag.Args(); len(args) > 0 {
+		dir = args[0]
+	}
+
+	var typeNames []string
+	if *typesList != "" {
+		typeNames = strings.Split(*typesList, ",")
+	}
+
+	if err := run(dir, typeNames, *output, *tagName, *withTests); err != nil {
+		fmt.Fprintf(os.Stderr, "bcsgen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(dir string, typeNames []string, output, tagName string, withTests bool) error {
+	pkg, err := loadPackage(dir)
+	if err != nil {
+		return err
+	}
+
+	if output == "" {
+		output = pkg.name + "_bcs.go"
+	}
+
+	code, testCode, err := generate(pkg, typeNames, tagName)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, output), code, 0o644); err != nil { //nolint:gosec
+		return err
+	}
+
+	if !withTests {
+		return nil
+	}
+
+	testOutput := strings.TrimSuffix(output, ".go") + "_test.go"
+
+	return os.WriteFile(filepath.Join(dir, testOutput), testCode, 0o644) //nolint:gosec
+}
@@ -29,11 +29,14 @@ func TestArrayCodec(t *testing.T) {
 
 	bcs.TestCodecAndBytes(t, [3]int64{42, 43, 44}, []byte{0x2a, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x2b, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x2c, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0})
 	bcs.TestCodecAndBytes(t, [3]byte{42, 43, 44}, []byte{0x2a, 0x2b, 0x2c})
+	bcs.TestCodecAndBytes(t, []NamedByte{42, 43}, []byte{0x2, 0x2A, 0x2B})
 
 	bcs.TestCodecAndBytes(t, []string{"aaa", "bbb"}, []byte{0x2, 0x3, 0x61, 0x61, 0x61, 0x3, 0x62, 0x62, 0x62})
 	bcs.TestCodecAndBytes(t, [][]int16{{1, 2}, {3, 4, 5}}, []byte{0x2, 0x2, 0x1, 0x0, 0x2, 0x0, 0x3, 0x3, 0x0, 0x4, 0x0, 0x5, 0x0})
 }
 
+type NamedByte byte
+
 func TestDecodeMalformedRegularSlice(t *testing.T) {
 	e := bcs.NewBytesEncoder()
 
@@ -44,7 +47,7 @@ func TestDecodeMalformedRegularSlice(t *testing.T) {
 
 	_, err := bcs.Unmarshal[[]BasicWithCustomCodec](e.Bytes())
 	require.Error(t, err)
-	require.True(t, errors.Is(err, io.EOF))
+	require.True(t, errors.Is(err, io.ErrUnexpectedEOF))
 }
 
 func TestDecodeMalformedBytesSlice(t *testing.T) {
@@ -58,7 +61,7 @@ func TestDecodeMalformedBytesSlice(t *testing.T) {
 
 	_, err := bcs.Unmarshal[[]byte](e.Bytes())
 	require.Error(t, err)
-	require.True(t, errors.Is(err, io.EOF))
+	require.True(t, errors.Is(err, io.ErrUnexpectedEOF))
 }
 
 func TestMapCodec(t *testing.T) {
@@ -3,9 +3,13 @@ package bcs
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
+	"math"
+	"math/big"
 	"reflect"
+	"unicode/utf8"
 	"unsafe"
 
 	"github.com/samber/lo"
@@ -47,7 +51,7 @@ func UnmarshalStreamInto[V any](r io.Reader, v *V) (*V, error) {
 	d := NewDecoder(r)
 	d.Decode(v)
 	if d.err != nil {
-		return nil, d.err
+		return nil, d.Err()
 	}
 
 	return v, nil
@@ -69,7 +73,13 @@ func UnmarshalInto[V any](b []byte, v *V) (*V, error) {
 	}
 
 	if r.Len() > 0 {
-		return nil, fmt.Errorf("excess bytes: %v", r.Len())
+		return nil, &DecodeError{
+			Kind:   ErrExcessBytes,
+			Path:   rootTypeName(reflect.TypeOf(v)),
+			Type:   reflect.TypeOf(v).Elem(),
+			Offset: r.Size() - int64(r.Len()),
+			Err:    fmt.Errorf("excess bytes: %v", r.Len()),
+		}
 	}
 
 	return v, nil
@@ -118,7 +128,7 @@ func Decode[V any](dec *Decoder) V {
 func MustDecode[V any](dec *Decoder) V {
 	v := Decode[V](dec)
 	if dec.err != nil {
-		panic(fmt.Errorf("failed to decode object of type %T: %w", v, dec.err))
+		panic(fmt.Errorf("failed to decode object of type %T: %w", v, dec.Err()))
 	}
 
 	return v
@@ -141,6 +151,9 @@ type (
 	InitFunc      func(v reflect.Value) error
 )
 
+// CustomDecoders contains decoders registered in DefaultRegistry.
+//
+// Deprecated: direct modification is not thread-safe. Use AddCustomDecoder or Registry instead.
 var CustomDecoders = make(map[reflect.Type]CustomDecoder)
 
 func MakeCustomDecoder[V any](f func(e *Decoder, v *V) error) func(e *Decoder, v reflect.Value) error {
@@ -150,37 +163,54 @@ func MakeCustomDecoder[V any](f func(e *Decoder, v *V) error) func(e *Decoder, v
 }
 
 func AddCustomDecoder[V any](f func(e *Decoder, v *V) error) struct{} {
-	t := reflect.TypeOf((*V)(nil)).Elem()
-	if CustomDecoders[t] != nil {
-		panic(fmt.Errorf("custom decoder for type %v is already registered", t))
-	}
-	CustomDecoders[t] = MakeCustomDecoder(f)
-	return struct{}{}
+	return AddCustomDecoderTo(DefaultRegistry, f)
 }
 
 func RemoveCustomDecoder[V any]() {
-	delete(CustomDecoders, reflect.TypeOf((*V)(nil)).Elem())
+	RemoveCustomDecoderFrom[V](DefaultRegistry)
 }
 
 type DecoderConfig struct {
 	TagName                  string
 	InterfaceIsEnumByDefault bool
-	// CustomDecoders map[reflect.Type]CustomDecoder
+	Limits                   DecoderLimits
+	// Strict forces decoder to reject any payload, which is not the only valid encoding of a value.
+	// Useful when encoded bytes are signed or hashed.
+	Strict bool
+	// Registry of custom decoders and enums. DefaultRegistry is used if nil.
+	Registry *Registry
+	// ZeroCopy makes BytesDecoder return []byte and string values, which reference the input, instead of copying them.
+	// It applies to byte slices, strings and Raw values. Arrays of constant length are always copied.
+	// Decoded values stay valid as long as the input is valid, so the input must not be modified after decoding:
+	// any change of it would be visible in the decoded values, including strings, which are expected to be immutable.
+	// Keeping a small decoded value also keeps whole input in memory.
+	// Has no effect for decoders created from io.Reader.
+	ZeroCopy bool
 }
 
 func (c *DecoderConfig) InitializeDefaults() {
 	if c.TagName == "" {
 		c.TagName = "bcs"
 	}
+	if c.Registry == nil {
+		c.Registry = DefaultRegistry
+	}
 }
 
 func NewBytesDecoder(b []byte) *BytesDecoder {
+	return NewBytesDecoderWithOpts(b, DecoderConfig{})
+}
+
+func NewBytesDecoderWithOpts(b []byte, cfg DecoderConfig) *BytesDecoder {
 	r := bytes.NewReader(b)
-	return &BytesDecoder{
-		Decoder: *NewDecoder(r),
+	d := &BytesDecoder{
+		Decoder: *NewDecoderWithOpts(r, cfg),
 		buf:     r,
 		b:       b,
 	}
+	d.input, d.inputReader = b, r
+
+	return d
 }
 
 type BytesDecoder struct {
@@ -222,7 +252,7 @@ func NewDecoderWithOpts(src io.Reader, cfg DecoderConfig) *Decoder {
 	return &Decoder{
 		cfg:           cfg,
 		r:             src,
-		typeInfoCache: decoderGlobalTypeInfoCache.Get(),
+		typeInfoCache: cfg.Registry.decoderTypeInfoCache.Get(),
 	}
 }
 
@@ -231,16 +261,46 @@ type Decoder struct {
 	r             io.Reader
 	err           error
 	typeInfoCache localTypeInfoCache
+	path          valuePath
+	depth         int
+	allocated     int
+	// Structs, which plans are being compiled.
+	compilingStructs []reflect.Type
+
+	// Count of bytes read from the stream.
+	offset int64
+	// Location of the first failure.
+	failure *DecodeError
+
+	// Buffer for reading fixed-size values without allocations.
+	scratch [32]byte
+
+	// Input of BytesDecoder and its reader. When ZeroCopy is enabled and decoder reads from inputReader,
+	// bytes are returned as subslices of input instead of copying.
+	input       []byte
+	inputReader *bytes.Reader
+
+	// Path of unknown enum variant, which payload took all remaining bytes of current input.
+	// If anything is read after it, the variant was not the last value of the input.
+	restTakenBy string
 }
 
+// Err returns *DecodeError describing the first failure of decoder or nil if there were no failures.
 func (d *Decoder) Err() error {
-	return d.err
+	if d.err == nil {
+		return nil
+	}
+
+	err := *d.failure
+	err.Err = d.err
+
+	return &err
 }
 
 func (d *Decoder) MustDecode(v any) {
 	d.Decode(v)
 	if d.err != nil {
-		panic(d.err)
+		panic(d.Err())
 	}
 }
 
@@ -267,6 +327,12 @@ func (d *Decoder) MustDecode(v any) {
 //	    return nil
 //	}
 func (d *Decoder) Decode(v any) {
+	d.DecodeWithOptions(v, nil)
+}
+
+// DecodeWithOptions is same as Decode, but also applies options to the value same way as if they were specified
+// in a tag of struct field.
+func (d *Decoder) DecodeWithOptions(v any, opts *TypeOptions) {
 	if d.err != nil {
 		return
 	}
@@ -274,17 +340,26 @@ func (d *Decoder) Decode(v any) {
 	vR := reflect.ValueOf(v)
 
 	if vR.Kind() != reflect.Ptr {
-		_ = d.handleErrorf("Decode destination must be a pointer")
+		_ = d.kindErrorf(ErrUnsupportedType, "Decode destination must be a pointer")
 		return
 	}
 	if vR.IsNil() {
-		_ = d.handleErrorf("Decode destination cannot be nil")
+		_ = d.kindErrorf(ErrNilValue, "Decode destination cannot be nil")
 		return
 	}
 
-	defer d.typeInfoCache.Save()
+	// Decode() could be called from custom decoder, so path may be already non-empty.
+	pathLen := len(d.path)
+	defer func() { d.path = d.path[:pathLen] }()
+
+	if pathLen == 0 {
+		d.typeInfoCache.Refresh()
+		d.path.pushField(rootTypeName(vR.Type()))
+		// Each top-level value has its own allocations budget.
+		d.allocated = 0
+	}
 
-	if err := d.decodeValue(vR, nil, nil); err != nil {
+	if err := d.decodeValue(vR, opts, nil, nil); err != nil {
 		_ = d.handleErrorf("decoding %T: %w", v, err)
 		return
 	}
@@ -313,18 +388,38 @@ func (d *Decoder) ReadOptionalFlag() bool {
 	case 1:
 		return true
 	default:
-		_ = d.handleErrorf("invalid optional flag value: %v", f)
+		_ = d.kindErrorf(ErrInvalidOptionalFlag, "invalid optional flag value: %v", f)
 		return false
 	}
 }
 
 // Enum index is an index of variant in enum type.
 func (d *Decoder) ReadEnumIdx() int {
-	return int(d.ReadCompactUint64())
+	idx := d.ReadCompactUint64()
+	if d.cfg.Strict && idx > MaxEnumVariantIdx {
+		_ = d.nonCanonicalErrorf("enum variant index %v exceeds %v", idx, uint64(MaxEnumVariantIdx))
+		return 0
+	}
+	if idx > math.MaxInt {
+		_ = d.kindErrorf(ErrOverflow, "enum variant index %v is out of range of type int", idx)
+		return 0
+	}
+
+	return int(idx)
 }
 
 func (d *Decoder) ReadLen() int {
-	return int(d.ReadCompactUint64())
+	length := d.ReadCompactUint64()
+	if d.cfg.Strict && length > MaxSequenceLength {
+		_ = d.nonCanonicalErrorf("length %v exceeds %v", length, MaxSequenceLength)
+		return 0
+	}
+	if length > math.MaxInt {
+		_ = d.kindErrorf(ErrOverflow, "length %v is out of range of type int", length)
+		return 0
+	}
+
+	return int(length)
 }
 
 func (d *Decoder) ReadCompactUint64() uint64 {
@@ -345,6 +440,11 @@ func (d *Decoder) ReadCompactUint64() uint64 {
 			return 0
 		}
 		if b < 0x80 {
+			if b == 0 && d.cfg.Strict {
+				_ = d.nonCanonicalErrorf("compact uint64 has redundant trailing zero byte")
+				return 0
+			}
+
 			return value | (uint64(b) << shift)
 		}
 		value |= uint64(b&0x7f) << shift
@@ -357,17 +457,27 @@ func (d *Decoder) ReadCompactUint64() uint64 {
 
 	// must be the final bit (since we already encoded 63 bits)
 	if b > 0x01 {
-		_ = d.handleErrorf("compact uint64 overflow")
+		_ = d.kindErrorf(ErrOverflow, "compact uint64 overflow")
+		return 0
+	}
+	if b == 0 && d.cfg.Strict {
+		_ = d.nonCanonicalErrorf("compact uint64 has redundant trailing zero byte")
 		return 0
 	}
 
 	return value | (uint64(b) << 63)
 }
 
+// ReadCompactInt64 reads signed integer written by Encoder.WriteCompactInt64.
+func (d *Decoder) ReadCompactInt64() int64 {
+	u := d.ReadCompactUint64()
+	return int64(u>>1) ^ -int64(u&1) //nolint:gosec
+}
+
 func (d *Decoder) readByte() (byte, error) {
-	var b [1]byte
-	_, err := d.Read(b[:])
-	return b[0], err
+	d.scratch[0] = 0
+	_, err := d.Read(d.scratch[:1])
+	return d.scratch[0], err
 }
 
 func (d *Decoder) ReadBool() bool {
@@ -378,7 +488,7 @@ func (d *Decoder) ReadBool() bool {
 	case 1:
 		return true
 	default:
-		_ = d.handleErrorf("invalid bool value: %v", b)
+		_ = d.kindErrorf(ErrInvalidBool, "invalid bool value: %v", b)
 		return false
 	}
 }
@@ -437,11 +547,46 @@ func (d *Decoder) ReadUint64() uint64 {
 }
 
 func (d *Decoder) ReadInt() int {
-	return int(d.ReadInt64())
+	v := d.ReadInt64()
+	if v < math.MinInt || v > math.MaxInt {
+		_ = d.kindErrorf(ErrOverflow, "value %v is out of range of type int", v)
+		return 0
+	}
+
+	return int(v)
 }
 
 func (d *Decoder) ReadUint() uint {
-	return uint(d.ReadUint64())
+	v := d.ReadUint64()
+	if v > math.MaxUint {
+		_ = d.kindErrorf(ErrOverflow, "value %v is out of range of type uint", v)
+		return 0
+	}
+
+	return uint(v)
+}
+
+// ReadCollectionLen reads length of a collection and checks it against limits of decoder.
+// Size of collection element is used to account allocation of the collection.
+func (d *Decoder) ReadCollectionLen(elemSize int) int {
+	length := d.ReadLen()
+	if d.err != nil || d.checkCollectionLen(length) != nil || d.chargeAlloc(length, elemSize) != nil {
+		return 0
+	}
+
+	return length
+}
+
+// Reads variable-length array of bytes prepended by its length.
+func (d *Decoder) ReadBytes() []byte {
+	length := d.ReadLen()
+	if d.err != nil || d.checkByteLen(length) != nil || d.chargeAlloc(length, 1) != nil {
+		return nil
+	}
+
+	b, _ := d.readN(length)
+
+	return b
 }
 
 func (d *Decoder) ReadString() string {
@@ -450,37 +595,142 @@ func (d *Decoder) ReadString() string {
 		return ""
 	}
 
-	b, _ := d.ReadN(length)
+	if d.checkByteLen(length) != nil || d.chargeAlloc(length, 1) != nil {
+		return ""
+	}
+
+	if b, ok := d.view(length); ok {
+		if d.cfg.Strict && !utf8.Valid(b) {
+			_ = d.nonCanonicalErrorf("string is not valid UTF-8")
+			return ""
+		}
+
+		return unsafe.String(unsafe.SliceData(b), len(b))
+	}
+
+	b, _ := d.readN(length)
+
+	if d.cfg.Strict && d.err == nil && !utf8.Valid(b) {
+		_ = d.nonCanonicalErrorf("string is not valid UTF-8")
+		return ""
+	}
 
 	return string(b)
 }
 
+// Read reads exactly len(b) bytes. Underlying reader may return less bytes per call (e.g. network connection or pipe),
+// so reading is repeated until b is filled. If input ends earlier, io.ErrUnexpectedEOF is returned.
 func (d *Decoder) Read(b []byte) (n int, _ error) {
 	if d.err != nil {
 		return 0, d.err
 	}
 
-	n, d.err = d.r.Read(b)
+	n, err := io.ReadFull(d.r, b)
+	d.offset += int64(n)
+	if err != nil {
+		if err == io.EOF {
+			// Decoder reads only bytes, which are expected to be there. So end of input is unexpected even if nothing was read.
+			err = io.ErrUnexpectedEOF
+		}
+
+		_ = d.setReadErr(err)
+	}
 
 	return n, d.err
 }
 
+// Sets error of reading from input. If input has ended, because it was taken by payload of unknown enum variant,
+// failure is reported as invalid position of the variant instead of unexpected end of input.
+func (d *Decoder) setReadErr(err error) error {
+	if d.restTakenBy != "" && errors.Is(err, io.ErrUnexpectedEOF) {
+		return d.kindErrorf(ErrInvalidEnumVariant, "payload of unknown enum variant at %v took all remaining bytes, "+
+			"so it must be the last value of the input or be wrapped into bytearr: %w", d.restTakenBy, err)
+	}
+
+	return d.setErr(nil, err)
+}
+
+// Reads all remaining bytes of input. Limits of decoder are checked.
+func (d *Decoder) readRest() ([]byte, error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	// Reading one byte more than allowed to detect that limit is exceeded.
+	maxLen := int64(-1)
+	if limit := d.cfg.Limits.MaxByteLen; limit > 0 {
+		maxLen = int64(limit)
+	}
+	if limit := d.cfg.Limits.MaxAllocBytes; limit > 0 && (maxLen < 0 || int64(limit-d.allocated) < maxLen) {
+		maxLen = int64(limit - d.allocated)
+	}
+
+	if d.canView() {
+		length := d.inputReader.Len()
+		if err := d.checkByteLen(length); err != nil {
+			return nil, err
+		}
+		if err := d.chargeAlloc(length, 1); err != nil {
+			return nil, err
+		}
+
+		b, _ := d.view(length)
+
+		return b, nil
+	}
+
+	r := d.r
+	if maxLen >= 0 {
+		r = io.LimitReader(d.r, maxLen+1)
+	}
+
+	b, err := io.ReadAll(r)
+	d.offset += int64(len(b))
+	if err != nil {
+		return nil, d.setErr(nil, err)
+	}
+
+	if err := d.checkByteLen(len(b)); err != nil {
+		return nil, err
+	}
+	if err := d.chargeAlloc(len(b), 1); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
 const maxReadNBufferSize = 1024
 
 // This is safer to use, then Read() method, because it does not require to create entire buffer from the start.
 // It helps to avoid huge allocations in case of corrupted payload.
 // And it is not as slow as reading byte by byte.
+// If ZeroCopy is enabled, the result references input of BytesDecoder.
 func (d *Decoder) ReadN(bytesToRead int) ([]byte, error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+	if err := d.chargeAlloc(bytesToRead, 1); err != nil {
+		return nil, err
+	}
+
+	return d.readN(bytesToRead)
+}
+
+// Same as ReadN, but does not account read bytes against allocation budget.
+func (d *Decoder) readN(bytesToRead int) ([]byte, error) {
 	if d.err != nil {
 		return nil, d.err
 	}
 	if bytesToRead == 0 {
 		return []byte{}, nil
 	}
+	if b, ok := d.view(bytesToRead); ok {
+		return b, nil
+	}
 
 	res := make([]byte, min(maxReadNBufferSize, bytesToRead))
-	_, d.err = d.r.Read(res)
-	if bytesToRead <= maxReadNBufferSize || d.err != nil {
+	if _, err := d.Read(res); bytesToRead <= maxReadNBufferSize || err != nil {
 		return res, d.err
 	}
 
@@ -488,10 +738,9 @@ func (d *Decoder) ReadN(bytesToRead int) ([]byte, error) {
 	batchBuff := make([]byte, min(maxReadNBufferSize, bytesToRead))
 
 	for bytesToRead > 0 {
-		var n int
-		n, d.err = d.r.Read(batchBuff[:min(maxReadNBufferSize, bytesToRead)])
-		if d.err != nil {
-			return nil, d.err
+		n, err := d.Read(batchBuff[:min(maxReadNBufferSize, bytesToRead)])
+		if err != nil {
+			return nil, err
 		}
 
 		res = append(res, batchBuff[:n]...)
@@ -505,8 +754,47 @@ func (d *Decoder) ReadN(bytesToRead int) ([]byte, error) {
 	return res, nil
 }
 
+// Checks if bytes could be returned as subslices of input. This is possible only if ZeroCopy is enabled and
+// decoder reads directly from the input (e.g. not capturing bytes).
+func (d *Decoder) canView() bool {
+	return d.cfg.ZeroCopy && d.inputReader != nil && d.r == io.Reader(d.inputReader)
+}
+
+// Returns next n bytes of input without copying them. Returns false if that is not possible,
+// in which case nothing is read.
+func (d *Decoder) view(n int) ([]byte, bool) {
+	if d.err != nil || !d.canView() || d.inputReader.Len() < n {
+		return nil, false
+	}
+
+	pos := len(d.input) - d.inputReader.Len()
+	if _, err := d.inputReader.Seek(int64(n), io.SeekCurrent); err != nil {
+		return nil, false
+	}
+
+	d.offset += int64(n)
+
+	// Capacity is limited to prevent appending to the result from overwriting the input.
+	return d.input[pos : pos+n : pos+n], true
+}
+
+// Decodes value v. Info of its type and merged options could be passed same way as for Encoder.encodeValue.
+//
 //nolint:gocyclo,funlen
-func (d *Decoder) decodeValue(v reflect.Value, typeOptionsFromTag *TypeOptions, tInfo *typeInfo) error {
+func (d *Decoder) decodeValue(v reflect.Value, typeOptionsFromTag *TypeOptions, tInfo *typeInfo, mergedTypeOptions *TypeOptions) (err error) {
+	if limit := d.cfg.Limits.MaxDepth; limit > 0 && d.depth >= limit {
+		return d.limitErrorf("MaxDepth", d.depth+1, limit)
+	}
+
+	d.depth++
+	defer func() {
+		d.depth--
+		if err != nil && d.failure != nil && d.failure.Type == nil {
+			// The innermost value is the one, which failed.
+			d.failure.Type = v.Type()
+		}
+	}()
+
 	if tInfo == nil {
 		// Hint about type customization could have been provided by caller when decoding collections.
 		// This is done to avoid parsing type for each element of collection.
@@ -521,10 +809,22 @@ func (d *Decoder) decodeValue(v reflect.Value, typeOptionsFromTag *TypeOptions,
 
 	v = d.getDecodedValueStorage(v, tInfo.RefLevelsCount)
 
+	if typeOptionsFromTag != nil && typeOptionsFromTag.BigIntEncoding != BigIntDefault {
+		if v.Type() != bigIntT {
+			return d.kindErrorf(ErrInvalidOptions, "%v: bigint option is applicable only to big.Int", v.Type())
+		}
+
+		if err := d.decodeBigInt(v.Addr().Interface().(*big.Int), typeOptionsFromTag.BigIntEncoding); err != nil {
+			return d.handleErrorf("%v: %v: %w", v.Type(), typeOptionsFromTag.BigIntEncoding, err)
+		}
+
+		return nil
+	}
+
 	if tInfo.CustomDecoder != nil {
 		if err := tInfo.CustomDecoder(d, v.Addr()); err != nil {
 			if d.err == nil {
-				d.err = err
+				_ = d.setErr(nil, err)
 			}
 			return d.handleErrorf("%v: custom decoder: %w", v.Type(), err)
 		}
@@ -541,29 +841,46 @@ func (d *Decoder) decodeValue(v reflect.Value, typeOptionsFromTag *TypeOptions,
 		return nil
 	}
 
-	var typeOptions TypeOptions
-	if tInfo.HasTypeOptions {
-		typeOptions = v.Interface().(BCSType).BCSOptions()
-	}
-	if typeOptionsFromTag != nil {
-		typeOptions.Update(*typeOptionsFromTag)
+	if tInfo.IntEnumNames != nil {
+		if err := d.decodeIntEnum(v, tInfo.IntEnumNames); err != nil {
+			return d.handleErrorf("%v: %w", v.Type(), err)
+		}
+
+		return nil
 	}
 
-	var err error
+	var typeOptions TypeOptions
+	if mergedTypeOptions != nil {
+		typeOptions = *mergedTypeOptions
+	} else {
+		if tInfo.HasTypeOptions {
+			typeOptions = tInfo.TypeOptions
+		}
+		if typeOptionsFromTag != nil {
+			typeOptions.Update(*typeOptionsFromTag)
+		}
+	}
 
 	switch v.Kind() {
 	case reflect.Bool:
 		v.SetBool(d.ReadBool())
 	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int:
-		if typeOptions.IsCompactInt {
-			v.SetInt(int64(d.ReadCompactUint64())) //nolint:gosec
-		} else {
+		switch {
+		case typeOptions.IsCompactInt && typeOptions.IsCompactZigZag:
+			err = d.setCheckedInt(v, d.ReadCompactInt64())
+		case typeOptions.IsCompactInt:
+			// Negative values are encoded as their two's complement
+			err = d.setCheckedInt(v, int64(d.ReadCompactUint64())) //nolint:gosec
+		default:
 			err = d.decodeInt(v, typeOptions.UnderlyingType)
 		}
 	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint:
-		if typeOptions.IsCompactInt {
-			v.SetUint(d.ReadCompactUint64())
-		} else {
+		switch {
+		case typeOptions.IsCompactInt && typeOptions.IsCompactZigZag:
+			return d.kindErrorf(ErrInvalidOptions, "%v: zigzag encoding is applicable only to signed integers", v.Type())
+		case typeOptions.IsCompactInt:
+			err = d.setCheckedUint(v, d.ReadCompactUint64())
+		default:
 			err = d.decodeUint(v, typeOptions.UnderlyingType)
 		}
 	case reflect.String:
@@ -588,14 +905,14 @@ func (d *Decoder) decodeValue(v reflect.Value, typeOptionsFromTag *TypeOptions,
 		err = d.decodeMap(v, typeOptions)
 	case reflect.Struct:
 		if tInfo.IsStructEnum {
-			err = d.decodeStructEnum(v)
+			err = d.decodeStructEnum(v, tInfo)
 		} else {
 			err = d.decodeStruct(v, tInfo)
 		}
 	case reflect.Interface:
 		err = d.decodeInterface(v, !typeOptions.InterfaceIsNotEnum)
 	default:
-		return d.handleErrorf("%v: cannot decode unknown type", v.Type())
+		return d.kindErrorf(ErrUnsupportedType, "%v: cannot decode unknown type", v.Type())
 	}
 
 	if err != nil {
@@ -614,7 +931,7 @@ func (d *Decoder) decodeValue(v reflect.Value, typeOptionsFromTag *TypeOptions,
 	return nil
 }
 
-func (d *Decoder) checkTypeCustomizations(t reflect.Type) typeCustomization {
+func (d *Decoder) checkTypeCustomizations(t reflect.Type) (typeCustomization, error) {
 	customDecoder := d.getCustomDecoder(t)
 	customInitFunc := d.getCustomInitFunc(t)
 
@@ -622,21 +939,32 @@ func (d *Decoder) checkTypeCustomizations(t reflect.Type) typeCustomization {
 		return typeCustomization{
 			CustomDecoder: customDecoder,
 			Init:          customInitFunc,
-		}
+		}, nil
 	}
 
 	kind := t.Kind()
 
 	switch {
 	case kind == reflect.Interface:
-		return typeCustomization{}
+		return typeCustomization{}, nil
 	case kind == reflect.Struct && t.Implements(structEnumT):
-		return typeCustomization{IsStructEnum: true}
-	case t.Implements(bcsTypeT):
-		return typeCustomization{HasTypeOptions: true}
+		variantIDs, err := structEnumVariantIDs(t, d.cfg.TagName, d.kindErrorf)
+		if err != nil {
+			return typeCustomization{}, err
+		}
+
+		return typeCustomization{IsStructEnum: true, EnumVariantIDs: variantIDs}, nil
+	case isIntKind(kind):
+		if names, registered := d.cfg.Registry.IntEnumVariants(t); registered {
+			return typeCustomization{IntEnumNames: names}, nil
+		}
+	}
+
+	if t.Implements(bcsTypeT) {
+		return typeCustomization{HasTypeOptions: true, TypeOptions: typeOptionsOf(t)}, nil
 	}
 
-	return typeCustomization{}
+	return typeCustomization{}, nil
 }
 
 func (d *Decoder) getEncodedTypeInfo(t reflect.Type) (typeInfo, error) {
@@ -651,7 +979,10 @@ func (d *Decoder) getEncodedTypeInfo(t reflect.Type) (typeInfo, error) {
 
 	for t.Kind() == reflect.Ptr {
 		// Before dereferencing pointer, we should check if maybe current type is already the type we should decode.
-		customization := d.checkTypeCustomizations(t)
+		customization, err := d.checkTypeCustomizations(t)
+		if err != nil {
+			return typeInfo{}, err
+		}
 		if customization.HasCustomizations() {
 			res := typeInfo{RefLevelsCount: refLevelsCount, typeCustomization: customization}
 			d.typeInfoCache.Add(initialT, res)
@@ -663,16 +994,22 @@ func (d *Decoder) getEncodedTypeInfo(t reflect.Type) (typeInfo, error) {
 		t = t.Elem()
 	}
 
-	customization := d.checkTypeCustomizations(t)
+	customization, err := d.checkTypeCustomizations(t)
+	if err != nil {
+		return typeInfo{}, err
+	}
 
 	res := typeInfo{RefLevelsCount: refLevelsCount, typeCustomization: customization}
 
 	if t.Kind() == reflect.Struct {
-		var err error
 		res.FieldOptions, res.FieldHasTag, err = FieldOptionsFromStruct(t, d.cfg.TagName)
 		if err != nil {
-			return typeInfo{}, d.handleErrorf("parsing struct fields options: %w", err)
+			return typeInfo{}, d.kindErrorf(ErrInvalidOptions, "parsing struct fields options: %w", err)
 		}
+
+		d.compilingStructs = append(d.compilingStructs, t)
+		res.StructPlan = compileStructPlan(t, res.FieldOptions, res.FieldHasTag, d.fieldTypeInfo)
+		d.compilingStructs = d.compilingStructs[:len(d.compilingStructs)-1]
 	}
 
 	d.typeInfoCache.Add(initialT, res)
@@ -706,7 +1043,7 @@ func (d *Decoder) getDecodedValueStorage(v reflect.Value, refLevelsCount int) (d
 }
 
 func (d *Decoder) getCustomDecoder(t reflect.Type) CustomDecoder {
-	if customDecoder, ok := CustomDecoders[t]; ok {
+	if customDecoder := d.cfg.Registry.customDecoder(t); customDecoder != nil {
 		return customDecoder
 	}
 
@@ -755,8 +1092,11 @@ func (d *Decoder) decodeInt(v reflect.Value, encodedType reflect.Kind) error {
 		v.SetInt(int64(d.ReadInt16()))
 	case reflect.Int32:
 		v.SetInt(int64(d.ReadInt32()))
-	case reflect.Int64, reflect.Int:
+	case reflect.Int64:
 		v.SetInt(d.ReadInt64())
+	case reflect.Int:
+		// Int has only 4 bytes on 32-bit platforms
+		return d.setCheckedInt(v, d.ReadInt64())
 	default:
 		panic(fmt.Sprintf("unexpected int kind: %v", k))
 	}
@@ -778,8 +1118,10 @@ func (d *Decoder) decodeUint(v reflect.Value, encodedType reflect.Kind) error {
 		v.SetUint(uint64(d.ReadUint16()))
 	case reflect.Uint32:
 		v.SetUint(uint64(d.ReadUint32()))
-	case reflect.Uint64, reflect.Uint:
+	case reflect.Uint64:
 		v.SetUint(d.ReadUint64())
+	case reflect.Uint:
+		return d.setCheckedUint(v, d.ReadUint64())
 	default:
 		panic(fmt.Sprintf("unexpected uint kind: %v", k))
 	}
@@ -787,6 +1129,34 @@ func (d *Decoder) decodeUint(v reflect.Value, encodedType reflect.Kind) error {
 	return nil
 }
 
+// Sets decoded value i into v, if it fits into the type of v.
+func (d *Decoder) setCheckedInt(v reflect.Value, i int64) error {
+	if d.err != nil {
+		return d.err
+	}
+	if v.OverflowInt(i) {
+		return d.kindErrorf(ErrOverflow, "value %v is out of range of type %v", i, v.Type())
+	}
+
+	v.SetInt(i)
+
+	return nil
+}
+
+// Sets decoded value u into v, if it fits into the type of v.
+func (d *Decoder) setCheckedUint(v reflect.Value, u uint64) error {
+	if d.err != nil {
+		return d.err
+	}
+	if v.OverflowUint(u) {
+		return d.kindErrorf(ErrOverflow, "value %v is out of range of type %v", u, v.Type())
+	}
+
+	v.SetUint(u)
+
+	return nil
+}
+
 func decodeConvertNumber(d *Decoder, v reflect.Value, encodedType reflect.Kind) error {
 	switch v.Kind() {
 	case reflect.Int8:
@@ -830,7 +1200,7 @@ func decodeConvertNumber2[RealType constraints.Numeric](d *Decoder, encodedType
 	case reflect.Uint64, reflect.Uint:
 		return decodeConvertNumber3(d, d.ReadUint64, set)
 	default:
-		return d.handleErrorf("invalid underlaying type %v for type %T", encodedType, lo.Empty[RealType]())
+		return d.kindErrorf(ErrInvalidOptions, "invalid underlaying type %v for type %T", encodedType, lo.Empty[RealType]())
 	}
 }
 
@@ -840,7 +1210,7 @@ func decodeConvertNumber3[To, From constraints.Numeric](d *Decoder, read func()
 	converted := To(v)
 
 	if From(converted) != v {
-		return d.handleErrorf("value %v is out of range of type %T", v, To(0))
+		return d.kindErrorf(ErrOverflow, "value %v is out of range of type %T", v, To(0))
 	}
 
 	set(converted)
@@ -857,14 +1227,28 @@ func (d *Decoder) decodeSlice(v reflect.Value, typeOpts TypeOptions) error {
 	case 0:
 	case Len2Bytes:
 		if length > 0xFFFF {
-			return d.handleErrorf("array size exceeds 2 bytes: %v", length)
+			return d.kindErrorf(ErrOverflow, "array size exceeds 2 bytes: %v", length)
 		}
 	case Len4Bytes:
-		if length > 0xFFFFFFFF {
-			return d.handleErrorf("array size exceeds 4 bytes: %v", length)
+		if uint64(length) > 0xFFFFFFFF {
+			return d.kindErrorf(ErrOverflow, "array size exceeds 4 bytes: %v", length)
 		}
 	default:
-		return d.handleErrorf("invalid array size type: %v", typeOpts.LenSizeInBytes)
+		return d.kindErrorf(ErrInvalidOptions, "invalid array size type: %v", typeOpts.LenSizeInBytes)
+	}
+
+	elemType := v.Type().Elem()
+
+	if elemType.Kind() == reflect.Uint8 {
+		if err := d.checkByteLen(length); err != nil {
+			return err
+		}
+	} else if err := d.checkCollectionLen(length); err != nil {
+		return err
+	}
+
+	if err := d.chargeAlloc(length, int(elemType.Size())); err != nil {
+		return err
 	}
 
 	if length == 0 {
@@ -897,26 +1281,32 @@ func (d *Decoder) decodeArray(v reflect.Value, n int, typeOpts TypeOptions) erro
 		if elemType.Kind() == reflect.Uint8 && (isSlice || v.CanAddr()) && !typeOpts.ArrayElement.AsByteArray {
 			// Optimization for []byte and [N]byte.
 			if isSlice {
-				b, _ := d.ReadN(n)
-				v.Set(reflect.ValueOf(b))
+				b, _ := d.readN(n)
+				v.SetBytes(b)
 			} else {
 				_, _ = d.Read(v.Bytes())
 			}
 
 			return nil
 		}
+	}
 
-		// There could be other optimizations for encoding of basic types. But I removed them for now for simplicity.
+	if elemSize, ok := bulkElemSize(elemType, &tInfo, typeOpts.ArrayElement); ok && (isSlice || v.CanAddr()) {
+		// Optimization for arrays of integers and bools.
+		return d.decodeArrayBulk(v, n, elemSize)
 	}
 
+	d.path.pushIdx(0)
+
 	if typeOpts.ArrayElement.AsByteArray {
 		// Elements were encoded as byte arrays.
 		for i := 0; i < n; i++ {
+			d.path.setIdx(i)
 			err := d.decodeAsByteArray(func() error {
 				if isSlice {
 					v.Set(reflect.Append(v, reflect.New(elemType).Elem()))
 				}
-				return d.decodeValue(v.Index(i).Addr(), nil, &tInfo)
+				return d.decodeValue(v.Index(i).Addr(), &typeOpts.ArrayElement.TypeOptions, &tInfo, nil)
 			})
 			if err != nil {
 				return d.handleErrorf("[%v]: %w", i, err)
@@ -924,15 +1314,18 @@ func (d *Decoder) decodeArray(v reflect.Value, n int, typeOpts TypeOptions) erro
 		}
 	} else {
 		for i := 0; i < n; i++ {
+			d.path.setIdx(i)
 			if isSlice {
 				v.Set(reflect.Append(v, reflect.New(elemType).Elem()))
 			}
-			if err := d.decodeValue(v.Index(i).Addr(), nil, &tInfo); err != nil {
+			if err := d.decodeValue(v.Index(i).Addr(), &typeOpts.ArrayElement.TypeOptions, &tInfo, nil); err != nil {
 				return d.handleErrorf("[%v]: %w", i, err)
 			}
 		}
 	}
 
+	d.path.pop()
+
 	return nil
 }
 
@@ -943,19 +1336,26 @@ func (d *Decoder) decodeMap(v reflect.Value, typeOpts TypeOptions) error {
 	case 0:
 	case Len2Bytes:
 		if length > 0xFFFF {
-			return d.handleErrorf("map size exceeds 2 bytes: %v", length)
+			return d.kindErrorf(ErrOverflow, "map size exceeds 2 bytes: %v", length)
 		}
 	case Len4Bytes:
-		if length > 0xFFFFFFFF {
-			return d.handleErrorf("map size exceeds 4 bytes: %v", length)
+		if uint64(length) > 0xFFFFFFFF {
+			return d.kindErrorf(ErrOverflow, "map size exceeds 4 bytes: %v", length)
 		}
 	default:
-		return d.handleErrorf("invalid map size type: %v", typeOpts.LenSizeInBytes)
+		return d.kindErrorf(ErrInvalidOptions, "invalid map size type: %v", typeOpts.LenSizeInBytes)
 	}
 
 	keyType := v.Type().Key()
 	valueType := v.Type().Elem()
 
+	if err := d.checkCollectionLen(length); err != nil {
+		return err
+	}
+	if err := d.chargeAlloc(length, int(keyType.Size()+valueType.Size())); err != nil {
+		return err
+	}
+
 	keyTypeInfo, err := d.getEncodedTypeInfo(keyType)
 	if err != nil {
 		return d.handleErrorf("key: %w", err)
@@ -966,102 +1366,149 @@ func (d *Decoder) decodeMap(v reflect.Value, typeOpts TypeOptions) error {
 		return d.handleErrorf("value: %w", err)
 	}
 
+	d.path.pushIdx(0)
+	d.path.pushField("")
+
+	var prevEncodedKey []byte
+
 	for i := 0; i < length; i++ {
 		key := reflect.New(keyType).Elem()
 		value := reflect.New(valueType).Elem()
 
-		if err := d.decodeValue(key, typeOpts.MapKey, &keyTypeInfo); err != nil {
+		d.path[len(d.path)-2].idx = i
+		d.path.setField("mapKey")
+
+		if d.cfg.Strict {
+			// Capturing encoded bytes of key to check, that entries are sorted and unique.
+			encodedKey, err := d.captureBytes(func() error {
+				return d.decodeValue(key, typeOpts.MapKey, &keyTypeInfo, nil)
+			})
+			if err != nil {
+				return d.handleErrorf("key: %w", err)
+			}
+
+			if i > 0 {
+				if err := d.checkMapKeysOrder(prevEncodedKey, encodedKey); err != nil {
+					return err
+				}
+			}
+
+			prevEncodedKey = encodedKey
+		} else if err := d.decodeValue(key, typeOpts.MapKey, &keyTypeInfo, nil); err != nil {
 			return d.handleErrorf("key: %w", err)
 		}
 
-		if err := d.decodeValue(value, typeOpts.MapValue, &valueTypeInfo); err != nil {
+		d.path.setField("mapValue")
+
+		if err := d.decodeValue(value, typeOpts.MapValue, &valueTypeInfo, nil); err != nil {
 			return d.handleErrorf("value: %w", err)
 		}
 
 		v.SetMapIndex(key, value)
 	}
 
+	d.path.pop()
+	d.path.pop()
+
 	return nil
 }
 
 func (d *Decoder) decodeStruct(v reflect.Value, tInfo *typeInfo) error {
-	t := v.Type()
+	// Fast paths skip decodeValue, so they are only used while depth limit of fields is not reached.
+	limit := d.cfg.Limits.MaxDepth
+	canUseFastPath := limit == 0 || d.depth < limit
 
-	for i := 0; i < v.NumField(); i++ {
-		fieldType := t.Field(i)
-		fieldOpts, hasTag := tInfo.FieldOptions[i], tInfo.FieldHasTag[i]
+	for i := range tInfo.StructPlan {
+		f := &tInfo.StructPlan[i]
 
-		if fieldOpts.Skip {
-			continue
+		if f.Err != "" {
+			return d.kindErrorf(ErrInvalidOptions, "%v", f.Err)
 		}
 
-		fieldVal := v.Field(i)
+		// The field could be unexported, but marked for export, so it is accessed by offset.
+		fieldVal := f.value(v)
+
+		d.path.pushField(f.Name)
 
-		if !fieldType.IsExported() {
-			if !fieldOpts.ExportAnonymousField {
-				if hasTag {
-					return d.handleErrorf("%v: unexported field %v has tag, but is not marked for export", t.Name(), fieldType.Name)
+		if f.Decode != nil && canUseFastPath {
+			f.Decode(d, fieldVal)
+			if d.err != nil {
+				if d.failure != nil && d.failure.Type == nil {
+					d.failure.Type = f.Type
 				}
 
-				// Unexported fields are skipped by default if not explicitly marked as exported
-				continue
+				return d.handleErrorf("%v: %v: %w", f.Name, f.Type, d.err)
 			}
 
-			// The field is unexported, but it has a tag, so we need to serialize it.
-			fieldVal = reflect.NewAt(fieldVal.Type(), unsafe.Pointer(fieldVal.UnsafeAddr())).Elem()
-		} else if fieldOpts.ExportAnonymousField {
-			return d.handleErrorf("%v: field %v is already exported, but is marked for export", t.Name(), fieldType.Name)
+			d.path.pop()
+			continue
 		}
 
-		fieldKind := fieldVal.Kind()
-
-		if fieldKind == reflect.Ptr || fieldKind == reflect.Interface || fieldKind == reflect.Map || fieldKind == reflect.Slice {
-			if fieldOpts.Optional {
-				hasValue := d.ReadOptionalFlag()
-				if d.err != nil {
-					return d.handleErrorf("%v: %w", fieldType.Name, d.err)
-				}
+		if f.IsNullable && f.Opts.Optional {
+			hasValue := d.ReadOptionalFlag()
+			if d.err != nil {
+				return d.handleErrorf("%v: %w", f.Name, d.err)
+			}
 
-				if !hasValue {
-					// TODO: should we "clean" the field?
-					// I'm not doing it to allow presetting it and keeping even if it was missing.
-					continue
-				}
+			if !hasValue {
+				// TODO: should we "clean" the field?
+				// I'm not doing it to allow presetting it and keeping even if it was missing.
+				d.path.pop()
+				continue
 			}
 		}
 
 		var err error
 
-		if fieldOpts.AsByteArray {
+		if f.Opts.AsByteArray {
 			err = d.decodeAsByteArray(func() error {
-				return d.decodeValue(fieldVal, &fieldOpts.TypeOptions, nil)
+				return d.decodeValue(fieldVal, &f.Opts.TypeOptions, f.Info, f.TypeOpts)
 			})
 		} else {
-			err = d.decodeValue(fieldVal, &fieldOpts.TypeOptions, nil)
+			err = d.decodeValue(fieldVal, &f.Opts.TypeOptions, f.Info, f.TypeOpts)
 		}
 
 		if err != nil {
-			return d.handleErrorf("%v: %w", fieldType.Name, err)
+			return d.handleErrorf("%v: %w", f.Name, err)
 		}
+
+		d.path.pop()
 	}
 
 	return nil
 }
 
+// Returns info of type of struct field for plan of struct (see Encoder.fieldTypeInfo).
+func (d *Decoder) fieldTypeInfo(t reflect.Type) *typeInfo {
+	if isCompilingStruct(d.compilingStructs, t) {
+		return nil
+	}
+
+	prevErr, prevFailure := d.err, d.failure
+
+	tInfo, err := d.getEncodedTypeInfo(t)
+	if err != nil {
+		d.err, d.failure = prevErr, prevFailure
+		return nil
+	}
+
+	return &tInfo
+}
+
 func (d *Decoder) decodeInterface(v reflect.Value, couldBeEnum bool) error {
 	if couldBeEnum {
-		variants, registered := EnumTypes[v.Type()]
+		variants, registered := d.cfg.Registry.EnumVariants(v.Type())
 
 		if registered {
 			return d.decodeInterfaceEnum(v, variants)
 		}
 		if d.cfg.InterfaceIsEnumByDefault {
-			return d.handleErrorf("interface type %v is not registered as enum", v.Type())
+			return d.kindErrorf(ErrUnsupportedType, "interface type %v is not registered as enum", v.Type())
 		}
 	}
 
 	if v.IsNil() {
-		return d.handleErrorf("cannot decode interface which is not enum and has nil value")
+		return d.kindErrorf(ErrUnsupportedType, "cannot decode interface which is not enum and has nil value")
 	}
 
 	e := v.Elem()
@@ -1072,7 +1519,7 @@ func (d *Decoder) decodeInterface(v reflect.Value, couldBeEnum bool) error {
 			e = v.Elem()
 		}
 
-		return d.decodeValue(e, nil, nil)
+		return d.decodeValue(e, nil, nil, nil)
 	}
 
 	// Interface is not nil and contains non-pointer value.
@@ -1082,7 +1529,7 @@ func (d *Decoder) decodeInterface(v reflect.Value, couldBeEnum bool) error {
 	eCopy.Set(e)
 	e = eCopy
 
-	if err := d.decodeValue(e, nil, nil); err != nil {
+	if err := d.decodeValue(e, nil, nil, nil); err != nil {
 		return err
 	}
 
@@ -1097,18 +1544,18 @@ func (d *Decoder) decodeInterfaceEnum(v reflect.Value, variants map[int]reflect.
 		return d.err
 	}
 
-	if variantIdx >= len(variants) {
-		return d.handleErrorf("invalid variant index %v for enum %v - enum has only %v variants", variantIdx, v.Type(), len(variants))
+	variantT, known := variants[variantIdx]
+	if !known {
+		return d.decodeUnknownEnumVariant(v, variantIdx)
 	}
 
-	variantT := variants[variantIdx]
 	if variantT == noneT {
 		return nil
 	}
 
 	variant := reflect.New(variantT).Elem()
 
-	if err := d.decodeValue(variant, nil, nil); err != nil {
+	if err := d.decodeValue(variant, nil, nil, nil); err != nil {
 		return d.handleErrorf("%v: %w", variants[variantIdx], err)
 	}
 
@@ -1117,16 +1564,139 @@ func (d *Decoder) decodeInterfaceEnum(v reflect.Value, variants map[int]reflect.
 	return nil
 }
 
-func (d *Decoder) decodeStructEnum(v reflect.Value) error {
+func (d *Decoder) decodeUnknownEnumVariant(v reflect.Value, variantIdx EnumVariantID) error {
+	unknownVariantT := d.cfg.Registry.enumUnknownVariant(v.Type())
+	if unknownVariantT == nil {
+		return d.kindErrorf(ErrInvalidEnumVariant, "invalid variant index %v for enum %v", variantIdx, v.Type())
+	}
+
+	payload, err := d.readRest()
+	if err != nil {
+		return d.handleErrorf("%v: %w", unknownVariantT, err)
+	}
+
+	d.restTakenBy = d.path.String()
+
+	variant := reflect.ValueOf(UnknownEnumVariant{ID: variantIdx, Payload: payload}).Convert(unknownVariantT)
+	v.Set(variant)
+
+	return nil
+}
+
+func (d *Decoder) decodeIntEnum(v reflect.Value, names map[EnumVariantID]string) error {
+	id := d.ReadEnumIdx()
+	if d.err != nil {
+		return d.err
+	}
+
+	if _, ok := names[id]; !ok {
+		return d.kindErrorf(ErrInvalidEnumVariant, "invalid variant index %v for enum %v, expected one of: %v", id, v.Type(), intEnumVariantsString(names))
+	}
+
+	if v.CanInt() {
+		v.SetInt(int64(id))
+	} else {
+		v.SetUint(uint64(id))
+	}
+
+	return nil
+}
+
+func (d *Decoder) decodeStructEnum(v reflect.Value, tInfo *typeInfo) error {
 	variantIdx := d.ReadEnumIdx()
+	if d.err != nil {
+		return d.err
+	}
 
 	t := v.Type()
 
-	if t.NumField() <= variantIdx {
-		return d.handleErrorf("invalid variant index %v for enum %v - enum has only %v variants", variantIdx, t, t.NumField())
+	fieldIdx := lo.IndexOf(tInfo.EnumVariantIDs, variantIdx)
+	if fieldIdx == -1 {
+		return d.kindErrorf(ErrInvalidEnumVariant, "invalid variant index %v for enum %v", variantIdx, t)
+	}
+
+	d.path.pushField(t.Field(fieldIdx).Name)
+
+	// Options from tag of variant field are applied same way as for fields of regular structs.
+	fieldVal, fieldOpts := v.Field(fieldIdx), &tInfo.FieldOptions[fieldIdx]
+
+	var err error
+
+	if fieldOpts.AsByteArray {
+		err = d.decodeAsByteArray(func() error {
+			return d.decodeValue(fieldVal, &fieldOpts.TypeOptions, nil, nil)
+		})
+	} else {
+		err = d.decodeValue(fieldVal, &fieldOpts.TypeOptions, nil, nil)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	d.path.pop()
+
+	return nil
+}
+
+// DecodeMapKey decodes key of map entry with given index using dec().
+// In strict mode it also checks that keys are sorted and unique. For that it returns
+// encoded bytes of the key, which must be passed as prevKey when decoding next entry.
+func (d *Decoder) DecodeMapKey(entryIdx int, prevKey []byte, dec func() error) ([]byte, error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	if !d.cfg.Strict {
+		return nil, dec()
+	}
+
+	key, err := d.captureBytes(dec)
+	if err != nil {
+		return nil, err
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	if entryIdx > 0 {
+		if err := d.checkMapKeysOrder(prevKey, key); err != nil {
+			return nil, err
+		}
+	}
+
+	return key, nil
+}
+
+// DecodeNested decodes nested value using dec() and accounts it in nesting depth of decoded values.
+// Custom decoders could use it to make values, which they decode directly, subject to MaxDepth limit.
+func (d *Decoder) DecodeNested(dec func() error) error {
+	if d.err != nil {
+		return d.err
+	}
+
+	if limit := d.cfg.Limits.MaxDepth; limit > 0 && d.depth >= limit {
+		return d.limitErrorf("MaxDepth", d.depth+1, limit)
+	}
+
+	d.depth++
+	defer func() { d.depth-- }()
+
+	if err := dec(); err != nil {
+		return err
+	}
+
+	return d.err
+}
+
+// DecodeAsByteArray decodes value written as array of bytes using dec().
+// This is how values with "bytearr" option are decoded.
+func (d *Decoder) DecodeAsByteArray(dec func() error) error {
+	if d.err != nil {
+		return d.err
 	}
 
-	return d.decodeValue(v.Field(variantIdx), nil, nil)
+	return d.decodeAsByteArray(dec)
 }
 
 func (d *Decoder) decodeAsByteArray(dec func() error) error {
@@ -1135,16 +1705,24 @@ func (d *Decoder) decodeAsByteArray(dec func() error) error {
 	// skip length and continue reading. But that may result in confusing decoding errors in case of corrupted data.
 	// So more reliable way is to separate those bytes and decode from them.
 
-	b, _ := d.ReadN(d.ReadLen())
+	var b []byte
+	length := d.ReadLen()
+	if d.err == nil && d.checkByteLen(length) == nil && d.chargeAlloc(length, 1) == nil {
+		b, _ = d.readN(length)
+	}
 	if d.err != nil {
 		return d.handleErrorf("bytearr: %w", d.err)
 	}
 
-	origStream := d.r
-	defer func() { d.r = origStream }() // for case of panic/error
+	origStream, origInput, origInputReader, endOffset := d.r, d.input, d.inputReader, d.offset
+	// Restoring also in case of panic/error. Unknown enum variant could take only the rest of the array,
+	// but not of the original stream.
+	defer func() { d.r, d.input, d.inputReader, d.restTakenBy = origStream, origInput, origInputReader, "" }()
 
-	buff := bytes.NewBuffer(b)
-	d.r = buff
+	buff := bytes.NewReader(b)
+	d.r, d.input, d.inputReader = buff, b, buff
+	// Offsets of the bytes are the same as in the original stream.
+	d.offset -= int64(len(b))
 
 	if err := dec(); err != nil {
 		return err
@@ -1154,20 +1732,49 @@ func (d *Decoder) decodeAsByteArray(dec func() error) error {
 	}
 
 	if avail := buff.Len(); avail > 0 {
-		return d.handleErrorf("bytearr: excess bytes: %v", avail)
+		return d.kindErrorf(ErrExcessBytes, "bytearr: excess bytes: %v", avail)
 	}
 
+	d.offset = endOffset
+
 	return nil
 }
 
+// KindErrorf sets error of decoder and returns it. If this is the first failure of decoder, its kind and
+// location are recorded same way as for failures detected by decoder itself, so they are reported by Err().
+// If kind is nil, it is detected from the error, e.g. wrapped using %w.
+// This is how custom (e.g. generated) decoders report failures like ErrOverflow.
+func (d *Decoder) KindErrorf(kind error, format string, args ...interface{}) error {
+	return d.kindErrorf(kind, format, args...)
+}
+
 func (d *Decoder) handleErrorf(format string, args ...interface{}) error {
-	d.err = fmt.Errorf(format, args...)
-	return d.err
+	return d.setErr(nil, fmt.Errorf(format, args...))
+}
+
+// Same as handleErrorf, but also sets kind of failure if this is the first failure of decoder.
+func (d *Decoder) kindErrorf(kind error, format string, args ...interface{}) error {
+	return d.setErr(kind, fmt.Errorf(format, args...))
+}
+
+// Sets error of decoder. Location of the first failure is recorded to be reported by Err().
+// If kind is nil, it is detected from the error.
+func (d *Decoder) setErr(kind, err error) error {
+	if d.failure == nil {
+		if kind == nil {
+			kind = errorKind(err)
+		}
+
+		d.failure = &DecodeError{Kind: kind, Path: d.path.String(), Offset: d.offset}
+	}
+
+	d.err = err
+
+	return err
 }
 
 var (
-	decodableT                 = reflect.TypeOf((*Decodable)(nil)).Elem()
-	readableT                  = reflect.TypeOf((*Readable)(nil)).Elem()
-	initializeableT            = reflect.TypeOf((*Initializeable)(nil)).Elem()
-	decoderGlobalTypeInfoCache = newSharedTypeInfoCache()
+	decodableT      = reflect.TypeOf((*Decodable)(nil)).Elem()
+	readableT       = reflect.TypeOf((*Readable)(nil)).Elem()
+	initializeableT = reflect.TypeOf((*Initializeable)(nil)).Elem()
 )
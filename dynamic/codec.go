@@ -0,0 +1,487 @@
+package dynamic
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"unsafe"
+
+	"github.com/iotaledger/bcs-go"
+)
+
+// MaxContainerDepth limits nesting of structs and enums, same as reference BCS implementation does.
+// This protects from stack overflow when decoding values of recursive types.
+const MaxContainerDepth = 500
+
+func Unmarshal(b []byte, s *Schema) (Value, error) {
+	r := bytes.NewReader(b)
+
+	v, err := Decode(bcs.NewDecoder(r), s)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.Len() > 0 {
+		return nil, fmt.Errorf("%w: %v", bcs.ErrExcessBytes, r.Len())
+	}
+
+	return v, nil
+}
+
+func Marshal(s *Schema, v Value) ([]byte, error) {
+	e := bcs.NewBytesEncoder()
+	if err := Encode(&e.Encoder, s, v); err != nil {
+		return nil, err
+	}
+
+	return e.Bytes(), nil
+}
+
+// Decode reads value described by schema s from decoder d.
+// Limits and strict mode of the decoder are applied.
+// Failure is recorded in the decoder, so it is also reported by d.Err().
+func Decode(d *bcs.Decoder, s *Schema) (Value, error) {
+	v, err := decodeValue(d, s, 0)
+	if err != nil {
+		_ = d.KindErrorf(nil, "%w", err)
+		return nil, d.Err()
+	}
+
+	return v, nil
+}
+
+// DecodeRaw reads value described by schema s from decoder d and returns its encoded bytes.
+// This allows to find bounds of a value of a type, which is not known at compile time, e.g. to keep it as bcs.Raw.
+func DecodeRaw(d *bcs.Decoder, s *Schema) (bcs.Raw, error) {
+	return d.CaptureBytes(func() error {
+		_, err := Decode(d, s)
+		return err
+	})
+}
+
+// Encode writes value v described by schema s into encoder e.
+func Encode(e *bcs.Encoder, s *Schema, v Value) error {
+	return encodeValue(e, s, v, 0)
+}
+
+var (
+	valueSize    = int(unsafe.Sizeof(Value(nil)))
+	mapEntrySize = int(unsafe.Sizeof(MapEntry{}))
+)
+
+//nolint:gocyclo,funlen
+func decodeValue(d *bcs.Decoder, s *Schema, depth int) (Value, error) {
+	var v Value
+
+	switch s.Kind {
+	case KindUnit:
+		v = Unit{}
+	case KindBool:
+		v = Bool(d.ReadBool())
+	case KindU8:
+		v = U8(d.ReadUint8())
+	case KindU16:
+		v = U16(d.ReadUint16())
+	case KindU32:
+		v = U32(d.ReadUint32())
+	case KindU64:
+		v = U64(d.ReadUint64())
+	case KindI8:
+		v = I8(d.ReadInt8())
+	case KindI16:
+		v = I16(d.ReadInt16())
+	case KindI32:
+		v = I32(d.ReadInt32())
+	case KindI64:
+		v = I64(d.ReadInt64())
+	case KindU128:
+		var u U128
+		readLimbs(d, u[:])
+		v = u
+	case KindU256:
+		var u U256
+		readLimbs(d, u[:])
+		v = u
+	case KindI128:
+		var i I128
+		readLimbs(d, i[:])
+		v = i
+	case KindULEB128:
+		v = ULEB128(d.ReadCompactUint64())
+	case KindStr:
+		v = Str(d.ReadString())
+	case KindBytes:
+		v = Bytes(d.ReadBytes())
+	case KindSeq, KindArray:
+		n := s.Len
+		if s.Kind == KindSeq {
+			n = d.ReadCollectionLen(valueSize)
+		}
+		if d.Err() != nil {
+			return nil, d.Err()
+		}
+
+		elems := make(Seq, 0, min(n, 100))
+		for i := 0; i < n; i++ {
+			elem, err := decodeValue(d, s.Elem, depth)
+			if err != nil {
+				return nil, fmt.Errorf("[%v]: %w", i, err)
+			}
+			elems = append(elems, elem)
+		}
+		v = elems
+	case KindTuple:
+		elems := make(Tuple, len(s.Fields))
+		for i, f := range s.Fields {
+			elem, err := decodeValue(d, f.Schema, depth)
+			if err != nil {
+				return nil, fmt.Errorf("[%v]: %w", i, err)
+			}
+			elems[i] = elem
+		}
+		v = elems
+	case KindMap:
+		n := d.ReadCollectionLen(mapEntrySize)
+		if d.Err() != nil {
+			return nil, d.Err()
+		}
+
+		entries := make(Map, 0, min(n, 100))
+		var prevKey []byte
+		for i := 0; i < n; i++ {
+			var entry MapEntry
+			var err error
+
+			prevKey, err = d.DecodeMapKey(i, prevKey, func() error {
+				entry.Key, err = decodeValue(d, s.Key, depth)
+				return err
+			})
+			if err != nil {
+				return nil, fmt.Errorf("key [%v]: %w", i, err)
+			}
+
+			if entry.Value, err = decodeValue(d, s.Value, depth); err != nil {
+				return nil, fmt.Errorf("value [%v]: %w", i, err)
+			}
+
+			entries = append(entries, entry)
+		}
+		v = entries
+	case KindOption:
+		if !d.ReadOptionalFlag() {
+			v = Option{}
+			break
+		}
+
+		elem, err := decodeValue(d, s.Elem, depth)
+		if err != nil {
+			return nil, err
+		}
+		v = Option{Value: elem}
+	case KindStruct:
+		if depth >= MaxContainerDepth {
+			return nil, fmt.Errorf("%v: exceeded max container depth %v", s, MaxContainerDepth)
+		}
+
+		res := Struct{Name: s.Name, Fields: make([]StructField, len(s.Fields))}
+		for i, f := range s.Fields {
+			fieldVal, err := decodeValue(d, f.Schema, depth+1)
+			if err != nil {
+				return nil, fmt.Errorf("%v: %v: %w", s, f.Name, err)
+			}
+			res.Fields[i] = StructField{Name: f.Name, Value: fieldVal}
+		}
+		v = res
+	case KindEnum:
+		if depth >= MaxContainerDepth {
+			return nil, fmt.Errorf("%v: exceeded max container depth %v", s, MaxContainerDepth)
+		}
+
+		idx := d.ReadEnumIdx()
+		if d.Err() != nil {
+			return nil, fmt.Errorf("%v: %w", s, d.Err())
+		}
+
+		variant, ok := s.Variants[idx]
+		if !ok {
+			return nil, fmt.Errorf("%v: %w index %v", s, bcs.ErrInvalidEnumVariant, idx)
+		}
+
+		res := Enum{Name: s.Name, Index: idx, Variant: variant.Name}
+		if variant.Schema != nil {
+			variantVal, err := decodeValue(d, variant.Schema, depth+1)
+			if err != nil {
+				return nil, fmt.Errorf("%v: %v: %w", s, variant.Name, err)
+			}
+			res.Value = variantVal
+		}
+		v = res
+	default:
+		return nil, fmt.Errorf("cannot decode value of unknown kind %v", s.Kind)
+	}
+
+	if d.Err() != nil {
+		return nil, d.Err()
+	}
+
+	return v, nil
+}
+
+// Reads integer of multiple 64-bit limbs into its little-endian bytes.
+func readLimbs(d *bcs.Decoder, dest []byte) {
+	for i := 0; i < len(dest); i += 8 {
+		binary.LittleEndian.PutUint64(dest[i:], d.ReadUint64())
+	}
+}
+
+//nolint:gocyclo,funlen
+func encodeValue(e *bcs.Encoder, s *Schema, v Value, depth int) error {
+	switch s.Kind {
+	case KindUnit:
+		if _, ok := v.(Unit); !ok {
+			return unexpectedValueErr(s, v)
+		}
+	case KindBool:
+		b, ok := v.(Bool)
+		if !ok {
+			return unexpectedValueErr(s, v)
+		}
+		e.WriteBool(bool(b))
+	case KindU8:
+		i, ok := v.(U8)
+		if !ok {
+			return unexpectedValueErr(s, v)
+		}
+		e.WriteUint8(uint8(i))
+	case KindU16:
+		i, ok := v.(U16)
+		if !ok {
+			return unexpectedValueErr(s, v)
+		}
+		e.WriteUint16(uint16(i))
+	case KindU32:
+		i, ok := v.(U32)
+		if !ok {
+			return unexpectedValueErr(s, v)
+		}
+		e.WriteUint32(uint32(i))
+	case KindU64:
+		i, ok := v.(U64)
+		if !ok {
+			return unexpectedValueErr(s, v)
+		}
+		e.WriteUint64(uint64(i))
+	case KindI8:
+		i, ok := v.(I8)
+		if !ok {
+			return unexpectedValueErr(s, v)
+		}
+		e.WriteInt8(int8(i))
+	case KindI16:
+		i, ok := v.(I16)
+		if !ok {
+			return unexpectedValueErr(s, v)
+		}
+		e.WriteInt16(int16(i))
+	case KindI32:
+		i, ok := v.(I32)
+		if !ok {
+			return unexpectedValueErr(s, v)
+		}
+		e.WriteInt32(int32(i))
+	case KindI64:
+		i, ok := v.(I64)
+		if !ok {
+			return unexpectedValueErr(s, v)
+		}
+		e.WriteInt64(int64(i))
+	case KindU128:
+		i, ok := v.(U128)
+		if !ok {
+			return unexpectedValueErr(s, v)
+		}
+		_, _ = e.Write(i[:])
+	case KindU256:
+		i, ok := v.(U256)
+		if !ok {
+			return unexpectedValueErr(s, v)
+		}
+		_, _ = e.Write(i[:])
+	case KindI128:
+		i, ok := v.(I128)
+		if !ok {
+			return unexpectedValueErr(s, v)
+		}
+		_, _ = e.Write(i[:])
+	case KindULEB128:
+		i, ok := v.(ULEB128)
+		if !ok {
+			return unexpectedValueErr(s, v)
+		}
+		e.WriteCompactUint64(uint64(i))
+	case KindStr:
+		str, ok := v.(Str)
+		if !ok {
+			return unexpectedValueErr(s, v)
+		}
+		e.WriteString(string(str))
+	case KindBytes:
+		b, ok := v.(Bytes)
+		if !ok {
+			return unexpectedValueErr(s, v)
+		}
+		e.WriteBytes(b)
+	case KindSeq, KindArray:
+		elems, ok := v.(Seq)
+		if !ok {
+			return unexpectedValueErr(s, v)
+		}
+
+		if s.Kind == KindSeq {
+			e.WriteLen(len(elems))
+		} else if len(elems) != s.Len {
+			return fmt.Errorf("expected %v elements, got %v", s.Len, len(elems))
+		}
+
+		for i, elem := range elems {
+			if err := encodeValue(e, s.Elem, elem, depth); err != nil {
+				return fmt.Errorf("[%v]: %w", i, err)
+			}
+		}
+	case KindTuple:
+		elems, ok := v.(Tuple)
+		if !ok {
+			return unexpectedValueErr(s, v)
+		}
+		if len(elems) != len(s.Fields) {
+			return fmt.Errorf("expected %v tuple elements, got %v", len(s.Fields), len(elems))
+		}
+
+		for i, elem := range elems {
+			if err := encodeValue(e, s.Fields[i].Schema, elem, depth); err != nil {
+				return fmt.Errorf("[%v]: %w", i, err)
+			}
+		}
+	case KindMap:
+		entries, ok := v.(Map)
+		if !ok {
+			return unexpectedValueErr(s, v)
+		}
+
+		if err := encodeMap(e, s, entries, depth); err != nil {
+			return err
+		}
+	case KindOption:
+		opt, ok := v.(Option)
+		if !ok {
+			return unexpectedValueErr(s, v)
+		}
+
+		e.WriteOptionalFlag(opt.Value != nil)
+		if opt.Value != nil {
+			if err := encodeValue(e, s.Elem, opt.Value, depth); err != nil {
+				return err
+			}
+		}
+	case KindStruct:
+		if depth >= MaxContainerDepth {
+			return fmt.Errorf("%v: exceeded max container depth %v", s, MaxContainerDepth)
+		}
+
+		st, ok := v.(Struct)
+		if !ok {
+			return unexpectedValueErr(s, v)
+		}
+		if len(st.Fields) != len(s.Fields) {
+			return fmt.Errorf("%v: expected %v fields, got %v", s, len(s.Fields), len(st.Fields))
+		}
+
+		for i, f := range s.Fields {
+			if st.Fields[i].Name != "" && st.Fields[i].Name != f.Name {
+				return fmt.Errorf("%v: expected field %v, got %v", s, f.Name, st.Fields[i].Name)
+			}
+			if err := encodeValue(e, f.Schema, st.Fields[i].Value, depth+1); err != nil {
+				return fmt.Errorf("%v: %v: %w", s, f.Name, err)
+			}
+		}
+	case KindEnum:
+		if depth >= MaxContainerDepth {
+			return fmt.Errorf("%v: exceeded max container depth %v", s, MaxContainerDepth)
+		}
+
+		en, ok := v.(Enum)
+		if !ok {
+			return unexpectedValueErr(s, v)
+		}
+
+		variant, ok := s.Variants[en.Index]
+		if !ok {
+			return fmt.Errorf("%v: %w index %v", s, bcs.ErrInvalidEnumVariant, en.Index)
+		}
+		if en.Variant != "" && en.Variant != variant.Name {
+			return fmt.Errorf("%v: variant %v has index %v, got %v", s, variant.Name, en.Index, en.Variant)
+		}
+
+		e.WriteEnumIdx(en.Index)
+
+		switch {
+		case variant.Schema == nil && en.Value != nil:
+			return fmt.Errorf("%v: %v: unit variant has value %T", s, variant.Name, en.Value)
+		case variant.Schema != nil:
+			if err := encodeValue(e, variant.Schema, en.Value, depth+1); err != nil {
+				return fmt.Errorf("%v: %v: %w", s, variant.Name, err)
+			}
+		}
+	default:
+		return fmt.Errorf("cannot encode value of unknown kind %v", s.Kind)
+	}
+
+	return e.Err()
+}
+
+// Writes entries sorted by encoded bytes of their keys, same as reflection-based encoder does.
+// Keys are written one after another into single buffer, so slices of previous keys stay valid.
+func encodeMap(e *bcs.Encoder, s *Schema, entries Map, depth int) error {
+	type encodedEntry struct {
+		idx   int
+		key   []byte
+		value Value
+	}
+
+	keys := bcs.NewBytesEncoderWithOpts(e.Config())
+	sorted := make([]encodedEntry, len(entries))
+
+	for i, entry := range entries {
+		keyStart := len(keys.Bytes())
+		if err := encodeValue(&keys.Encoder, s.Key, entry.Key, depth); err != nil {
+			return fmt.Errorf("key [%v]: %w", i, err)
+		}
+
+		sorted[i] = encodedEntry{idx: i, key: keys.Bytes()[keyStart:], value: entry.Value}
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i].key, sorted[j].key) < 0
+	})
+
+	for i := 1; i < len(sorted); i++ {
+		if bytes.Equal(sorted[i-1].key, sorted[i].key) {
+			return fmt.Errorf("key [%v]: %w: duplicate map key %x", sorted[i].idx, bcs.ErrNonCanonical, sorted[i].key)
+		}
+	}
+
+	e.WriteLen(len(sorted))
+	for _, entry := range sorted {
+		_, _ = e.Write(entry.key)
+		if err := encodeValue(e, s.Value, entry.value, depth); err != nil {
+			return fmt.Errorf("value [%v]: %w", entry.idx, err)
+		}
+	}
+
+	return nil
+}
+
+func unexpectedValueErr(s *Schema, v Value) error {
+	return fmt.Errorf("expected value of kind %v, got %T", s, v)
+}
@@ -0,0 +1,236 @@
+package dynamic_test
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/exp/maps"
+
+	"github.com/iotaledger/bcs-go"
+	"github.com/iotaledger/bcs-go/dynamic"
+)
+
+type Shape interface{}
+
+type Circle struct {
+	Radius uint32
+}
+
+type Point struct {
+	X, Y int16
+}
+
+type Drawing struct {
+	Title   string
+	Data    []byte
+	Shapes  []Shape
+	Origin  [2]Point
+	Labels  map[string]uint64
+	Parent  *Drawing `bcs:"optional"`
+	Balance big.Int
+	Flag    bool
+}
+
+func TestDecodeFromRegistry(t *testing.T) {
+	t.Cleanup(func() { maps.Clear(bcs.EnumTypes) })
+
+	bcs.RegisterEnumType3[Shape, bcs.None, Circle, Point]()
+
+	registry, err := bcs.ReflectSchema[Drawing]()
+	require.NoError(t, err)
+
+	schema, err := dynamic.FromRegistry(registry, "Drawing")
+	require.NoError(t, err)
+
+	drawing := Drawing{
+		Title:  "test",
+		Data:   []byte{1, 2, 3},
+		Shapes: []Shape{nil, Circle{Radius: 5}, Point{X: -1, Y: 2}},
+		Origin: [2]Point{{X: 1, Y: 2}, {X: 3, Y: 4}},
+		Labels: map[string]uint64{"b": 2, "a": 1},
+		Parent: &Drawing{Title: "parent", Labels: map[string]uint64{}, Balance: *big.NewInt(7)},
+		Flag:   true,
+	}
+	drawing.Balance.SetString("1000000000000000000000", 10)
+
+	encoded := bcs.MustMarshal(&drawing)
+
+	v, err := dynamic.Unmarshal(encoded, schema)
+	require.NoError(t, err)
+
+	balance, _ := new(big.Int).SetString("1000000000000000000000", 10)
+	expectedBalance, err := dynamic.U128FromBigInt(balance)
+	require.NoError(t, err)
+
+	d := v.(dynamic.Struct)
+	require.Equal(t, "Drawing", d.Name)
+	require.Equal(t, dynamic.Str("test"), d.Field("Title"))
+	require.Equal(t, dynamic.Bytes{1, 2, 3}, d.Field("Data"))
+	require.Equal(t, dynamic.Seq{
+		dynamic.Enum{Name: "Shape", Index: 0, Variant: "None"},
+		dynamic.Enum{Name: "Shape", Index: 1, Variant: "Circle", Value: dynamic.Struct{
+			Name: "Circle", Fields: []dynamic.StructField{{Name: "Radius", Value: dynamic.U32(5)}},
+		}},
+		dynamic.Enum{Name: "Shape", Index: 2, Variant: "Point", Value: dynamic.Struct{
+			Name: "Point", Fields: []dynamic.StructField{{Name: "X", Value: dynamic.I16(-1)}, {Name: "Y", Value: dynamic.I16(2)}},
+		}},
+	}, d.Field("Shapes"))
+	require.Len(t, d.Field("Origin"), 2)
+	require.Equal(t, dynamic.Map{
+		{Key: dynamic.Str("a"), Value: dynamic.U64(1)},
+		{Key: dynamic.Str("b"), Value: dynamic.U64(2)},
+	}, d.Field("Labels"))
+	require.Equal(t, expectedBalance, d.Field("Balance"))
+	require.Equal(t, "1000000000000000000000", d.Field("Balance").(dynamic.U128).String())
+	require.Equal(t, dynamic.Bool(true), d.Field("Flag"))
+
+	parent := d.Field("Parent").(dynamic.Option).Value.(dynamic.Struct)
+	require.Equal(t, dynamic.Str("parent"), parent.Field("Title"))
+	require.Equal(t, dynamic.Option{}, parent.Field("Parent"))
+	require.Equal(t, dynamic.NewU128(0, 7), parent.Field("Balance"))
+
+	reencoded, err := dynamic.Marshal(schema, v)
+	require.NoError(t, err)
+	require.Equal(t, encoded, reencoded)
+}
+
+func TestManualSchema(t *testing.T) {
+	schema := &dynamic.Schema{Kind: dynamic.KindTuple, Fields: []dynamic.Field{
+		{Schema: &dynamic.Schema{Kind: dynamic.KindULEB128}},
+		{Schema: &dynamic.Schema{Kind: dynamic.KindU256}},
+		{Schema: &dynamic.Schema{Kind: dynamic.KindI128}},
+		{Schema: &dynamic.Schema{Kind: dynamic.KindUnit}},
+		{Schema: &dynamic.Schema{Kind: dynamic.KindEnum, Name: "Sparse", Variants: map[int]dynamic.Variant{
+			0: {Name: "A"},
+			7: {Name: "B", Schema: &dynamic.Schema{Kind: dynamic.KindU8}},
+		}}},
+	}}
+
+	u256, err := dynamic.U256FromBigInt(new(big.Int).Lsh(big.NewInt(1), 255))
+	require.NoError(t, err)
+	i128, err := dynamic.I128FromBigInt(big.NewInt(-2))
+	require.NoError(t, err)
+
+	v := dynamic.Tuple{dynamic.ULEB128(300), u256, i128, dynamic.Unit{}, dynamic.Enum{Index: 7, Value: dynamic.U8(9)}}
+
+	encoded, err := dynamic.Marshal(schema, v)
+	require.NoError(t, err)
+
+	expected := []byte{0xac, 0x02}
+	expected = append(expected, make([]byte, 31)...)
+	expected = append(expected, 0x80)
+	expected = append(expected, 0xfe)
+	expected = append(expected, bytes.Repeat([]byte{0xff}, 15)...)
+	expected = append(expected, 7, 9)
+	require.Equal(t, expected, encoded)
+
+	decoded, err := dynamic.Unmarshal(encoded, schema)
+	require.NoError(t, err)
+	require.Equal(t, "-2", decoded.(dynamic.Tuple)[2].(dynamic.I128).String())
+	require.Equal(t, new(big.Int).Lsh(big.NewInt(1), 255), decoded.(dynamic.Tuple)[1].(dynamic.U256).BigInt())
+	require.Equal(t, dynamic.Enum{Name: "Sparse", Index: 7, Variant: "B", Value: dynamic.U8(9)}, decoded.(dynamic.Tuple)[4])
+
+	_, err = dynamic.I128FromBigInt(new(big.Int).Lsh(big.NewInt(1), 127))
+	require.Error(t, err)
+	_, err = dynamic.U128FromBigInt(big.NewInt(-1))
+	require.Error(t, err)
+}
+
+func TestDecodeErrors(t *testing.T) {
+	enum := &dynamic.Schema{Kind: dynamic.KindEnum, Name: "E", Variants: map[int]dynamic.Variant{0: {Name: "A"}}}
+
+	_, err := dynamic.Unmarshal([]byte{1}, enum)
+	require.ErrorContains(t, err, "E: invalid enum variant index 1")
+	require.ErrorIs(t, err, bcs.ErrInvalidEnumVariant)
+
+	_, err = dynamic.Unmarshal([]byte{0, 0}, enum)
+	require.ErrorContains(t, err, "excess bytes: 1")
+	require.ErrorIs(t, err, bcs.ErrExcessBytes)
+
+	_, err = dynamic.Marshal(enum, dynamic.Enum{Index: 0, Value: dynamic.U8(1)})
+	require.ErrorContains(t, err, "E: A: unit variant has value dynamic.U8")
+
+	_, err = dynamic.Marshal(&dynamic.Schema{Kind: dynamic.KindU8}, dynamic.U16(1))
+	require.ErrorContains(t, err, "expected value of kind U8, got dynamic.U16")
+
+	// Infinitely nested value of recursive type
+	list := &dynamic.Schema{Kind: dynamic.KindStruct, Name: "List"}
+	list.Fields = []dynamic.Field{{Name: "Next", Schema: &dynamic.Schema{Kind: dynamic.KindOption, Elem: list}}}
+
+	_, err = dynamic.Unmarshal(bytes.Repeat([]byte{1}, dynamic.MaxContainerDepth+1), list)
+	require.ErrorContains(t, err, "exceeded max container depth 500")
+
+	// Failures of underlying decoder are reported with their offset
+	_, err = dynamic.Unmarshal([]byte{1, 1, 2}, list)
+	require.ErrorIs(t, err, bcs.ErrInvalidOptionalFlag)
+	var decodeErr *bcs.DecodeError
+	require.ErrorAs(t, err, &decodeErr)
+	require.Equal(t, int64(3), decodeErr.Offset)
+
+	// Unsorted map keys are rejected in strict mode
+	m := &dynamic.Schema{Kind: dynamic.KindMap, Key: &dynamic.Schema{Kind: dynamic.KindU8}, Value: &dynamic.Schema{Kind: dynamic.KindBool}}
+	unsorted := []byte{2, 2, 1, 1, 0}
+
+	v, err := dynamic.Unmarshal(unsorted, m)
+	require.NoError(t, err)
+
+	// Entries are sorted when encoding, same as by reflection-based encoder
+	reencoded, err := dynamic.Marshal(m, v)
+	require.NoError(t, err)
+	require.Equal(t, []byte{2, 1, 0, 2, 1}, reencoded)
+
+	_, err = dynamic.Decode(bcs.NewDecoderWithOpts(bytes.NewReader(unsorted), bcs.DecoderConfig{Strict: true}), m)
+	require.ErrorIs(t, err, bcs.ErrNonCanonical)
+
+	_, err = dynamic.Marshal(m, dynamic.Map{
+		{Key: dynamic.U8(1), Value: dynamic.Bool(true)},
+		{Key: dynamic.U8(1), Value: dynamic.Bool(false)},
+	})
+	require.ErrorIs(t, err, bcs.ErrNonCanonical)
+	require.ErrorContains(t, err, "duplicate map key 01")
+
+	// Failures are recorded in the decoder
+	d := bcs.NewDecoder(bytes.NewReader([]byte{0x9}))
+	_, err = dynamic.Decode(d, &dynamic.Schema{Kind: dynamic.KindEnum, Name: "E", Variants: map[int]dynamic.Variant{0: {Name: "A"}}})
+	require.ErrorIs(t, err, bcs.ErrInvalidEnumVariant)
+	require.ErrorIs(t, d.Err(), bcs.ErrInvalidEnumVariant)
+
+	d = bcs.NewDecoder(bytes.NewReader([]byte{0x2}))
+	_, err = dynamic.DecodeRaw(d, m)
+	require.ErrorIs(t, err, bcs.ErrUnexpectedEOF)
+	require.ErrorIs(t, d.Err(), bcs.ErrUnexpectedEOF)
+
+	// Decoder limits are applied
+	seq := &dynamic.Schema{Kind: dynamic.KindSeq, Elem: &dynamic.Schema{Kind: dynamic.KindU8}}
+	limits := bcs.DecoderConfig{Limits: bcs.DecoderLimits{MaxCollectionLen: 2}}
+
+	_, err = dynamic.Decode(bcs.NewDecoderWithOpts(bytes.NewReader([]byte{3, 1, 2, 3}), limits), seq)
+	require.ErrorIs(t, err, bcs.ErrLimitExceeded)
+}
+
+func TestDecodeRaw(t *testing.T) {
+	t.Cleanup(func() { maps.Clear(bcs.EnumTypes) })
+
+	bcs.RegisterEnumType3[Shape, bcs.None, Circle, Point]()
+
+	registry, err := bcs.ReflectSchema[Drawing]()
+	require.NoError(t, err)
+
+	schema, err := dynamic.FromRegistry(registry, "Drawing")
+	require.NoError(t, err)
+
+	drawing := Drawing{Title: "raw", Shapes: []Shape{Circle{Radius: 1}}, Labels: map[string]uint64{"a": 1}}
+	encoded := bcs.MustMarshal(&drawing)
+
+	// Value is followed by other data, which must not be captured
+	d := bcs.NewDecoder(bytes.NewReader(append(bytes.Clone(encoded), 1, 2, 3)))
+	raw, err := dynamic.DecodeRaw(d, schema)
+	require.NoError(t, err)
+	require.Equal(t, bcs.Raw(encoded), raw)
+	require.Equal(t, byte(1), d.ReadByte())
+
+	_, err = dynamic.DecodeRaw(bcs.NewDecoder(bytes.NewReader(encoded[:5])), schema)
+	require.ErrorIs(t, err, bcs.ErrUnexpectedEOF)
+}
@@ -0,0 +1,254 @@
+// Package dynamic decodes and encodes BCS values using only a description of their schema,
+// without Go types for them. This is useful for indexers, explorers and debugging tools.
+//
+// Decoded values are represented as a tree of Value. Encoding of decoded tree produces
+// exactly the same bytes, including the order of map entries.
+package dynamic
+
+import (
+	"fmt"
+
+	"github.com/iotaledger/bcs-go"
+)
+
+type Kind int
+
+const (
+	KindInvalid Kind = iota
+	KindUnit
+	KindBool
+	KindU8
+	KindU16
+	KindU32
+	KindU64
+	KindU128
+	KindU256
+	KindI8
+	KindI16
+	KindI32
+	KindI64
+	KindI128
+	// Unsigned integer encoded as ULEB128 (bcs "compact" option).
+	KindULEB128
+	KindStr
+	// Vector of bytes.
+	KindBytes
+	// Variable-length sequence of elements.
+	KindSeq
+	// Fixed-length sequence of elements.
+	KindArray
+	KindMap
+	KindOption
+	KindTuple
+	KindStruct
+	KindEnum
+)
+
+var kindNames = map[Kind]string{
+	KindUnit:    "UNIT",
+	KindBool:    "BOOL",
+	KindU8:      "U8",
+	KindU16:     "U16",
+	KindU32:     "U32",
+	KindU64:     "U64",
+	KindU128:    "U128",
+	KindU256:    "U256",
+	KindI8:      "I8",
+	KindI16:     "I16",
+	KindI32:     "I32",
+	KindI64:     "I64",
+	KindI128:    "I128",
+	KindULEB128: "ULEB128",
+	KindStr:     "STR",
+	KindBytes:   "BYTES",
+	KindSeq:     "SEQ",
+	KindArray:   "TUPLEARRAY",
+	KindMap:     "MAP",
+	KindOption:  "OPTION",
+	KindTuple:   "TUPLE",
+	KindStruct:  "STRUCT",
+	KindEnum:    "ENUM",
+}
+
+func (k Kind) String() string {
+	if name, ok := kindNames[k]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+// Schema describes encoding of a value. Schemas of recursive types may reference themselves.
+type Schema struct {
+	Kind Kind
+	// Name of STRUCT or ENUM.
+	Name string
+	// Element of SEQ, TUPLEARRAY and OPTION.
+	Elem *Schema
+	// Length of TUPLEARRAY.
+	Len int
+	// Key and value of MAP.
+	Key, Value *Schema
+	// Fields of STRUCT and elements of TUPLE. Names of TUPLE elements are ignored.
+	Fields []Field
+	// Variants of ENUM by their indices.
+	Variants map[int]Variant
+}
+
+type Field struct {
+	Name   string
+	Schema *Schema
+}
+
+// Variant of enum. Unit variant has nil Schema.
+type Variant struct {
+	Name   string
+	Schema *Schema
+}
+
+func (s *Schema) String() string {
+	if s.Name != "" {
+		return s.Name
+	}
+
+	return s.Kind.String()
+}
+
+// FromRegistry converts description of type with given name from the registry produced by bcs.ReflectSchema.
+// Sequences of U8 are converted into BYTES, which are encoded in the same way.
+func FromRegistry(registry bcs.SchemaRegistry, name string) (*Schema, error) {
+	c := registryConverter{registry: registry, containers: make(map[string]*Schema)}
+	return c.container(name)
+}
+
+// FromFormat converts format referencing containers of the registry produced by bcs.ReflectSchema.
+func FromFormat(registry bcs.SchemaRegistry, f bcs.Format) (*Schema, error) {
+	c := registryConverter{registry: registry, containers: make(map[string]*Schema)}
+	return c.format(f)
+}
+
+type registryConverter struct {
+	registry   bcs.SchemaRegistry
+	containers map[string]*Schema
+}
+
+var formatKinds = map[bcs.FormatKind]Kind{
+	bcs.FormatUnit:  KindUnit,
+	bcs.FormatBool:  KindBool,
+	bcs.FormatI8:    KindI8,
+	bcs.FormatI16:   KindI16,
+	bcs.FormatI32:   KindI32,
+	bcs.FormatI64:   KindI64,
+	bcs.FormatI128:  KindI128,
+	bcs.FormatU8:    KindU8,
+	bcs.FormatU16:   KindU16,
+	bcs.FormatU32:   KindU32,
+	bcs.FormatU64:   KindU64,
+	bcs.FormatU128:  KindU128,
+	bcs.FormatU256:  KindU256,
+	bcs.FormatStr:   KindStr,
+	bcs.FormatBytes: KindBytes,
+}
+
+func (c *registryConverter) format(f bcs.Format) (*Schema, error) {
+	switch f.Kind {
+	case bcs.FormatTypeName:
+		return c.container(f.Name)
+	case bcs.FormatOption:
+		elem, err := c.format(*f.Content)
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Kind: KindOption, Elem: elem}, nil
+	case bcs.FormatSeq:
+		if f.Content.Kind == bcs.FormatU8 {
+			return &Schema{Kind: KindBytes}, nil
+		}
+		elem, err := c.format(*f.Content)
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Kind: KindSeq, Elem: elem}, nil
+	case bcs.FormatTupleArray:
+		elem, err := c.format(*f.Content)
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Kind: KindArray, Elem: elem, Len: f.Size}, nil
+	case bcs.FormatMap:
+		key, err := c.format(*f.Key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := c.format(*f.Value)
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Kind: KindMap, Key: key, Value: value}, nil
+	case bcs.FormatTuple:
+		s := &Schema{Kind: KindTuple, Fields: make([]Field, len(f.Elems))}
+		for i := range f.Elems {
+			elem, err := c.format(f.Elems[i])
+			if err != nil {
+				return nil, err
+			}
+			s.Fields[i] = Field{Schema: elem}
+		}
+		return s, nil
+	}
+
+	kind, ok := formatKinds[f.Kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown format %v", f.Kind)
+	}
+
+	return &Schema{Kind: kind}, nil
+}
+
+func (c *registryConverter) container(name string) (*Schema, error) {
+	if s, ok := c.containers[name]; ok {
+		return s, nil
+	}
+
+	cf, ok := c.registry[name]
+	if !ok {
+		return nil, fmt.Errorf("type %v is not found in registry", name)
+	}
+
+	// Registering schema before converting its content to support recursive types.
+	s := &Schema{Name: name}
+	c.containers[name] = s
+
+	switch cf.Kind {
+	case bcs.ContainerUnitStruct:
+		s.Kind = KindStruct
+	case bcs.ContainerStruct:
+		s.Kind = KindStruct
+		s.Fields = make([]Field, len(cf.Fields))
+		for i, f := range cf.Fields {
+			fieldSchema, err := c.format(f.Format)
+			if err != nil {
+				return nil, fmt.Errorf("%v: %v: %w", name, f.Name, err)
+			}
+			s.Fields[i] = Field{Name: f.Name, Schema: fieldSchema}
+		}
+	case bcs.ContainerEnum:
+		s.Kind = KindEnum
+		s.Variants = make(map[int]Variant, len(cf.Variants))
+		for idx, v := range cf.Variants {
+			variant := Variant{Name: v.Name}
+			if v.Newtype != nil {
+				variantSchema, err := c.format(*v.Newtype)
+				if err != nil {
+					return nil, fmt.Errorf("%v: %v: %w", name, v.Name, err)
+				}
+				variant.Schema = variantSchema
+			}
+			s.Variants[idx] = variant
+		}
+	default:
+		return nil, fmt.Errorf("%v: unknown container kind %v", name, cf.Kind)
+	}
+
+	return s, nil
+}
@@ -0,0 +1,203 @@
+package dynamic
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+)
+
+var (
+	twoPow128 = new(big.Int).Lsh(big.NewInt(1), 128)
+	minI128   = new(big.Int).Neg(new(big.Int).Lsh(big.NewInt(1), 127))
+	maxI128   = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 127), big.NewInt(1))
+)
+
+// Value is a node of decoded value tree. It is one of the types declared in this package.
+type Value interface {
+	isValue()
+}
+
+type (
+	Unit    struct{}
+	Bool    bool
+	U8      uint8
+	U16     uint16
+	U32     uint32
+	U64     uint64
+	I8      int8
+	I16     int16
+	I32     int32
+	I64     int64
+	ULEB128 uint64
+	Str     string
+	Bytes   []byte
+
+	// Little-endian bytes of 128-bit unsigned integer.
+	U128 [16]byte
+	// Little-endian bytes of 256-bit unsigned integer.
+	U256 [32]byte
+	// Little-endian bytes of 128-bit signed integer in two's complement form.
+	I128 [16]byte
+
+	// Elements of SEQ or TUPLEARRAY.
+	Seq []Value
+	// Elements of TUPLE.
+	Tuple []Value
+	// Entries of MAP. Decoded entries are in the order they are encoded. When encoding, entries are sorted by
+	// encoded bytes of their keys and duplicate keys are rejected.
+	Map []MapEntry
+
+	// Option has nil Value if it is empty.
+	Option struct {
+		Value Value
+	}
+
+	Struct struct {
+		Name   string
+		Fields []StructField
+	}
+
+	// Enum has nil Value if variant is unit variant.
+	Enum struct {
+		Name    string
+		Index   int
+		Variant string
+		Value   Value
+	}
+)
+
+type MapEntry struct {
+	Key   Value
+	Value Value
+}
+
+type StructField struct {
+	Name  string
+	Value Value
+}
+
+func (Unit) isValue()    {}
+func (Bool) isValue()    {}
+func (U8) isValue()      {}
+func (U16) isValue()     {}
+func (U32) isValue()     {}
+func (U64) isValue()     {}
+func (U128) isValue()    {}
+func (U256) isValue()    {}
+func (I8) isValue()      {}
+func (I16) isValue()     {}
+func (I32) isValue()     {}
+func (I64) isValue()     {}
+func (I128) isValue()    {}
+func (ULEB128) isValue() {}
+func (Str) isValue()     {}
+func (Bytes) isValue()   {}
+func (Seq) isValue()     {}
+func (Tuple) isValue()   {}
+func (Map) isValue()     {}
+func (Option) isValue()  {}
+func (Struct) isValue()  {}
+func (Enum) isValue()    {}
+
+// Field returns value of field with given name or nil if there is no such field.
+func (s Struct) Field(name string) Value {
+	for _, f := range s.Fields {
+		if f.Name == name {
+			return f.Value
+		}
+	}
+
+	return nil
+}
+
+func (v U128) BigInt() *big.Int {
+	return leBytesToBigInt(v[:])
+}
+
+func (v U128) String() string {
+	return v.BigInt().String()
+}
+
+func (v U256) BigInt() *big.Int {
+	return leBytesToBigInt(v[:])
+}
+
+func (v U256) String() string {
+	return v.BigInt().String()
+}
+
+func (v I128) BigInt() *big.Int {
+	res := leBytesToBigInt(v[:])
+	if v[15]&0x80 != 0 {
+		res.Sub(res, twoPow128)
+	}
+
+	return res
+}
+
+func (v I128) String() string {
+	return v.BigInt().String()
+}
+
+func NewU128(hi, lo uint64) U128 {
+	var v U128
+	binary.LittleEndian.PutUint64(v[:8], lo)
+	binary.LittleEndian.PutUint64(v[8:], hi)
+
+	return v
+}
+
+func U128FromBigInt(i *big.Int) (U128, error) {
+	var v U128
+	if i.Sign() < 0 || i.BitLen() > 128 {
+		return v, fmt.Errorf("value %v is out of range of U128", i)
+	}
+
+	i.FillBytes(v[:])
+	reverse(v[:])
+
+	return v, nil
+}
+
+func U256FromBigInt(i *big.Int) (U256, error) {
+	var v U256
+	if i.Sign() < 0 || i.BitLen() > 256 {
+		return v, fmt.Errorf("value %v is out of range of U256", i)
+	}
+
+	i.FillBytes(v[:])
+	reverse(v[:])
+
+	return v, nil
+}
+
+func I128FromBigInt(i *big.Int) (I128, error) {
+	var v I128
+	if i.Cmp(minI128) < 0 || i.Cmp(maxI128) > 0 {
+		return v, fmt.Errorf("value %v is out of range of I128", i)
+	}
+
+	twosComplement := i
+	if i.Sign() < 0 {
+		twosComplement = new(big.Int).Add(i, twoPow128)
+	}
+
+	twosComplement.FillBytes(v[:])
+	reverse(v[:])
+
+	return v, nil
+}
+
+func leBytesToBigInt(le []byte) *big.Int {
+	be := make([]byte, len(le))
+	copy(be, le)
+	reverse(be)
+
+	return new(big.Int).SetBytes(be)
+}
+
+func reverse(b []byte) {
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+}
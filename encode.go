@@ -5,9 +5,11 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
+	"math"
+	"math/big"
 	"reflect"
 	"sort"
-	"unsafe"
+	"sync"
 
 	"github.com/samber/lo"
 
@@ -31,7 +33,7 @@ func MarshalStream[V any](v *V, dest io.Writer) error {
 		e.Encode(v)
 	}
 
-	return e.err
+	return e.Err()
 }
 
 func MustMarshalStream[V any](v *V, dest io.Writer) {
@@ -41,13 +43,44 @@ func MustMarshalStream[V any](v *V, dest io.Writer) {
 }
 
 func Marshal[V any](v *V) ([]byte, error) {
-	var buf bytes.Buffer
-
-	if err := MarshalStream(v, &buf); err != nil {
+	b, err := MarshalAppend(nil, v)
+	if err != nil {
 		return nil, err
 	}
 
-	return buf.Bytes(), nil
+	return b, nil
+}
+
+// MarshalAppend encodes v and appends its bytes to dst. It returns the extended slice.
+// If capacity of dst is sufficient, no memory is allocated for the result, which allows to reuse buffers in hot paths.
+// In case of error dst is returned unchanged, although bytes after its length could have been overwritten.
+// Same as MarshalStream, "*any" is treated as "any".
+func MarshalAppend[V any](dst []byte, v *V) ([]byte, error) {
+	e := GetBytesEncoder()
+
+	pooledDst := e.dst
+	e.dst = dst
+
+	// Result belongs to caller, so encoder must not reuse it. Buffer is restored even if encoding panics.
+	defer func() {
+		e.dst = pooledDst
+		PutBytesEncoder(e)
+	}()
+
+	switch v := interface{}(v).(type) {
+	case *interface{}:
+		e.Encode(*v)
+	default:
+		e.Encode(v)
+	}
+
+	res := e.dst
+
+	if e.err != nil {
+		return dst, e.Err()
+	}
+
+	return res, nil
 }
 
 func MustMarshal[V any](v *V) []byte {
@@ -69,6 +102,9 @@ type Writable interface {
 
 type CustomEncoder func(e *Encoder, v reflect.Value) error
 
+// CustomEncoders contains encoders registered in DefaultRegistry.
+//
+// Deprecated: direct modification is not thread-safe. Use AddCustomEncoder or Registry instead.
 var CustomEncoders = make(map[reflect.Type]CustomEncoder)
 
 func MakeCustomEncoder[V any](f func(e *Encoder, v V) error) func(e *Encoder, v reflect.Value) error {
@@ -78,76 +114,194 @@ func MakeCustomEncoder[V any](f func(e *Encoder, v V) error) func(e *Encoder, v
 }
 
 func AddCustomEncoder[V any](f func(e *Encoder, v V) error) struct{} {
-	t := reflect.TypeOf((*V)(nil)).Elem()
-	if CustomEncoders[t] != nil {
-		panic(fmt.Errorf("custom encoder for type %v is already registered", t))
-	}
-	CustomEncoders[t] = MakeCustomEncoder(f)
-	return struct{}{}
+	return AddCustomEncoderTo(DefaultRegistry, f)
 }
 
 func RemoveCustomEncoder[V any]() {
-	delete(CustomEncoders, reflect.TypeOf((*V)(nil)).Elem())
+	RemoveCustomEncoderFrom[V](DefaultRegistry)
 }
 
 type EncoderConfig struct {
 	TagName                  string
 	InterfaceIsEnumByDefault bool
+	// Registry of custom encoders and enums. DefaultRegistry is used if nil.
+	Registry *Registry
 	// IncludeUnexported bool
 	// IncludeUntaggedUnexported bool
 	// ExcludeUntagged           bool
-	// CustomEncoders map[reflect.Type]CustomEncoder
 }
 
 func (c *EncoderConfig) InitializeDefaults() {
 	if c.TagName == "" {
 		c.TagName = "bcs"
 	}
+	if c.Registry == nil {
+		c.Registry = DefaultRegistry
+	}
 }
 
 func NewBytesEncoder() *BytesEncoder {
-	var buf bytes.Buffer
-	return &BytesEncoder{Encoder: *NewEncoder(&buf), buf: &buf}
+	return NewBytesEncoderWithOpts(EncoderConfig{})
+}
+
+// NewBytesEncoderWithOpts creates encoder, which appends bytes directly into its slice
+// instead of writing them into io.Writer.
+func NewBytesEncoderWithOpts(cfg EncoderConfig) *BytesEncoder {
+	return &BytesEncoder{Encoder: *NewEncoderWithOpts(nil, cfg)}
 }
 
 type BytesEncoder struct {
 	Encoder
-	buf *bytes.Buffer
 }
 
 func (e *BytesEncoder) Bytes() []byte {
-	return e.buf.Bytes()
+	return e.dst
+}
+
+// Reset discards encoded bytes and error of encoder, so it could be used again. Memory of the buffer is reused,
+// so slices returned by Bytes() before Reset() must not be used after it.
+func (e *BytesEncoder) Reset() {
+	e.dst = e.dst[:0]
+	e.err = nil
+	e.failure = nil
+	e.offset = 0
+	e.path = e.path[:0]
+}
+
+// EncodedSize returns the number of bytes v would be encoded into. Nothing is actually written,
+// only sizes are counted, so this is cheaper than encoding, especially for types of fixed size.
+// Custom encoders are called as usual, but everything they write is only counted.
+// Same as MarshalStream, "*any" is treated as "any".
+func EncodedSize[V any](v *V) (int, error) {
+	e := NewCountingEncoder()
+
+	switch v := interface{}(v).(type) {
+	case *interface{}:
+		e.Encode(*v)
+	default:
+		e.Encode(v)
+	}
+
+	if e.err != nil {
+		return 0, e.Err()
+	}
+
+	return e.Size(), nil
+}
+
+// NewCountingEncoder creates counting encoder with default config (see NewCountingEncoderWithOpts).
+func NewCountingEncoder() *Encoder {
+	return NewCountingEncoderWithOpts(EncoderConfig{})
+}
+
+// NewCountingEncoderWithOpts creates encoder, which does not write anything, but only counts size of encoded values.
+// The size is returned by Size().
+func NewCountingEncoderWithOpts(cfg EncoderConfig) *Encoder {
+	e := NewEncoderWithOpts(nil, cfg)
+	e.countOnly = true
+
+	return e
+}
+
+// Encoders with larger buffers are not returned into the pool to avoid keeping rarely needed memory.
+const maxPooledEncoderBufferSize = 64 * 1024
+
+var bytesEncodersPool = sync.Pool{
+	New: func() any { return NewBytesEncoder() },
+}
+
+// GetBytesEncoder returns empty BytesEncoder with default config from the pool.
+// After use it should be returned using PutBytesEncoder.
+//
+// Example:
+//
+//	e := bcs.GetBytesEncoder()
+//	defer bcs.PutBytesEncoder(e)
+//
+//	e.Encode(&v)
+//	if e.Err() != nil {
+//	    return e.Err()
+//	}
+//	hash := blake2b.Sum256(e.Bytes())
+func GetBytesEncoder() *BytesEncoder {
+	return bytesEncodersPool.Get().(*BytesEncoder)
+}
+
+// PutBytesEncoder resets encoder and returns it into the pool. Bytes of the encoder must not be used after that.
+func PutBytesEncoder(e *BytesEncoder) {
+	if cap(e.dst) > maxPooledEncoderBufferSize {
+		e.dst = nil
+	}
+
+	e.Reset()
+	bytesEncodersPool.Put(e)
 }
 
 func NewEncoder(dest io.Writer) *Encoder {
 	return NewEncoderWithOpts(dest, EncoderConfig{})
 }
 
+// NewEncoderWithOpts creates encoder writing into dest.
+// If dest is nil, bytes are appended into internal slice instead (see BytesEncoder).
 func NewEncoderWithOpts(dest io.Writer, cfg EncoderConfig) *Encoder {
 	cfg.InitializeDefaults()
 
 	return &Encoder{
 		cfg:           cfg,
 		w:             dest,
-		typeInfoCache: encoderGlobalTypeInfoCache.Get(),
+		typeInfoCache: cfg.Registry.encoderTypeInfoCache.Get(),
 	}
 }
 
 type Encoder struct {
-	cfg           EncoderConfig
-	w             io.Writer
+	cfg EncoderConfig
+	// Stream to write into. If nil, bytes are appended to dst.
+	w   io.Writer
+	dst []byte
+	// If set, bytes are not written anywhere, only their count is tracked.
+	countOnly     bool
 	err           error
 	typeInfoCache localTypeInfoCache
+	path          valuePath
+	// Structs, which plans are being compiled.
+	compilingStructs []reflect.Type
+
+	// Count of bytes written into the stream.
+	offset int64
+	// Location of the first failure.
+	failure *EncodeError
+
+	// Buffer for writing fixed-size values without allocations.
+	scratch [32]byte
+}
+
+// Config returns configuration of encoder. Could be used to create another encoder with same settings,
+// e.g. for encoding map keys.
+func (e *Encoder) Config() EncoderConfig {
+	return e.cfg
 }
 
+// Size returns the number of bytes written (or counted) by encoder so far.
+func (e *Encoder) Size() int {
+	return int(e.offset)
+}
+
+// Err returns *EncodeError describing the first failure of encoder or nil if there were no failures.
 func (e *Encoder) Err() error {
-	return e.err
+	if e.err == nil {
+		return nil
+	}
+
+	err := *e.failure
+	err.Err = e.err
+
+	return &err
 }
 
 func (e *Encoder) MustEncode(val any) {
 	e.Encode(val)
 	if e.err != nil {
-		panic(e.err)
+		panic(e.Err())
 	}
 }
 
@@ -174,18 +328,31 @@ func (e *Encoder) MustEncode(val any) {
 //	    return nil
 //	}
 func (e *Encoder) Encode(val any) {
+	e.EncodeWithOptions(val, nil)
+}
+
+// EncodeWithOptions is same as Encode, but also applies options to the value same way as if they were specified
+// in a tag of struct field.
+func (e *Encoder) EncodeWithOptions(val any, opts *TypeOptions) {
 	if e.err != nil {
 		return
 	}
 
 	if val == nil {
-		_ = e.handleErrorf("cannot encode a nil value")
+		_ = e.kindErrorf(ErrNilValue, "cannot encode a nil value")
 		return
 	}
 
-	defer e.typeInfoCache.Save()
+	// Encode() could be called from custom encoder, so path may be already non-empty.
+	pathLen := len(e.path)
+	defer func() { e.path = e.path[:pathLen] }()
+
+	if pathLen == 0 {
+		e.typeInfoCache.Refresh()
+		e.path.pushField(rootTypeName(reflect.TypeOf(val)))
+	}
 
-	if err := e.encodeValue(reflect.ValueOf(val), nil, nil); err != nil {
+	if err := e.encodeValue(reflect.ValueOf(val), opts, nil, nil); err != nil {
 		_ = e.handleErrorf("encoding %T: %w", val, err)
 		return
 	}
@@ -201,7 +368,7 @@ func (e *Encoder) EncodeOptional(val any) {
 	switch v.Kind() {
 	case reflect.Ptr, reflect.Interface, reflect.Map:
 	default:
-		_ = e.handleErrorf("optional value must be a pointer, interface or map, got %v", v.Type())
+		_ = e.kindErrorf(ErrUnsupportedType, "optional value must be a pointer, interface or map, got %v", v.Type())
 		return
 	}
 
@@ -216,31 +383,32 @@ func (e *Encoder) EncodeOptional(val any) {
 
 func (e *Encoder) WriteBool(v bool) {
 	if v {
-		_, _ = e.Write([]byte{0x01})
+		e.WriteByte(0x01)
 	} else {
-		_, _ = e.Write([]byte{0x00})
+		e.WriteByte(0x00)
 	}
 }
 
 //nolint:govet
 func (e *Encoder) WriteByte(v byte) {
-	_, _ = e.Write([]byte{v})
+	e.scratch[0] = v
+	_, _ = e.Write(e.scratch[:1])
 }
 
 func (e *Encoder) WriteInt8(v int8) {
-	_, _ = e.Write([]byte{byte(v)})
+	e.WriteByte(byte(v))
 }
 
 func (e *Encoder) WriteUint8(v uint8) {
-	_, _ = e.Write([]byte{byte(v)})
+	e.WriteByte(v)
 }
 
 func (e *Encoder) WriteInt16(v int16) {
-	e.Write([]byte{byte(v), byte(v >> 8)})
+	e.WriteUint16(uint16(v))
 }
 
 func (e *Encoder) WriteUint16(v uint16) {
-	e.Write([]byte{byte(v), byte(v >> 8)})
+	_, _ = e.Write(binary.LittleEndian.AppendUint16(e.scratch[:0], v))
 }
 
 func (e *Encoder) WriteInt32(v int32) {
@@ -248,9 +416,7 @@ func (e *Encoder) WriteInt32(v int32) {
 }
 
 func (e *Encoder) WriteUint32(v uint32) {
-	var b [4]byte
-	binary.LittleEndian.PutUint32(b[:], v)
-	_, _ = e.Write(b[:])
+	_, _ = e.Write(binary.LittleEndian.AppendUint32(e.scratch[:0], v))
 }
 
 func (e *Encoder) WriteInt64(v int64) {
@@ -258,9 +424,7 @@ func (e *Encoder) WriteInt64(v int64) {
 }
 
 func (e *Encoder) WriteUint64(v uint64) {
-	var b [8]byte
-	binary.LittleEndian.PutUint64(b[:], v)
-	_, _ = e.Write(b[:])
+	_, _ = e.Write(binary.LittleEndian.AppendUint64(e.scratch[:0], v))
 }
 
 func (e *Encoder) WriteInt(v int) {
@@ -273,14 +437,30 @@ func (e *Encoder) WriteUint(v uint) {
 
 func (e *Encoder) WriteString(v string) {
 	e.WriteLen(len(v))
+
+	if e.w == nil && !e.countOnly {
+		if e.err == nil {
+			e.dst = append(e.dst, v...)
+			e.offset += int64(len(v))
+		}
+
+		return
+	}
+
 	_, _ = e.Write([]byte(v))
 }
 
+// Writes variable-length array of bytes prepended by its length.
+func (e *Encoder) WriteBytes(v []byte) {
+	e.WriteLen(len(v))
+	_, _ = e.Write(v)
+}
+
 func (e *Encoder) WriteOptionalFlag(hasValue bool) {
 	if hasValue {
-		e.Write([]byte{1})
+		e.WriteByte(1)
 	} else {
-		e.Write([]byte{0})
+		e.WriteByte(0)
 	}
 }
 
@@ -295,29 +475,14 @@ func (e *Encoder) WriteLen(length int) {
 
 func (e *Encoder) WriteCompactUint64(v uint64) {
 	// ULEB - unsigned little-endian base-128 - variable-length integer value.
-	// TODO: not effective for negative values - need separate version for them.
-	switch {
-	case v < 0x80:
-		_, _ = e.Write([]byte{byte(v)})
-	case v < 0x4000:
-		_, _ = e.Write([]byte{byte(v | 0x80), byte(v >> 7)})
-	case v < 0x20_0000:
-		_, _ = e.Write([]byte{byte(v | 0x80), byte((v >> 7) | 0x80), byte(v >> 14)})
-	case v < 0x1000_0000:
-		_, _ = e.Write([]byte{byte(v | 0x80), byte((v >> 7) | 0x80), byte((v >> 14) | 0x80), byte(v >> 21)})
-	case v < 0x8_0000_0000:
-		_, _ = e.Write([]byte{byte(v | 0x80), byte((v >> 7) | 0x80), byte((v >> 14) | 0x80), byte((v >> 21) | 0x80), byte(v >> 28)})
-	case v < 0x400_0000_0000:
-		_, _ = e.Write([]byte{byte(v | 0x80), byte((v >> 7) | 0x80), byte((v >> 14) | 0x80), byte((v >> 21) | 0x80), byte((v >> 28) | 0x80), byte(v >> 35)})
-	case v < 0x2_0000_0000_0000:
-		_, _ = e.Write([]byte{byte(v | 0x80), byte((v >> 7) | 0x80), byte((v >> 14) | 0x80), byte((v >> 21) | 0x80), byte((v >> 28) | 0x80), byte((v >> 35) | 0x80), byte(v >> 42)})
-	case v < 0x100_0000_0000_0000:
-		_, _ = e.Write([]byte{byte(v | 0x80), byte((v >> 7) | 0x80), byte((v >> 14) | 0x80), byte((v >> 21) | 0x80), byte((v >> 28) | 0x80), byte((v >> 35) | 0x80), byte((v >> 42) | 0x80), byte(v >> 49)})
-	case v < 0x8000_0000_0000_0000:
-		_, _ = e.Write([]byte{byte(v | 0x80), byte((v >> 7) | 0x80), byte((v >> 14) | 0x80), byte((v >> 21) | 0x80), byte((v >> 28) | 0x80), byte((v >> 35) | 0x80), byte((v >> 42) | 0x80), byte((v >> 49) | 0x80), byte(v >> 56)})
-	default:
-		_, _ = e.Write([]byte{byte(v | 0x80), byte((v >> 7) | 0x80), byte((v >> 14) | 0x80), byte((v >> 21) | 0x80), byte((v >> 28) | 0x80), byte((v >> 35) | 0x80), byte((v >> 42) | 0x80), byte((v >> 49) | 0x80), byte((v >> 56) | 0x80), byte(v >> 63)})
-	}
+	// It is same as unsigned varint of encoding/binary.
+	_, _ = e.Write(binary.AppendUvarint(e.scratch[:0], v))
+}
+
+// WriteCompactInt64 writes signed integer as ULEB128 of its ZigZag encoding, so values of small magnitude
+// take few bytes regardless of their sign: 0 -> 0, -1 -> 1, 1 -> 2, -2 -> 3 etc.
+func (e *Encoder) WriteCompactInt64(v int64) {
+	e.WriteCompactUint64(uint64(v<<1) ^ uint64(v>>63)) //nolint:gosec
 }
 
 // For support of io.Writer interface
@@ -326,13 +491,38 @@ func (e *Encoder) Write(b []byte) (n int, _ error) {
 		return 0, e.err
 	}
 
-	n, e.err = e.w.Write(b)
+	switch {
+	case e.countOnly:
+		e.offset += int64(len(b))
+		return len(b), nil
+	case e.w == nil:
+		e.dst = append(e.dst, b...)
+		e.offset += int64(len(b))
+
+		return len(b), nil
+	}
+
+	n, err := e.w.Write(b)
+	e.offset += int64(n)
+	if err != nil {
+		_ = e.setErr(nil, err)
+	}
 
 	return n, e.err
 }
 
+// Encodes value v. If info of its type and options of type merged with options from tag are already known
+// (e.g. from plan of struct), they are passed as tInfo and mergedTypeOptions to avoid collecting them for each value.
+//
 //nolint:gocyclo,funlen
-func (e *Encoder) encodeValue(v reflect.Value, typeOptionsFromTag *TypeOptions, tInfo *typeInfo) error {
+func (e *Encoder) encodeValue(v reflect.Value, typeOptionsFromTag *TypeOptions, tInfo *typeInfo, mergedTypeOptions *TypeOptions) (err error) {
+	defer func() {
+		if err != nil && e.failure != nil && e.failure.Type == nil {
+			// The innermost value is the one, which failed.
+			e.failure.Type = v.Type()
+		}
+	}()
+
 	if tInfo == nil {
 		// Hint about type customization could have been provided by caller when encoding collections.
 		// This is done to avoid parsing type for each element of collection.
@@ -346,15 +536,33 @@ func (e *Encoder) encodeValue(v reflect.Value, typeOptionsFromTag *TypeOptions,
 		tInfo = &t
 	}
 
-	v, err := e.getEncodedValue(v, tInfo.RefLevelsCount)
+	if e.countOnly && tInfo.HasFixedSize && hasDefaultEncoding(typeOptionsFromTag) {
+		e.offset += int64(tInfo.FixedSize)
+		return nil
+	}
+
+	v, err = e.getEncodedValue(v, tInfo.RefLevelsCount)
 	if err != nil {
 		return e.handleErrorf("%v: %w", v.Type(), err)
 	}
 
+	if typeOptionsFromTag != nil && typeOptionsFromTag.BigIntEncoding != BigIntDefault {
+		if v.Type() != bigIntT {
+			return e.kindErrorf(ErrInvalidOptions, "%v: bigint option is applicable only to big.Int", v.Type())
+		}
+
+		bigI := v.Interface().(big.Int)
+		if err := e.encodeBigInt(&bigI, typeOptionsFromTag.BigIntEncoding); err != nil {
+			return e.kindErrorf(ErrOverflow, "%v: %v: %w", v.Type(), typeOptionsFromTag.BigIntEncoding, err)
+		}
+
+		return nil
+	}
+
 	if tInfo.CustomEncoder != nil {
 		if err := tInfo.CustomEncoder(e, v); err != nil { //nolint:govet
 			if e.err == nil {
-				e.err = err
+				_ = e.setErr(nil, err)
 			}
 			return e.handleErrorf("%v: custom encoder: %w", v.Type(), err)
 		}
@@ -365,27 +573,45 @@ func (e *Encoder) encodeValue(v reflect.Value, typeOptionsFromTag *TypeOptions,
 		return nil
 	}
 
-	var typeOptions TypeOptions
-	if tInfo.HasTypeOptions {
-		typeOptions = v.Interface().(BCSType).BCSOptions()
+	if tInfo.IntEnumNames != nil {
+		if err := e.encodeIntEnum(v, tInfo.IntEnumNames); err != nil {
+			return e.handleErrorf("%v: %w", v.Type(), err)
+		}
+
+		return nil
 	}
-	if typeOptionsFromTag != nil {
-		typeOptions.Update(*typeOptionsFromTag)
+
+	var typeOptions TypeOptions
+	if mergedTypeOptions != nil {
+		typeOptions = *mergedTypeOptions
+	} else {
+		if tInfo.HasTypeOptions {
+			typeOptions = tInfo.TypeOptions
+		}
+		if typeOptionsFromTag != nil {
+			typeOptions.Update(*typeOptionsFromTag)
+		}
 	}
 
 	switch v.Kind() {
 	case reflect.Bool:
 		e.WriteBool(v.Bool())
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		if typeOptions.IsCompactInt {
+		switch {
+		case typeOptions.IsCompactInt && typeOptions.IsCompactZigZag:
+			e.WriteCompactInt64(v.Int())
+		case typeOptions.IsCompactInt:
 			e.WriteCompactUint64(uint64(v.Int())) //nolint:gosec
-		} else {
+		default:
 			err = e.encodeInt(v, typeOptions.UnderlyingType)
 		}
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		if typeOptions.IsCompactInt {
+		switch {
+		case typeOptions.IsCompactInt && typeOptions.IsCompactZigZag:
+			return e.kindErrorf(ErrInvalidOptions, "%v: zigzag encoding is applicable only to signed integers", v.Type())
+		case typeOptions.IsCompactInt:
 			e.WriteCompactUint64(v.Uint())
-		} else {
+		default:
 			err = e.encodeUint(v, typeOptions.UnderlyingType)
 		}
 	case reflect.String:
@@ -410,14 +636,14 @@ func (e *Encoder) encodeValue(v reflect.Value, typeOptionsFromTag *TypeOptions,
 		err = e.encodeMap(v, typeOptions)
 	case reflect.Struct:
 		if tInfo.IsStructEnum {
-			err = e.encodeStructEnum(v)
+			err = e.encodeStructEnum(v, tInfo)
 		} else {
 			err = e.encodeStruct(v, tInfo)
 		}
 	case reflect.Interface:
 		err = e.encodeInterface(v, !typeOptions.InterfaceIsNotEnum)
 	default:
-		return e.handleErrorf("%v: cannot encode unknown type", v.Type())
+		return e.kindErrorf(ErrUnsupportedType, "%v: cannot encode unknown type", v.Type())
 	}
 
 	if err != nil {
@@ -436,6 +662,12 @@ type typeInfo struct {
 	typeCustomization
 	FieldOptions []FieldOptions
 	FieldHasTag  []bool
+	// Compiled plan of encoding or decoding of struct fields
+	StructPlan []fieldPlan
+	// Size of encoded value, if it is same for all values of the type.
+	// Only known for types without customizations, which are encoded without any checks.
+	FixedSize    int
+	HasFixedSize bool
 }
 
 // Finds actual type we want to encode from the current type of value.
@@ -483,17 +715,27 @@ func (e *Encoder) getEncodedTypeInfo(t reflect.Type) (typeInfo, error) {
 		}
 	}
 
-	customization := e.checkTypeCustomizations(t)
+	customization, err := e.checkTypeCustomizations(t)
+	if err != nil {
+		return typeInfo{}, err
+	}
 
 	res := typeInfo{RefLevelsCount: refLevelsCount, typeCustomization: customization}
 
 	if t.Kind() == reflect.Struct {
 		// Value type is struct - parsing tags of its fields
-		var err error
 		res.FieldOptions, res.FieldHasTag, err = FieldOptionsFromStruct(t, e.cfg.TagName)
 		if err != nil {
-			return typeInfo{}, e.handleErrorf("parsing struct fields options: %v: %w", t, err)
+			return typeInfo{}, e.kindErrorf(ErrInvalidOptions, "parsing struct fields options: %v: %w", t, err)
 		}
+
+		e.compilingStructs = append(e.compilingStructs, t)
+		res.StructPlan = compileStructPlan(t, res.FieldOptions, res.FieldHasTag, e.fieldTypeInfo)
+		e.compilingStructs = e.compilingStructs[:len(e.compilingStructs)-1]
+	}
+
+	if refLevelsCount == 0 && !res.HasCustomizations() {
+		res.FixedSize, res.HasFixedSize = e.fixedEncodedSize(t, res.FieldOptions, res.FieldHasTag)
 	}
 
 	e.typeInfoCache.Add(initialT, res)
@@ -501,6 +743,89 @@ func (e *Encoder) getEncodedTypeInfo(t reflect.Type) (typeInfo, error) {
 	return res, nil
 }
 
+// Returns size of encoded values of type t, if it is same for all values.
+// Only types, which encoding cannot fail and does not depend on value, have fixed size.
+func (e *Encoder) fixedEncodedSize(t reflect.Type, fieldOpts []FieldOptions, fieldHasTag []bool) (int, bool) {
+	switch t.Kind() {
+	case reflect.Bool, reflect.Int8, reflect.Uint8:
+		return 1, true
+	case reflect.Int16, reflect.Uint16:
+		return 2, true
+	case reflect.Int32, reflect.Uint32:
+		return 4, true
+	case reflect.Int64, reflect.Uint64, reflect.Int, reflect.Uint:
+		return 8, true
+	case reflect.Array:
+		elemInfo, ok := e.fixedSizeTypeInfo(t.Elem())
+		if !ok {
+			return 0, false
+		}
+
+		return t.Len() * elemInfo.FixedSize, true
+	case reflect.Struct:
+		size := 0
+
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+
+			switch {
+			case fieldOpts[i].Skip:
+				continue
+			case !field.IsExported() && !fieldHasTag[i]:
+				continue
+			case !field.IsExported() || fieldOpts[i].Optional || fieldOpts[i].AsByteArray || !hasDefaultEncoding(&fieldOpts[i].TypeOptions):
+				// Options of field may affect encoding or result in error
+				return 0, false
+			}
+
+			fieldInfo, ok := e.fixedSizeTypeInfo(field.Type)
+			if !ok {
+				return 0, false
+			}
+
+			size += fieldInfo.FixedSize
+		}
+
+		return size, true
+	default:
+		return 0, false
+	}
+}
+
+// Checks that options do not change the way value is encoded.
+func hasDefaultEncoding(o *TypeOptions) bool {
+	if o == nil {
+		return true
+	}
+	if o.LenSizeInBytes != 0 || o.UnderlyingType != reflect.Invalid || o.BigIntEncoding != BigIntDefault || o.IsCompactInt {
+		return false
+	}
+	if o.ArrayElement != nil && (o.ArrayElement.AsByteArray || !hasDefaultEncoding(&o.ArrayElement.TypeOptions)) {
+		return false
+	}
+
+	return hasDefaultEncoding(o.MapKey) && hasDefaultEncoding(o.MapValue)
+}
+
+// Returns type info of t, if it has fixed size. Types, which surely do not have fixed size, are not parsed.
+func (e *Encoder) fixedSizeTypeInfo(t reflect.Type) (typeInfo, bool) {
+	switch t.Kind() {
+	case reflect.Bool, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int,
+		reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint,
+		reflect.Array, reflect.Struct:
+	default:
+		return typeInfo{}, false
+	}
+
+	// Errors are ignored here. If type is invalid, they will be reported when value of that type is encoded.
+	tInfo, err := e.getEncodedTypeInfo(t)
+	if err != nil {
+		return typeInfo{}, false
+	}
+
+	return tInfo, tInfo.HasFixedSize
+}
+
 func (e *Encoder) getEncodedValue(v reflect.Value, refsCount int) (valToEncode reflect.Value, _ error) {
 	if refsCount == -1 {
 		// Custom encoder for pointer type is found, so we need to encode pointer to value instead of value itself.
@@ -518,7 +843,7 @@ func (e *Encoder) getEncodedValue(v reflect.Value, refsCount int) (valToEncode r
 	// Removing all found redundant pointers
 	for i := 0; i < refsCount; i++ {
 		if v.IsNil() {
-			return v, e.handleErrorf("attempt to encode non-optinal nil value of type %v", v.Type())
+			return v, e.kindErrorf(ErrNilValue, "attempt to encode non-optinal nil value of type %v", v.Type())
 		}
 
 		v = v.Elem()
@@ -533,36 +858,53 @@ type typeCustomization struct {
 	Init           InitFunc
 	IsStructEnum   bool
 	HasTypeOptions bool
+	// Variant ids of fields of struct enum
+	EnumVariantIDs []EnumVariantID
+	// Names of values of integer enum
+	IntEnumNames map[EnumVariantID]string
+	// Options of type, which implements BCSType
+	TypeOptions TypeOptions
 }
 
 func (c *typeCustomization) HasCustomizations() bool {
-	return c.CustomEncoder != nil || c.CustomDecoder != nil || c.Init != nil || c.IsStructEnum || c.HasTypeOptions
+	return c.CustomEncoder != nil || c.CustomDecoder != nil || c.Init != nil || c.IsStructEnum || c.HasTypeOptions || c.IntEnumNames != nil
 }
 
-func (e *Encoder) checkTypeCustomizations(t reflect.Type) typeCustomization {
+func (e *Encoder) checkTypeCustomizations(t reflect.Type) (typeCustomization, error) {
 	// Detecting enum variant index might return error, so we
 	// should first check for existence of custom encoder.
 	if customEncoder := e.getCustomEncoder(t); customEncoder != nil {
-		return typeCustomization{CustomEncoder: customEncoder}
+		return typeCustomization{CustomEncoder: customEncoder}, nil
 	}
 
 	kind := t.Kind()
 
 	switch {
 	case kind == reflect.Interface:
-		return typeCustomization{}
+		return typeCustomization{}, nil
 	case kind == reflect.Struct && t.Implements(structEnumT):
-		return typeCustomization{IsStructEnum: true}
-	case t.Implements(bcsTypeT):
-		return typeCustomization{HasTypeOptions: true}
+		variantIDs, err := structEnumVariantIDs(t, e.cfg.TagName, e.kindErrorf)
+		if err != nil {
+			return typeCustomization{}, err
+		}
+
+		return typeCustomization{IsStructEnum: true, EnumVariantIDs: variantIDs}, nil
+	case isIntKind(kind):
+		if names, registered := e.cfg.Registry.IntEnumVariants(t); registered {
+			return typeCustomization{IntEnumNames: names}, nil
+		}
 	}
 
-	return typeCustomization{}
+	if t.Implements(bcsTypeT) {
+		return typeCustomization{HasTypeOptions: true, TypeOptions: typeOptionsOf(t)}, nil
+	}
+
+	return typeCustomization{}, nil
 }
 
 func (e *Encoder) getCustomEncoder(t reflect.Type) CustomEncoder {
 	// Check if this type has custom encoder func
-	if customEncoder, ok := CustomEncoders[t]; ok {
+	if customEncoder := e.cfg.Registry.customEncoder(t); customEncoder != nil {
 		return customEncoder
 	}
 
@@ -653,7 +995,7 @@ func convertEncodeNumber[Value constraints.Numeric](e *Encoder, v Value, encoded
 	case reflect.Uint64, reflect.Uint:
 		return convertEncodeNumber2(e, v, e.WriteUint64)
 	default:
-		return e.handleErrorf("invalid underlaying type %v for type %T", encodedType, lo.Empty[Value]())
+		return e.kindErrorf(ErrInvalidOptions, "invalid underlaying type %v for type %T", encodedType, lo.Empty[Value]())
 	}
 }
 
@@ -662,7 +1004,7 @@ func convertEncodeNumber2[To, From constraints.Numeric](e *Encoder, v From, writ
 	converted := To(v)
 
 	if From(converted) != v {
-		return e.handleErrorf("value %v is out of range of type %T", v, To(0))
+		return e.kindErrorf(ErrOverflow, "value %v is out of range of type %T", v, To(0))
 	}
 
 	write(converted)
@@ -677,14 +1019,14 @@ func (e *Encoder) encodeSlice(v reflect.Value, typeOpts TypeOptions) error {
 	case 0:
 	case Len2Bytes:
 		if length > 0xFFFF {
-			return e.handleErrorf("slice length %v exceeds 2 bytes", length)
+			return e.kindErrorf(ErrOverflow, "slice length %v exceeds 2 bytes", length)
 		}
 	case Len4Bytes:
-		if length > 0xFFFFFFFF {
-			return e.handleErrorf("slice length %v exceeds 4 bytes", length)
+		if uint64(length) > 0xFFFFFFFF {
+			return e.kindErrorf(ErrOverflow, "slice length %v exceeds 4 bytes", length)
 		}
 	default:
-		return e.handleErrorf("invalid collection size type: %v", typeOpts.LenSizeInBytes)
+		return e.kindErrorf(ErrInvalidOptions, "invalid collection size type: %v", typeOpts.LenSizeInBytes)
 	}
 
 	e.WriteLen(v.Len())
@@ -707,14 +1049,26 @@ func (e *Encoder) encodeArray(v reflect.Value, typeOpts TypeOptions) error {
 			_, _ = e.Write(v.Bytes())
 			return nil
 		}
+	}
+
+	if elemSize, ok := bulkElemSize(elemType, &tInfo, typeOpts.ArrayElement); ok && (v.Kind() == reflect.Slice || v.CanAddr()) {
+		// Optimization for arrays of integers and bools.
+		e.encodeArrayBulk(v, elemSize)
+		return nil
+	}
 
-		// There could be other optimizations for encoding of basic types. But I removed them for now for simplicity.
+	if e.countOnly && tInfo.HasFixedSize && !typeOpts.ArrayElement.AsByteArray && hasDefaultEncoding(&typeOpts.ArrayElement.TypeOptions) {
+		e.offset += int64(v.Len() * tInfo.FixedSize)
+		return nil
 	}
 
+	e.path.pushIdx(0)
+
 	if typeOpts.ArrayElement.AsByteArray {
 		for i := 0; i < v.Len(); i++ {
+			e.path.setIdx(i)
 			err := e.encodeAsByteArray(func() error {
-				return e.encodeValue(v.Index(i), &typeOpts.ArrayElement.TypeOptions, &tInfo)
+				return e.encodeValue(v.Index(i), &typeOpts.ArrayElement.TypeOptions, &tInfo, nil)
 			})
 			if err != nil {
 				return e.handleErrorf("[%v]: %v: %w", i, elemType, err)
@@ -722,18 +1076,21 @@ func (e *Encoder) encodeArray(v reflect.Value, typeOpts TypeOptions) error {
 		}
 	} else {
 		for i := 0; i < v.Len(); i++ {
-			if err := e.encodeValue(v.Index(i), &typeOpts.ArrayElement.TypeOptions, &tInfo); err != nil {
+			e.path.setIdx(i)
+			if err := e.encodeValue(v.Index(i), &typeOpts.ArrayElement.TypeOptions, &tInfo, nil); err != nil {
 				return e.handleErrorf("[%v]: %v: %w", i, elemType, err)
 			}
 		}
 	}
 
+	e.path.pop()
+
 	return nil
 }
 
 func (e *Encoder) encodeMap(v reflect.Value, typeOpts TypeOptions) error {
 	if v.IsNil() {
-		return e.handleErrorf("attempt to encode non-optional nil-map")
+		return e.kindErrorf(ErrNilValue, "attempt to encode non-optional nil-map")
 	}
 
 	length := v.Len()
@@ -742,14 +1099,14 @@ func (e *Encoder) encodeMap(v reflect.Value, typeOpts TypeOptions) error {
 	case 0:
 	case Len2Bytes:
 		if length > 0xFFFF {
-			return e.handleErrorf("map length %v exceeds 2 bytes", length)
+			return e.kindErrorf(ErrOverflow, "map length %v exceeds 2 bytes", length)
 		}
 	case Len4Bytes:
-		if length > 0xFFFFFFFF {
-			return e.handleErrorf("map length %v exceeds 4 bytes", length)
+		if uint64(length) > 0xFFFFFFFF {
+			return e.kindErrorf(ErrOverflow, "map length %v exceeds 4 bytes", length)
 		}
 	default:
-		return e.handleErrorf("invalid collection size type: %v", typeOpts.LenSizeInBytes)
+		return e.kindErrorf(ErrInvalidOptions, "invalid collection size type: %v", typeOpts.LenSizeInBytes)
 	}
 
 	e.WriteLen(v.Len())
@@ -765,12 +1122,22 @@ func (e *Encoder) encodeMap(v reflect.Value, typeOpts TypeOptions) error {
 		return e.handleErrorf("value: %w", err)
 	}
 
+	if e.countOnly {
+		// Order of entries does not affect size, so there is no need to sort them.
+		return e.countMap(v, typeOpts, &keyTypeInfo, &valTypeInfo)
+	}
+
 	entries := make([]*lo.Tuple2[[]byte, reflect.Value], 0, v.Len())
 
+	e.path.pushIdx(0)
+	e.path.pushField("mapKey")
+
 	for elem := v.MapRange(); elem.Next(); {
+		e.path[len(e.path)-2].idx = len(entries)
+
 		// Encoding keys to be able to sort map entries by key's bytes
 		encodedKey, err := e.getBytes(func() error {
-			return e.encodeValue(elem.Key(), typeOpts.MapKey, &keyTypeInfo)
+			return e.encodeValue(elem.Key(), typeOpts.MapKey, &keyTypeInfo, nil)
 		})
 		if err != nil {
 			return e.handleErrorf("key: %w", err)
@@ -784,68 +1151,95 @@ func (e *Encoder) encodeMap(v reflect.Value, typeOpts TypeOptions) error {
 		return bytes.Compare(entries[i].A, entries[j].A) < 0
 	})
 
+	e.path.setField("mapValue")
+
 	for i := range entries {
 		_, _ = e.Write(entries[i].A)
 
-		if err := e.encodeValue(entries[i].B, typeOpts.MapValue, &valTypeInfo); err != nil {
+		e.path[len(e.path)-2].idx = i
+
+		if err := e.encodeValue(entries[i].B, typeOpts.MapValue, &valTypeInfo, nil); err != nil {
 			return e.handleErrorf("value: %w", err)
 		}
 	}
 
+	e.path.pop()
+	e.path.pop()
+
 	return nil
 }
 
-func (e *Encoder) encodeStruct(v reflect.Value, tInfo *typeInfo) error {
-	t := v.Type()
+func (e *Encoder) countMap(v reflect.Value, typeOpts TypeOptions, keyTypeInfo, valTypeInfo *typeInfo) error {
+	e.path.pushIdx(0)
+	e.path.pushField("")
 
-	for i := 0; i < v.NumField(); i++ {
-		fieldOpts, hasTag := tInfo.FieldOptions[i], tInfo.FieldHasTag[i]
-		if fieldOpts.Skip {
-			continue
+	i := 0
+	for elem := v.MapRange(); elem.Next(); i++ {
+		e.path[len(e.path)-2].idx = i
+
+		e.path.setField("mapKey")
+		if err := e.encodeValue(elem.Key(), typeOpts.MapKey, keyTypeInfo, nil); err != nil {
+			return e.handleErrorf("key: %w", err)
 		}
 
-		fieldType := t.Field(i)
-		fieldVal := v.Field(i)
+		e.path.setField("mapValue")
+		if err := e.encodeValue(elem.Value(), typeOpts.MapValue, valTypeInfo, nil); err != nil {
+			return e.handleErrorf("value: %w", err)
+		}
+	}
 
-		if !fieldType.IsExported() {
-			if !fieldOpts.ExportAnonymousField {
-				if hasTag {
-					return e.handleErrorf("%v: unexported field %v has BCS tag, but is not marked for export", t.Name(), fieldType.Name)
-				}
+	e.path.pop()
+	e.path.pop()
 
-				// Unexported fields are skipped by default if not explicitly marked as exported
-				continue
-			}
+	return nil
+}
 
-			if !fieldVal.CanAddr() {
-				// Field is not addressable yet - making it addressable
-				vCopy := reflect.New(t).Elem()
-				vCopy.Set(v)
-				v = vCopy
-				fieldVal = v.Field(i)
-			}
+func (e *Encoder) encodeStruct(v reflect.Value, tInfo *typeInfo) error {
+	for i := range tInfo.StructPlan {
+		f := &tInfo.StructPlan[i]
 
-			// Accesing unexported field
-			fieldVal = reflect.NewAt(fieldVal.Type(), unsafe.Pointer(fieldVal.UnsafeAddr())).Elem()
-		} else if fieldOpts.ExportAnonymousField {
-			return e.handleErrorf("%v: field %v is already exported, but is marked for export", t.Name(), fieldType.Name)
+		if f.Err != "" {
+			return e.kindErrorf(ErrInvalidOptions, "%v", f.Err)
 		}
 
-		fieldKind := fieldVal.Kind()
+		if f.IsUnexported && !v.CanAddr() {
+			// Struct is not addressable yet - making it addressable to access unexported field
+			vCopy := reflect.New(v.Type()).Elem()
+			vCopy.Set(v)
+			v = vCopy
+		}
+
+		fieldVal := f.value(v)
+
+		e.path.pushField(f.Name)
+
+		if f.Encode != nil {
+			f.Encode(e, fieldVal)
+			if e.err != nil {
+				if e.failure != nil && e.failure.Type == nil {
+					e.failure.Type = f.Type
+				}
 
-		if fieldKind == reflect.Ptr || fieldKind == reflect.Interface || fieldKind == reflect.Map || fieldKind == reflect.Slice {
-			// The field is nullable
+				return e.handleErrorf("%v: %v: %w", f.Name, f.Type, e.err)
+			}
+
+			e.path.pop()
+			continue
+		}
 
+		if f.IsNullable {
 			isNil := fieldVal.IsNil()
+			fieldKind := f.Type.Kind()
 
-			if isNil && !fieldOpts.Optional && fieldKind != reflect.Interface && fieldKind != reflect.Slice {
-				return e.handleErrorf("%v: non-optional nil value", fieldType.Name)
+			if isNil && !f.Opts.Optional && fieldKind != reflect.Interface && fieldKind != reflect.Slice {
+				return e.kindErrorf(ErrNilValue, "%v: non-optional nil value", f.Name)
 			}
 
-			if fieldOpts.Optional {
+			if f.Opts.Optional {
 				e.WriteByte(lo.Ternary[byte](isNil, 0, 1))
 
 				if isNil {
+					e.path.pop()
 					continue
 				}
 			}
@@ -853,97 +1247,151 @@ func (e *Encoder) encodeStruct(v reflect.Value, tInfo *typeInfo) error {
 
 		var err error
 
-		if fieldOpts.AsByteArray {
+		if f.Opts.AsByteArray {
 			err = e.encodeAsByteArray(func() error {
-				return e.encodeValue(fieldVal, &fieldOpts.TypeOptions, nil)
+				return e.encodeValue(fieldVal, &f.Opts.TypeOptions, f.Info, f.TypeOpts)
 			})
 		} else {
-			err = e.encodeValue(fieldVal, &fieldOpts.TypeOptions, nil)
+			err = e.encodeValue(fieldVal, &f.Opts.TypeOptions, f.Info, f.TypeOpts)
 		}
 
 		if err != nil {
-			return e.handleErrorf("%v: %w", fieldType.Name, err)
+			return e.handleErrorf("%v: %w", f.Name, err)
 		}
+
+		e.path.pop()
 	}
 
 	return nil
 }
 
-func (e *Encoder) encodeStructEnum(v reflect.Value) error {
-	enumVariantIdx, err := e.getStructEnumVariantIdx(v)
+// Returns info of type of struct field for plan of struct. Returns nil if info is not known yet:
+// * for recursive types, because info of struct is not complete until its plan is compiled;
+// * if collecting info failed. The error is reported when the field is reached, so it is not kept here.
+func (e *Encoder) fieldTypeInfo(t reflect.Type) *typeInfo {
+	if isCompilingStruct(e.compilingStructs, t) {
+		return nil
+	}
+
+	prevErr, prevFailure := e.err, e.failure
+
+	tInfo, err := e.getEncodedTypeInfo(t)
 	if err != nil {
-		return err
+		e.err, e.failure = prevErr, prevFailure
+		return nil
 	}
 
-	if err := e.encodeEnum(v.Field(enumVariantIdx), enumVariantIdx); err != nil {
+	return &tInfo
+}
+
+func (e *Encoder) encodeStructEnum(v reflect.Value, tInfo *typeInfo) error {
+	fieldIdx, err := e.getStructEnumSetField(v)
+	if err != nil {
 		return err
 	}
 
+	e.path.pushField(v.Type().Field(fieldIdx).Name)
+
+	e.WriteEnumIdx(tInfo.EnumVariantIDs[fieldIdx])
+
+	// Options from tag of variant field are applied same way as for fields of regular structs.
+	fieldVal, fieldOpts := v.Field(fieldIdx), &tInfo.FieldOptions[fieldIdx]
+
+	if fieldOpts.AsByteArray {
+		err = e.encodeAsByteArray(func() error {
+			return e.encodeValue(fieldVal, &fieldOpts.TypeOptions, nil, nil)
+		})
+	} else {
+		err = e.encodeValue(fieldVal, &fieldOpts.TypeOptions, nil, nil)
+	}
+
+	if err != nil {
+		return e.handleErrorf("%v: %w", fieldVal.Type(), err)
+	}
+
+	e.path.pop()
+
 	return nil
 }
 
-func (e *Encoder) getStructEnumVariantIdx(v reflect.Value) (enumVariantIdx EnumVariantID, _ error) {
-	enumVariantIdx = -1
+// Returns index of the only field of struct enum, which is set.
+// Types of fields are expected to be already checked to be nullable.
+func (e *Encoder) getStructEnumSetField(v reflect.Value) (fieldIdx int, _ error) {
+	fieldIdx = -1
 
 	for i := 0; i < v.NumField(); i++ {
-		field := v.Field(i)
+		if v.Field(i).IsNil() {
+			continue
+		}
 
-		k := field.Kind()
-		switch k {
-		case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
-			if field.IsNil() {
-				continue
-			}
+		if fieldIdx != -1 {
+			prevSetField := v.Type().Field(fieldIdx)
+			currentField := v.Type().Field(i)
+			return -1, e.kindErrorf(ErrInvalidEnumVariant, "multiple options are set in enum struct %v: %v and %v", v.Type(), prevSetField.Name, currentField.Name)
+		}
 
-			if enumVariantIdx != -1 {
-				prevSetField := v.Type().Field(enumVariantIdx)
-				currentField := v.Type().Field(i)
-				return -1, e.handleErrorf("multiple options are set in enum struct %v: %v and %v", v.Type(), prevSetField.Name, currentField.Name)
-			}
+		fieldIdx = i
+		// We do not break here to check if there are multiple options set
+	}
 
-			enumVariantIdx = i
-			// We do not break here to check if there are multiple options set
-		default:
-			fieldType := v.Type().Field(i)
-			return -1, e.handleErrorf("field %v of enum %v is of non-nullable type %v", fieldType.Name, v.Type(), fieldType.Type)
+	if fieldIdx == -1 {
+		return -1, e.kindErrorf(ErrInvalidEnumVariant, "no options are set in enum struct %v", v.Type())
+	}
+
+	return fieldIdx, nil
+}
+
+func (e *Encoder) encodeIntEnum(v reflect.Value, names map[EnumVariantID]string) error {
+	id := EnumVariantID(-1)
+
+	if v.CanInt() {
+		if i := v.Int(); i >= 0 && i <= math.MaxInt {
+			id = EnumVariantID(i)
 		}
+	} else if u := v.Uint(); u <= math.MaxInt {
+		id = EnumVariantID(u)
 	}
 
-	if enumVariantIdx == -1 {
-		return -1, e.handleErrorf("no options are set in enum struct %v", v.Type())
+	if _, ok := names[id]; !ok {
+		return e.kindErrorf(ErrInvalidEnumVariant, "value %d is not a variant of enum %v, expected one of: %v", v.Interface(), v.Type(), intEnumVariantsString(names))
 	}
 
-	return enumVariantIdx, nil
+	e.WriteEnumIdx(id)
+
+	return nil
 }
 
 func (e *Encoder) encodeInterface(v reflect.Value, couldBeEnum bool) error {
 	if !couldBeEnum {
 		if v.IsNil() {
-			return e.handleErrorf("cannot encode nil interface, which is not enum and not optional")
+			return e.kindErrorf(ErrNilValue, "cannot encode nil interface, which is not enum and not optional")
 		}
 
-		return e.encodeValue(v.Elem(), nil, nil)
+		return e.encodeValue(v.Elem(), nil, nil, nil)
 	}
 
 	t := v.Type()
 
-	enumVariants, registered := EnumTypes[t]
+	enumVariants, registered := e.cfg.Registry.EnumVariants(t)
 	if !registered {
 		if e.cfg.InterfaceIsEnumByDefault {
-			return e.handleErrorf("interface %v is not registered as enum type", t)
+			return e.kindErrorf(ErrUnsupportedType, "interface %v is not registered as enum type", t)
 		}
 
 		if v.IsNil() {
-			return e.handleErrorf("cannot encode nil interface, which is not enum and not optional")
+			return e.kindErrorf(ErrNilValue, "cannot encode nil interface, which is not enum and not optional")
 		}
 
-		return e.encodeValue(v.Elem(), nil, nil)
+		return e.encodeValue(v.Elem(), nil, nil, nil)
 	}
 
 	enumVariantIdx, err := e.getInterfaceEnumVariantIdx(v, enumVariants)
 	if err != nil {
 		return err
 	}
+	if enumVariantIdx == -1 {
+		return e.encodeUnknownEnumVariant(v, enumVariants)
+	}
 
 	if err := e.encodeEnum(v.Elem(), enumVariantIdx); err != nil {
 		return err
@@ -972,14 +1420,33 @@ func (e *Encoder) getInterfaceEnumVariantIdx(v reflect.Value, enumVariants map[i
 
 	if enumVariantIdx == -1 {
 		if isNil {
-			return -1, e.handleErrorf("bcs.None is not registered as part of enum type %v - cannot encode nil interface enum value", v.Type())
+			return -1, e.kindErrorf(ErrInvalidEnumVariant, "bcs.None is not registered as part of enum type %v - cannot encode nil interface enum value", v.Type())
+		}
+		if valT != e.cfg.Registry.enumUnknownVariant(v.Type()) {
+			return -1, e.kindErrorf(ErrInvalidEnumVariant, "variant %v is not registered as part of enum type %v", valT, v.Type())
 		}
-		return -1, e.handleErrorf("variant %v is not registered as part of enum type %v", valT, v.Type())
 	}
 
 	return enumVariantIdx, nil
 }
 
+// Writes back the value, which was decoded as unknown variant of enum.
+func (e *Encoder) encodeUnknownEnumVariant(v reflect.Value, enumVariants map[int]reflect.Type) error {
+	unknownVariant := v.Elem().Convert(unknownEnumVariantT).Interface().(UnknownEnumVariant)
+
+	if unknownVariant.ID < 0 {
+		return e.kindErrorf(ErrInvalidEnumVariant, "unknown variant of enum %v has negative id %v", v.Type(), unknownVariant.ID)
+	}
+	if _, known := enumVariants[unknownVariant.ID]; known {
+		return e.kindErrorf(ErrInvalidEnumVariant, "unknown variant of enum %v has id %v, which is registered", v.Type(), unknownVariant.ID)
+	}
+
+	e.WriteEnumIdx(unknownVariant.ID)
+	_, _ = e.Write(unknownVariant.Payload)
+
+	return e.err
+}
+
 func (e *Encoder) encodeEnum(v reflect.Value, variantIdx int) error {
 	e.WriteEnumIdx(variantIdx)
 
@@ -987,15 +1454,41 @@ func (e *Encoder) encodeEnum(v reflect.Value, variantIdx int) error {
 		return nil
 	}
 
-	if err := e.encodeValue(v, nil, nil); err != nil {
+	if err := e.encodeValue(v, nil, nil, nil); err != nil {
 		return e.handleErrorf("%v: %w", v.Type(), err)
 	}
 
 	return nil
 }
 
+// EncodeAsByteArray writes bytes produced by enc() prepended with their count.
+// This is how values with "bytearr" option are encoded.
+func (e *Encoder) EncodeAsByteArray(enc func() error) error {
+	if e.err != nil {
+		return e.err
+	}
+
+	return e.encodeAsByteArray(enc)
+}
+
 // Captures bytes written by enc() and prepends them with their count.
 func (e *Encoder) encodeAsByteArray(enc func() error) error {
+	if e.countOnly {
+		// Bytes do not need to be captured, only their count is needed to count size of length prefix.
+		start := e.offset
+		if err := enc(); err != nil {
+			return err
+		}
+
+		e.WriteLen(int(e.offset - start))
+
+		if e.err != nil {
+			return e.handleErrorf("bytearr: %w", e.err)
+		}
+
+		return nil
+	}
+
 	encodedVal, err := e.getBytes(enc)
 	if err != nil {
 		return err
@@ -1012,25 +1505,54 @@ func (e *Encoder) encodeAsByteArray(enc func() error) error {
 }
 
 func (e *Encoder) getBytes(enc func() error) ([]byte, error) {
-	origStream := e.w
-	defer func() { e.w = origStream }() // for case of panic/error
+	origStream, origDst, origCountOnly, origOffset := e.w, e.dst, e.countOnly, e.offset
+	defer func() { e.w, e.dst, e.countOnly = origStream, origDst, origCountOnly }() // for case of panic/error
 
-	buff := bytes.NewBuffer(nil)
-	e.w = buff
+	e.w, e.dst, e.countOnly = nil, nil, false
 	if err := enc(); err != nil {
 		return nil, err
 	}
 
-	return buff.Bytes(), nil
+	// Captured bytes are not written into the stream yet.
+	e.offset = origOffset
+
+	return e.dst, nil
+}
+
+// KindErrorf sets error of encoder and returns it. If this is the first failure of encoder, its kind and
+// location are recorded same way as for failures detected by encoder itself, so they are reported by Err().
+// If kind is nil, it is detected from the error, e.g. wrapped using %w.
+// This is how custom (e.g. generated) encoders report failures like ErrOverflow.
+func (e *Encoder) KindErrorf(kind error, format string, args ...interface{}) error {
+	return e.kindErrorf(kind, format, args...)
 }
 
 func (e *Encoder) handleErrorf(format string, args ...interface{}) error {
-	e.err = fmt.Errorf(format, args...)
-	return e.err
+	return e.setErr(nil, fmt.Errorf(format, args...))
+}
+
+// Same as handleErrorf, but also sets kind of failure if this is the first failure of encoder.
+func (e *Encoder) kindErrorf(kind error, format string, args ...interface{}) error {
+	return e.setErr(kind, fmt.Errorf(format, args...))
+}
+
+// Sets error of encoder. Location of the first failure is recorded to be reported by Err().
+// If kind is nil, it is detected from the error.
+func (e *Encoder) setErr(kind, err error) error {
+	if e.failure == nil {
+		if kind == nil {
+			kind = errorKind(err)
+		}
+
+		e.failure = &EncodeError{Kind: kind, Path: e.path.String(), Offset: e.offset}
+	}
+
+	e.err = err
+
+	return err
 }
 
 var (
-	encodableT                 = reflect.TypeOf((*Encodable)(nil)).Elem()
-	writableT                  = reflect.TypeOf((*Writable)(nil)).Elem()
-	encoderGlobalTypeInfoCache = newSharedTypeInfoCache()
+	encodableT = reflect.TypeOf((*Encodable)(nil)).Elem()
+	writableT  = reflect.TypeOf((*Writable)(nil)).Elem()
 )
@@ -0,0 +1,110 @@
+package bcs
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// EnumVariantSpec describes variant of interface enum for EnumBuilder. Use Variant or NoneVariant to create it.
+type EnumVariantSpec struct {
+	ID   EnumVariantID
+	Name string
+	Type reflect.Type
+}
+
+// Variant describes variant of type V with given id and name. Empty name means name of the type.
+// If V implements enum interface with pointer receiver, pointer type must be used, e.g. Variant[*V].
+func Variant[V any](id EnumVariantID, name string) EnumVariantSpec {
+	return EnumVariantSpec{ID: id, Name: name, Type: reflect.TypeOf((*V)(nil)).Elem()}
+}
+
+// NoneVariant describes variant, which represents absence of value (nil interface). See None.
+func NoneVariant(id EnumVariantID) EnumVariantSpec {
+	return EnumVariantSpec{ID: id, Name: "None", Type: noneT}
+}
+
+// EnumBuilder collects variants of interface enum EnumType for registration.
+// It is an alternative to RegisterEnumType* functions, which is not limited in number of variants,
+// allows to set ids and names of variants and does not require to create values of variant types.
+//
+// Example:
+//
+//	var _ = bcs.NewEnum[Event]().Add(
+//		bcs.NoneVariant(0),
+//		bcs.Variant[Transfer](1, "Transfer"),
+//		bcs.Variant[*Mint](5, "Mint"),
+//	).Register()
+//
+// EnumBuilder is a value: Add returns a new builder and does not modify the original one,
+// so builders could be shared and extended by multiple goroutines.
+type EnumBuilder[EnumType any] struct {
+	variants []EnumVariantSpec
+}
+
+// NewEnum creates builder of interface enum EnumType without variants.
+func NewEnum[EnumType any]() EnumBuilder[EnumType] {
+	return EnumBuilder[EnumType]{}
+}
+
+// Add returns builder with given variants added.
+func (b EnumBuilder[EnumType]) Add(variants ...EnumVariantSpec) EnumBuilder[EnumType] {
+	extended := make([]EnumVariantSpec, 0, len(b.variants)+len(variants))
+	extended = append(extended, b.variants...)
+	extended = append(extended, variants...)
+
+	return EnumBuilder[EnumType]{variants: extended}
+}
+
+// Register registers enum in DefaultRegistry. See RegisterIn.
+func (b EnumBuilder[EnumType]) Register() struct{} {
+	return b.RegisterIn(DefaultRegistry)
+}
+
+// RegisterIn registers enum in registry r.
+// It panics if ids, types or names of variants are duplicated or if other variants of the enum are already registered in r.
+// Registering exactly same enum again is allowed, so it is safe to do it lazily from multiple goroutines.
+func (b EnumBuilder[EnumType]) RegisterIn(r *Registry) struct{} {
+	enumT := reflect.TypeOf((*EnumType)(nil)).Elem()
+
+	variants := make(map[EnumVariantID]reflect.Type, len(b.variants))
+	names := make(map[EnumVariantID]string, len(b.variants))
+	idsByName := make(map[string]EnumVariantID, len(b.variants))
+
+	for _, v := range b.variants {
+		if existingT, duplicate := variants[v.ID]; duplicate {
+			panic(fmt.Errorf("RegisterEnumType: variant id %v of enum %v is used by both %v and %v", v.ID, enumT, existingT, v.Type))
+		}
+
+		checkEnumVariant(enumT, v.ID, v.Type, variants)
+
+		name := v.Name
+		if name == "" {
+			name = enumVariantTypeName(v.Type)
+		}
+
+		if existingID, duplicate := idsByName[name]; duplicate {
+			panic(fmt.Errorf("RegisterEnumType: variants %v and %v of enum %v have same name %v", existingID, v.ID, enumT, name))
+		}
+
+		variants[v.ID] = v.Type
+		names[v.ID] = name
+		idsByName[name] = v.ID
+	}
+
+	r.registerEnumType(enumT, variants, names, true)
+
+	return struct{}{}
+}
+
+// Returns default name of enum variant of type t.
+func enumVariantTypeName(t reflect.Type) string {
+	if t == noneT {
+		return "None"
+	}
+
+	if t.Kind() == reflect.Ptr {
+		return t.Elem().Name()
+	}
+
+	return t.Name()
+}
@@ -0,0 +1,125 @@
+package bcs_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/iotaledger/bcs-go"
+)
+
+type BuilderEnum interface{}
+
+type BuilderVariant struct {
+	A int8
+}
+
+type BuilderPtrVariant struct {
+	A string
+}
+
+type BuilderRestrictedEnum interface {
+	isBuilderRestrictedEnum()
+}
+
+func (*BuilderPtrVariant) isBuilderRestrictedEnum() {}
+
+func TestEnumBuilderManyVariants(t *testing.T) {
+	r := bcs.NewRegistry()
+
+	bcs.NewEnum[BuilderEnum]().Add(
+		bcs.NoneVariant(0),
+		bcs.Variant[int8](1, "I8"),
+		bcs.Variant[int16](2, "I16"),
+		bcs.Variant[int32](3, "I32"),
+		bcs.Variant[int64](4, "I64"),
+		bcs.Variant[uint8](5, "U8"),
+		bcs.Variant[uint16](6, "U16"),
+		bcs.Variant[uint32](7, "U32"),
+		bcs.Variant[uint64](8, "U64"),
+		bcs.Variant[bool](9, "Bool"),
+		bcs.Variant[string](10, "Str"),
+		bcs.Variant[[]byte](11, "Bytes"),
+	).Add(
+		bcs.Variant[BuilderVariant](20, ""),
+		bcs.Variant[*BuilderPtrVariant](21, "Ptr"),
+	).RegisterIn(r)
+
+	variants, registered := r.EnumVariants(reflect.TypeOf((*BuilderEnum)(nil)).Elem())
+	require.True(t, registered)
+	require.Len(t, variants, 14)
+
+	for _, v := range []BuilderEnum{nil, int8(-1), uint64(5), "aaa", BuilderVariant{A: 3}, &BuilderPtrVariant{A: "b"}} {
+		b := encodeWithRegistry(t, r, &v)
+		require.Equal(t, v, decodeWithRegistry[BuilderEnum](t, r, b))
+	}
+
+	var v BuilderEnum = BuilderVariant{A: 3}
+	require.Equal(t, []byte{20, 3}, encodeWithRegistry(t, r, &v))
+	v = &BuilderPtrVariant{A: "b"}
+	require.Equal(t, []byte{21, 1, 'b'}, encodeWithRegistry(t, r, &v))
+
+	schema, err := r.ReflectSchemaOf(reflect.TypeOf((*BuilderEnum)(nil)).Elem())
+	require.NoError(t, err)
+	variantFormats := schema["BuilderEnum"].Variants
+	require.Equal(t, "None", variantFormats[0].Name)
+	require.Equal(t, "I16", variantFormats[2].Name)
+	require.Equal(t, "BuilderVariant", variantFormats[20].Name)
+	require.Equal(t, "Ptr", variantFormats[21].Name)
+}
+
+func TestEnumBuilderInvalidVariants(t *testing.T) {
+	r := bcs.NewRegistry()
+	b := bcs.NewEnum[BuilderEnum]().Add(bcs.Variant[int8](0, "A"))
+
+	require.PanicsWithError(t, "RegisterEnumType: variant id 0 of enum bcs_test.BuilderEnum is used by both int8 and int16", func() {
+		b.Add(bcs.Variant[int16](0, "B")).RegisterIn(r)
+	})
+	require.Panics(t, func() {
+		b.Add(bcs.Variant[int8](1, "B")).RegisterIn(r)
+	})
+	require.PanicsWithError(t, "RegisterEnumType: variants 0 and 1 of enum bcs_test.BuilderEnum have same name A", func() {
+		b.Add(bcs.Variant[int16](1, "A")).RegisterIn(r)
+	})
+	require.Panics(t, func() {
+		b.Add(bcs.Variant[int16](-1, "B")).RegisterIn(r)
+	})
+	require.Panics(t, func() {
+		b.Add(bcs.Variant[BuilderRestrictedEnum](1, "B")).RegisterIn(r)
+	})
+	require.Panics(t, func() {
+		bcs.NewEnum[BuilderRestrictedEnum]().Add(bcs.Variant[BuilderPtrVariant](0, "")).RegisterIn(r)
+	})
+
+	// Nothing was registered
+	_, registered := r.EnumVariants(reflect.TypeOf((*BuilderEnum)(nil)).Elem())
+	require.False(t, registered)
+}
+
+func TestEnumBuilderRepeatedRegistration(t *testing.T) {
+	r := bcs.NewRegistry()
+	base := bcs.NewEnum[BuilderRestrictedEnum]()
+	b := base.Add(bcs.NoneVariant(0), bcs.Variant[*BuilderPtrVariant](1, "Ptr"))
+
+	g := errgroup.Group{}
+	for i := 0; i < 10; i++ {
+		g.Go(func() error {
+			b.RegisterIn(r)
+			return nil
+		})
+	}
+	require.NoError(t, g.Wait())
+
+	var v BuilderRestrictedEnum = &BuilderPtrVariant{A: "a"}
+	require.Equal(t, []byte{1, 1, 'a'}, encodeWithRegistry(t, r, &v))
+
+	// Registering different variants is still an error
+	require.Panics(t, func() {
+		base.Add(bcs.Variant[*BuilderPtrVariant](1, "Other")).RegisterIn(r)
+	})
+	require.Panics(t, func() {
+		bcs.RegisterEnumTypeIn[BuilderRestrictedEnum](r, bcs.None{}, &BuilderPtrVariant{})
+	})
+}
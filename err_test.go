@@ -4,10 +4,14 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"io"
+	"reflect"
 	"testing"
 
-	"github.com/iotaledger/bcs-go"
+	"github.com/samber/lo"
 	"github.com/stretchr/testify/require"
+
+	"github.com/iotaledger/bcs-go"
 )
 
 func TestReadingDefferedErrorHandling(t *testing.T) {
@@ -370,3 +374,137 @@ func TestErrorMsg(t *testing.T) {
 	expectedErr = "decoding *bcs_test.Outer: bcs_test.Outer: Inner: bcs_test.Inner: S: []*bcs_test.FunkyStruct: [0]: bcs_test.FunkyStruct: custom decoder: test error from FunkyStruct"
 	require.Equal(t, expectedErr, err.Error())
 }
+
+func TestDecodeErrorLocation(t *testing.T) {
+	type Inner struct {
+		A uint8
+		B []bool
+	}
+
+	type Outer struct {
+		I Inner
+		M map[uint8]bool
+		N int8 `bcs:"type=u16"`
+	}
+
+	var decodeErr *bcs.DecodeError
+
+	_, err := bcs.Unmarshal[Outer]([]byte{1, 2, 1, 2})
+	require.ErrorIs(t, err, bcs.ErrInvalidBool)
+	require.ErrorAs(t, err, &decodeErr)
+	require.Equal(t, bcs.ErrInvalidBool, decodeErr.Kind)
+	require.Equal(t, "Outer.I.B[1]", decodeErr.Path)
+	require.Equal(t, reflect.TypeOf(false), decodeErr.Type)
+	require.Equal(t, int64(4), decodeErr.Offset)
+	// Message is not changed
+	require.Equal(t, "decoding *bcs_test.Outer: bcs_test.Outer: I: bcs_test.Inner: B: []bool: [1]: bool: invalid bool value: 2", err.Error())
+
+	_, err = bcs.Unmarshal[Outer]([]byte{1, 0, 2, 5, 1, 7, 3})
+	require.ErrorIs(t, err, bcs.ErrInvalidBool)
+	require.ErrorAs(t, err, &decodeErr)
+	require.Equal(t, "Outer.M[1].mapValue", decodeErr.Path)
+	require.Equal(t, int64(7), decodeErr.Offset)
+
+	_, err = bcs.Unmarshal[Outer]([]byte{1, 0, 0, 200, 1})
+	require.ErrorIs(t, err, bcs.ErrOverflow)
+	require.ErrorAs(t, err, &decodeErr)
+	require.Equal(t, "Outer.N", decodeErr.Path)
+	require.Equal(t, reflect.TypeOf(int8(0)), decodeErr.Type)
+
+	_, err = bcs.Unmarshal[Outer]([]byte{1, 2, 1})
+	require.ErrorIs(t, err, bcs.ErrUnexpectedEOF)
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	require.ErrorAs(t, err, &decodeErr)
+	require.Equal(t, "Outer.I.B[1]", decodeErr.Path)
+	require.Equal(t, int64(3), decodeErr.Offset)
+
+	_, err = bcs.Unmarshal[Outer]([]byte{1, 0, 0, 1, 0, 9})
+	require.ErrorIs(t, err, bcs.ErrExcessBytes)
+	require.ErrorAs(t, err, &decodeErr)
+	require.Equal(t, int64(5), decodeErr.Offset)
+	require.Equal(t, reflect.TypeOf(Outer{}), decodeErr.Type)
+
+	// Offsets inside of "bytearr" values are offsets in the original stream
+	type WithByteArrBool struct {
+		A string
+		B bool `bcs:"bytearr"`
+	}
+
+	_, err = bcs.Unmarshal[WithByteArrBool]([]byte{1, 'a', 1, 2})
+	require.ErrorIs(t, err, bcs.ErrInvalidBool)
+	require.ErrorAs(t, err, &decodeErr)
+	require.Equal(t, "WithByteArrBool.B", decodeErr.Path)
+	require.Equal(t, int64(4), decodeErr.Offset)
+
+	// Errors of custom decoders are not classified
+	f := &FunkyStruct{}
+	_, err = bcs.UnmarshalInto([]byte{1, 0, 0, 0, 0, 0, 0, 0}, f)
+	require.ErrorAs(t, err, &decodeErr)
+	require.Nil(t, decodeErr.Kind)
+	require.Equal(t, "FunkyStruct", decodeErr.Path)
+	require.Equal(t, reflect.TypeOf(FunkyStruct{}), decodeErr.Type)
+
+	// Limits
+	d := bcs.NewDecoderWithOpts(bytes.NewReader([]byte{1, 0, 3}), bcs.DecoderConfig{Limits: bcs.DecoderLimits{MaxCollectionLen: 2}})
+	d.Decode(new(Outer))
+	require.ErrorIs(t, d.Err(), bcs.ErrLimitExceeded)
+	require.ErrorAs(t, d.Err(), &decodeErr)
+	require.Equal(t, bcs.ErrLimitExceeded, decodeErr.Kind)
+	require.Equal(t, "Outer.M", decodeErr.Path)
+	require.Equal(t, int64(3), decodeErr.Offset)
+
+	// Direct reads
+	bd := bcs.NewBytesDecoder([]byte{0, 3})
+	bd.ReadBool()
+	bd.ReadOptionalFlag()
+	require.ErrorAs(t, bd.Err(), &decodeErr)
+	require.Equal(t, bcs.ErrInvalidOptionalFlag, decodeErr.Kind)
+	require.Equal(t, "", decodeErr.Path)
+	require.Nil(t, decodeErr.Type)
+	require.Equal(t, int64(2), decodeErr.Offset)
+}
+
+func TestEncodeErrorLocation(t *testing.T) {
+	type Inner struct {
+		A uint16
+		P *int
+	}
+
+	type Outer struct {
+		I []Inner
+		M map[string]int64 `bcs_value:"type=i8"`
+		E any              `bcs:"not_enum"`
+	}
+
+	var encodeErr *bcs.EncodeError
+
+	_, err := bcs.Marshal(&Outer{I: []Inner{{P: lo.ToPtr(1)}, {A: 1}}})
+	require.ErrorIs(t, err, bcs.ErrNilValue)
+	require.ErrorAs(t, err, &encodeErr)
+	require.Equal(t, bcs.ErrNilValue, encodeErr.Kind)
+	require.Equal(t, "Outer.I[1].P", encodeErr.Path)
+	require.Equal(t, reflect.TypeOf(Inner{}), encodeErr.Type)
+	require.Equal(t, int64(13), encodeErr.Offset)
+	// Message is not changed
+	require.Equal(t, "encoding *bcs_test.Outer: bcs_test.Outer: I: []bcs_test.Inner: [1]: bcs_test.Inner: bcs_test.Inner: P: non-optional nil value", err.Error())
+
+	_, err = bcs.Marshal(&Outer{M: map[string]int64{"b": 1, "a": 1000}})
+	require.ErrorIs(t, err, bcs.ErrOverflow)
+	require.ErrorAs(t, err, &encodeErr)
+	require.Equal(t, "Outer.M[0].mapValue", encodeErr.Path)
+	require.Equal(t, reflect.TypeOf(int64(0)), encodeErr.Type)
+	require.Equal(t, int64(4), encodeErr.Offset)
+
+	_, err = bcs.Marshal(&Outer{M: map[string]int64{}})
+	require.ErrorIs(t, err, bcs.ErrNilValue)
+	require.ErrorAs(t, err, &encodeErr)
+	require.Equal(t, "Outer.E", encodeErr.Path)
+	require.Equal(t, int64(2), encodeErr.Offset)
+
+	e := bcs.NewEncoder(io.Discard)
+	e.Encode(&FunkyStruct{})
+	require.ErrorAs(t, e.Err(), &encodeErr)
+	require.Nil(t, encodeErr.Kind)
+	require.Equal(t, "FunkyStruct", encodeErr.Path)
+	require.Equal(t, "encoding *bcs_test.FunkyStruct: *bcs_test.FunkyStruct: custom encoder: test error from FunkyStruct", e.Err().Error())
+}
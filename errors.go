@@ -0,0 +1,179 @@
+package bcs
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Kinds of encoding and decoding failures. Errors returned by Encoder.Err() and Decoder.Err() can be matched
+// against them using errors.Is(). ErrLimitExceeded and ErrNonCanonical are also used as kinds.
+var (
+	// Input ended before value was fully decoded.
+	ErrUnexpectedEOF = errors.New("unexpected end of input")
+	// Encoded bool is neither 0 nor 1.
+	ErrInvalidBool = errors.New("invalid bool value")
+	// Flag of optional value is neither 0 nor 1.
+	ErrInvalidOptionalFlag = errors.New("invalid optional flag value")
+	// Enum variant index is unknown or enum value does not have exactly one variant set.
+	ErrInvalidEnumVariant = errors.New("invalid enum variant")
+	// Bytes are left after decoding of a value.
+	ErrExcessBytes = errors.New("excess bytes")
+	// Value or length does not fit into its encoded type.
+	ErrOverflow = errors.New("value overflow")
+	// Encoded value is nil, but it is not marked as optional.
+	ErrNilValue = errors.New("nil value")
+	// Type cannot be encoded or decoded, e.g. interface, which is not registered as enum.
+	ErrUnsupportedType = errors.New("unsupported type")
+	// Options of a type or tags of a field are invalid or are not applicable to the type.
+	ErrInvalidOptions = errors.New("invalid options")
+)
+
+// DecodeError is returned by Decoder.Err() and all unmarshaling functions.
+// It describes where the first failure happened. The message is the same as of the wrapped error.
+type DecodeError struct {
+	// Kind of failure, e.g. ErrUnexpectedEOF. Nil if failure was not recognized, e.g. error returned by custom decoder.
+	Kind error
+	// Path to the value, which failed to decode, e.g. "Block.Transactions[3].Payload".
+	Path string
+	// Go type of the value, which failed to decode. Nil if failure happened outside of decoding of a value.
+	Type reflect.Type
+	// Count of bytes consumed from the input before the failure was detected.
+	Offset int64
+	Err    error
+}
+
+func (e *DecodeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+func (e *DecodeError) Is(target error) bool {
+	return e.Kind != nil && target == e.Kind
+}
+
+// EncodeError is returned by Encoder.Err() and all marshaling functions.
+// It describes where the first failure happened. The message is the same as of the wrapped error.
+type EncodeError struct {
+	// Kind of failure, e.g. ErrNilValue. Nil if failure was not recognized, e.g. error returned by custom encoder.
+	Kind error
+	// Path to the value, which failed to encode, e.g. "Block.Transactions[3].Payload".
+	Path string
+	// Go type of the value, which failed to encode. Nil if failure happened outside of encoding of a value.
+	Type reflect.Type
+	// Count of bytes written before the failure was detected.
+	Offset int64
+	Err    error
+}
+
+func (e *EncodeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *EncodeError) Unwrap() error {
+	return e.Err
+}
+
+func (e *EncodeError) Is(target error) bool {
+	return e.Kind != nil && target == e.Kind
+}
+
+var errorKinds = []error{
+	ErrUnexpectedEOF,
+	ErrInvalidBool,
+	ErrInvalidOptionalFlag,
+	ErrInvalidEnumVariant,
+	ErrExcessBytes,
+	ErrOverflow,
+	ErrNilValue,
+	ErrUnsupportedType,
+	ErrInvalidOptions,
+	ErrLimitExceeded,
+	ErrNonCanonical,
+}
+
+// Detects kind of error, which was not produced by encoder or decoder itself,
+// e.g. returned by underlying reader or custom decoder.
+func errorKind(err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return ErrUnexpectedEOF
+	}
+
+	for _, kind := range errorKinds {
+		if errors.Is(err, kind) {
+			return kind
+		}
+	}
+
+	return nil
+}
+
+// Element of path to currently encoded or decoded value.
+// If name is empty, the element is an index of collection element.
+type pathElem struct {
+	name string
+	idx  int
+}
+
+type valuePath []pathElem
+
+func (p *valuePath) pushField(name string) {
+	*p = append(*p, pathElem{name: name})
+}
+
+func (p *valuePath) pushIdx(idx int) {
+	*p = append(*p, pathElem{idx: idx})
+}
+
+// Sets index of last path element. Used to avoid push/pop for each element of collection.
+func (p valuePath) setIdx(idx int) {
+	p[len(p)-1].idx = idx
+}
+
+// Sets name of last path element. Used to avoid push/pop for key and value of each map entry.
+func (p valuePath) setField(name string) {
+	p[len(p)-1].name = name
+}
+
+func (p *valuePath) pop() {
+	*p = (*p)[:len(*p)-1]
+}
+
+// Returns string representation of path, e.g. "Struct.Field[3].mapKey".
+func (p valuePath) String() string {
+	var b strings.Builder
+
+	for i, e := range p {
+		if e.name == "" {
+			b.WriteByte('[')
+			b.WriteString(strconv.Itoa(e.idx))
+			b.WriteByte(']')
+			continue
+		}
+
+		if i > 0 {
+			b.WriteByte('.')
+		}
+		b.WriteString(e.name)
+	}
+
+	return b.String()
+}
+
+// Returns name of encoded or decoded type to be used as first element of path.
+func rootTypeName(t reflect.Type) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Name() != "" {
+		return t.Name()
+	}
+
+	return t.String()
+}
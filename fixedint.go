@@ -0,0 +1,705 @@
+package bcs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"math/bits"
+	"strconv"
+)
+
+// U128 is an unsigned 128-bit integer. It is encoded as 16 little-endian bytes.
+// Unlike big.Int it does not allocate and is encoded without range checks.
+// Arithmetic operations wrap around on overflow, same as for built-in unsigned integers.
+type U128 struct {
+	Hi, Lo uint64
+}
+
+// U256 is an unsigned 256-bit integer. It is encoded as 32 little-endian bytes.
+// Arithmetic operations wrap around on overflow, same as for built-in unsigned integers.
+type U256 struct {
+	Hi, Lo U128
+}
+
+// I128 is a signed 128-bit integer in two's complement form. It is encoded as 16 little-endian bytes.
+// Arithmetic operations wrap around on overflow, same as for built-in signed integers.
+type I128 struct {
+	Hi, Lo uint64
+}
+
+func init() {
+	AddCustomEncoder(func(e *Encoder, v U128) error {
+		e.WriteU128(v)
+		return e.err
+	})
+
+	AddCustomDecoder(func(d *Decoder, v *U128) error {
+		*v = d.ReadU128()
+		return d.err
+	})
+
+	AddCustomEncoder(func(e *Encoder, v U256) error {
+		e.WriteU256(v)
+		return e.err
+	})
+
+	AddCustomDecoder(func(d *Decoder, v *U256) error {
+		*v = d.ReadU256()
+		return d.err
+	})
+
+	AddCustomEncoder(func(e *Encoder, v I128) error {
+		e.WriteI128(v)
+		return e.err
+	})
+
+	AddCustomDecoder(func(d *Decoder, v *I128) error {
+		*v = d.ReadI128()
+		return d.err
+	})
+}
+
+func (e *Encoder) WriteU128(v U128) {
+	putU128(e.scratch[:16], v)
+	_, _ = e.Write(e.scratch[:16])
+}
+
+func (e *Encoder) WriteU256(v U256) {
+	putU128(e.scratch[:16], v.Lo)
+	putU128(e.scratch[16:], v.Hi)
+	_, _ = e.Write(e.scratch[:])
+}
+
+func (e *Encoder) WriteI128(v I128) {
+	e.WriteU128(U128(v))
+}
+
+func (d *Decoder) ReadU128() U128 {
+	if _, err := d.Read(d.scratch[:16]); err != nil {
+		return U128{}
+	}
+
+	return getU128(d.scratch[:16])
+}
+
+func (d *Decoder) ReadU256() U256 {
+	if _, err := d.Read(d.scratch[:]); err != nil {
+		return U256{}
+	}
+
+	return U256{Hi: getU128(d.scratch[16:]), Lo: getU128(d.scratch[:16])}
+}
+
+func (d *Decoder) ReadI128() I128 {
+	return I128(d.ReadU128())
+}
+
+func putU128(b []byte, v U128) {
+	binary.LittleEndian.PutUint64(b, v.Lo)
+	binary.LittleEndian.PutUint64(b[8:], v.Hi)
+}
+
+func getU128(b []byte) U128 {
+	return U128{Hi: binary.LittleEndian.Uint64(b[8:]), Lo: binary.LittleEndian.Uint64(b)}
+}
+
+var (
+	maxU256    = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+	minI128Big = new(big.Int).Neg(new(big.Int).Lsh(big.NewInt(1), 127))
+	maxI128Big = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 127), big.NewInt(1))
+	mask64     = new(big.Int).SetUint64(^uint64(0))
+)
+
+// ---------------- U128 ----------------
+
+func U128From64(v uint64) U128 {
+	return U128{Lo: v}
+}
+
+// U128FromBig converts big.Int into U128. Error is returned if value does not fit into 128 bits.
+func U128FromBig(v *big.Int) (U128, error) {
+	if err := checkUint128(v); err != nil {
+		return U128{}, err
+	}
+
+	return u128FromBigUnchecked(v), nil
+}
+
+// U128FromString parses decimal or, if prefixed with "0x", hexadecimal representation of U128.
+func U128FromString(s string) (U128, error) {
+	v, err := parseBigInt(s)
+	if err != nil {
+		return U128{}, err
+	}
+
+	return U128FromBig(v)
+}
+
+func u128FromBigUnchecked(v *big.Int) U128 {
+	lo := new(big.Int).And(v, mask64).Uint64()
+	hi := new(big.Int).Rsh(v, 64).Uint64()
+
+	return U128{Hi: hi, Lo: lo}
+}
+
+func (u U128) BigInt() *big.Int {
+	res := new(big.Int).SetUint64(u.Hi)
+	res.Lsh(res, 64)
+
+	return res.Or(res, new(big.Int).SetUint64(u.Lo))
+}
+
+// IsUint64 reports whether value fits into uint64.
+func (u U128) IsUint64() bool {
+	return u.Hi == 0
+}
+
+func (u U128) IsZero() bool {
+	return u.Hi == 0 && u.Lo == 0
+}
+
+// Cmp returns -1 if u < v, 0 if u == v and +1 if u > v.
+func (u U128) Cmp(v U128) int {
+	switch {
+	case u.Hi < v.Hi:
+		return -1
+	case u.Hi > v.Hi:
+		return 1
+	case u.Lo < v.Lo:
+		return -1
+	case u.Lo > v.Lo:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func (u U128) Add(v U128) U128 {
+	res, _ := u.addCarry(v, 0)
+	return res
+}
+
+func (u U128) addCarry(v U128, carry uint64) (U128, uint64) {
+	lo, carry := bits.Add64(u.Lo, v.Lo, carry)
+	hi, carry := bits.Add64(u.Hi, v.Hi, carry)
+
+	return U128{Hi: hi, Lo: lo}, carry
+}
+
+func (u U128) Sub(v U128) U128 {
+	res, _ := u.subBorrow(v, 0)
+	return res
+}
+
+func (u U128) subBorrow(v U128, borrow uint64) (U128, uint64) {
+	lo, borrow := bits.Sub64(u.Lo, v.Lo, borrow)
+	hi, borrow := bits.Sub64(u.Hi, v.Hi, borrow)
+
+	return U128{Hi: hi, Lo: lo}, borrow
+}
+
+func (u U128) Mul(v U128) U128 {
+	hi, lo := bits.Mul64(u.Lo, v.Lo)
+	hi += u.Hi*v.Lo + u.Lo*v.Hi
+
+	return U128{Hi: hi, Lo: lo}
+}
+
+// Returns full 256-bit product of u and v.
+func (u U128) mulFull(v U128) U256 {
+	// Schoolbook multiplication using 64-bit limbs
+	h00, l00 := bits.Mul64(u.Lo, v.Lo)
+	h01, l01 := bits.Mul64(u.Lo, v.Hi)
+	h10, l10 := bits.Mul64(u.Hi, v.Lo)
+	h11, l11 := bits.Mul64(u.Hi, v.Hi)
+
+	limb1, carry := bits.Add64(h00, l01, 0)
+	limb2, carry := bits.Add64(h01, l11, carry)
+	limb3, _ := bits.Add64(h11, 0, carry)
+
+	limb1, carry2 := bits.Add64(limb1, l10, 0)
+	limb2, carry2 = bits.Add64(limb2, h10, carry2)
+	limb3, _ = bits.Add64(limb3, 0, carry2)
+
+	return U256{Hi: U128{Hi: limb3, Lo: limb2}, Lo: U128{Hi: limb1, Lo: l00}}
+}
+
+// QuoRem returns quotient and remainder of u / v. It panics if v is zero.
+func (u U128) QuoRem(v U128) (q, r U128) {
+	if v.IsZero() {
+		panic("bcs: U128 division by zero")
+	}
+
+	if v.Hi == 0 {
+		// Divisor fits into 64 bits - can use hardware division
+		var rem uint64
+		q.Hi, rem = bits.Div64(0, u.Hi, v.Lo)
+		q.Lo, rem = bits.Div64(rem, u.Lo, v.Lo)
+
+		return q, U128{Lo: rem}
+	}
+
+	// Binary long division
+	for i := u.bitLen() - 1; i >= 0; i-- {
+		// Remainder is less than divisor, but shifting it may overflow 128 bits.
+		// If it does, the shifted remainder is certainly not less than divisor.
+		overflow := r.Hi>>63 != 0
+		r = r.Lsh(1)
+		r.Lo |= u.Rsh(uint(i)).Lo & 1
+		if overflow || r.Cmp(v) >= 0 {
+			r = r.Sub(v)
+			q = q.or(U128From64(1).Lsh(uint(i)))
+		}
+	}
+
+	return q, r
+}
+
+func (u U128) Quo(v U128) U128 {
+	q, _ := u.QuoRem(v)
+	return q
+}
+
+func (u U128) Rem(v U128) U128 {
+	_, r := u.QuoRem(v)
+	return r
+}
+
+func (u U128) Lsh(n uint) U128 {
+	switch {
+	case n >= 128:
+		return U128{}
+	case n >= 64:
+		return U128{Hi: u.Lo << (n - 64)}
+	case n == 0:
+		return u
+	default:
+		return U128{Hi: u.Hi<<n | u.Lo>>(64-n), Lo: u.Lo << n}
+	}
+}
+
+func (u U128) Rsh(n uint) U128 {
+	switch {
+	case n >= 128:
+		return U128{}
+	case n >= 64:
+		return U128{Lo: u.Hi >> (n - 64)}
+	case n == 0:
+		return u
+	default:
+		return U128{Hi: u.Hi >> n, Lo: u.Lo>>n | u.Hi<<(64-n)}
+	}
+}
+
+func (u U128) String() string {
+	if u.Hi == 0 {
+		return strconv.FormatUint(u.Lo, 10)
+	}
+
+	return u.BigInt().String()
+}
+
+func (u U128) MarshalText() ([]byte, error) {
+	return []byte(u.String()), nil
+}
+
+func (u *U128) UnmarshalText(text []byte) (err error) {
+	*u, err = U128FromString(string(text))
+	return err
+}
+
+// MarshalJSON encodes value as a string, because JSON numbers may loose precision.
+func (u U128) MarshalJSON() ([]byte, error) {
+	return strconv.AppendQuote(nil, u.String()), nil
+}
+
+// UnmarshalJSON accepts both string and number.
+func (u *U128) UnmarshalJSON(data []byte) error {
+	return u.UnmarshalText(unquoteJSONNumber(data))
+}
+
+func (U128) BCSSchema() Format {
+	return Format{Kind: FormatU128}
+}
+
+// ---------------- U256 ----------------
+
+func U256From64(v uint64) U256 {
+	return U256{Lo: U128{Lo: v}}
+}
+
+func U256FromU128(v U128) U256 {
+	return U256{Lo: v}
+}
+
+// U256FromBig converts big.Int into U256. Error is returned if value does not fit into 256 bits.
+func U256FromBig(v *big.Int) (U256, error) {
+	if v.Sign() < 0 {
+		return U256{}, fmt.Errorf("%s is negative", v.String())
+	}
+	if v.Cmp(maxU256) > 0 {
+		return U256{}, fmt.Errorf("%s is greater than max Uint256", v.String())
+	}
+
+	lo := u128FromBigUnchecked(new(big.Int).And(v, maxU128Mask))
+	hi := u128FromBigUnchecked(new(big.Int).Rsh(v, 128))
+
+	return U256{Hi: hi, Lo: lo}, nil
+}
+
+var maxU128Mask = new(big.Int).Sub(maxU128, big.NewInt(1))
+
+// U256FromString parses decimal or, if prefixed with "0x", hexadecimal representation of U256.
+func U256FromString(s string) (U256, error) {
+	v, err := parseBigInt(s)
+	if err != nil {
+		return U256{}, err
+	}
+
+	return U256FromBig(v)
+}
+
+func (u U256) BigInt() *big.Int {
+	res := u.Hi.BigInt()
+	res.Lsh(res, 128)
+
+	return res.Or(res, u.Lo.BigInt())
+}
+
+func (u U256) IsZero() bool {
+	return u.Hi.IsZero() && u.Lo.IsZero()
+}
+
+// Cmp returns -1 if u < v, 0 if u == v and +1 if u > v.
+func (u U256) Cmp(v U256) int {
+	if c := u.Hi.Cmp(v.Hi); c != 0 {
+		return c
+	}
+
+	return u.Lo.Cmp(v.Lo)
+}
+
+func (u U256) Add(v U256) U256 {
+	lo, carry := u.Lo.addCarry(v.Lo, 0)
+	hi, _ := u.Hi.addCarry(v.Hi, carry)
+
+	return U256{Hi: hi, Lo: lo}
+}
+
+func (u U256) Sub(v U256) U256 {
+	lo, borrow := u.Lo.subBorrow(v.Lo, 0)
+	hi, _ := u.Hi.subBorrow(v.Hi, borrow)
+
+	return U256{Hi: hi, Lo: lo}
+}
+
+func (u U256) Mul(v U256) U256 {
+	res := u.Lo.mulFull(v.Lo)
+	res.Hi = res.Hi.Add(u.Hi.Mul(v.Lo)).Add(u.Lo.Mul(v.Hi))
+
+	return res
+}
+
+// QuoRem returns quotient and remainder of u / v. It panics if v is zero.
+func (u U256) QuoRem(v U256) (q, r U256) {
+	if v.IsZero() {
+		panic("bcs: U256 division by zero")
+	}
+
+	if u.Cmp(v) < 0 {
+		return U256{}, u
+	}
+
+	// Binary long division
+	for i := u.bitLen() - 1; i >= 0; i-- {
+		overflow := r.Hi.Hi>>63 != 0
+		r = r.Lsh(1)
+		r.Lo.Lo |= u.Rsh(uint(i)).Lo.Lo & 1
+		if overflow || r.Cmp(v) >= 0 {
+			r = r.Sub(v)
+			q = q.Or(U256From64(1).Lsh(uint(i)))
+		}
+	}
+
+	return q, r
+}
+
+func (u U256) Quo(v U256) U256 {
+	q, _ := u.QuoRem(v)
+	return q
+}
+
+func (u U256) Rem(v U256) U256 {
+	_, r := u.QuoRem(v)
+	return r
+}
+
+func (u U256) Lsh(n uint) U256 {
+	switch {
+	case n >= 256:
+		return U256{}
+	case n >= 128:
+		return U256{Hi: u.Lo.Lsh(n - 128)}
+	case n == 0:
+		return u
+	default:
+		return U256{Hi: u.Hi.Lsh(n).or(u.Lo.Rsh(128 - n)), Lo: u.Lo.Lsh(n)}
+	}
+}
+
+func (u U256) Rsh(n uint) U256 {
+	switch {
+	case n >= 256:
+		return U256{}
+	case n >= 128:
+		return U256{Lo: u.Hi.Rsh(n - 128)}
+	case n == 0:
+		return u
+	default:
+		return U256{Hi: u.Hi.Rsh(n), Lo: u.Lo.Rsh(n).or(u.Hi.Lsh(128 - n))}
+	}
+}
+
+func (u U128) or(v U128) U128 {
+	return U128{Hi: u.Hi | v.Hi, Lo: u.Lo | v.Lo}
+}
+
+func (u U128) bitLen() int {
+	if u.Hi != 0 {
+		return 64 + bits.Len64(u.Hi)
+	}
+
+	return bits.Len64(u.Lo)
+}
+
+func (u U256) bitLen() int {
+	if !u.Hi.IsZero() {
+		return 128 + u.Hi.bitLen()
+	}
+
+	return u.Lo.bitLen()
+}
+
+func (u U256) Or(v U256) U256 {
+	return U256{Hi: u.Hi.or(v.Hi), Lo: u.Lo.or(v.Lo)}
+}
+
+func (u U256) String() string {
+	if u.Hi.IsZero() {
+		return u.Lo.String()
+	}
+
+	return u.BigInt().String()
+}
+
+func (u U256) MarshalText() ([]byte, error) {
+	return []byte(u.String()), nil
+}
+
+func (u *U256) UnmarshalText(text []byte) (err error) {
+	*u, err = U256FromString(string(text))
+	return err
+}
+
+// MarshalJSON encodes value as a string, because JSON numbers may loose precision.
+func (u U256) MarshalJSON() ([]byte, error) {
+	return strconv.AppendQuote(nil, u.String()), nil
+}
+
+// UnmarshalJSON accepts both string and number.
+func (u *U256) UnmarshalJSON(data []byte) error {
+	return u.UnmarshalText(unquoteJSONNumber(data))
+}
+
+func (U256) BCSSchema() Format {
+	return Format{Kind: FormatU256}
+}
+
+// ---------------- I128 ----------------
+
+func I128From64(v int64) I128 {
+	return I128{Hi: uint64(v >> 63), Lo: uint64(v)} //nolint:gosec
+}
+
+// I128FromBig converts big.Int into I128. Error is returned if value does not fit into 128 bits.
+func I128FromBig(v *big.Int) (I128, error) {
+	if v.Cmp(minI128Big) < 0 || v.Cmp(maxI128Big) > 0 {
+		return I128{}, fmt.Errorf("%s is out of range of Int128", v.String())
+	}
+
+	if v.Sign() >= 0 {
+		return I128(u128FromBigUnchecked(v)), nil
+	}
+
+	return I128(u128FromBigUnchecked(new(big.Int).Neg(v))).Neg(), nil
+}
+
+// I128FromString parses decimal or, if prefixed with "0x", hexadecimal representation of I128.
+func I128FromString(s string) (I128, error) {
+	v, err := parseBigInt(s)
+	if err != nil {
+		return I128{}, err
+	}
+
+	return I128FromBig(v)
+}
+
+func (i I128) BigInt() *big.Int {
+	if i.Sign() >= 0 {
+		return U128(i).BigInt()
+	}
+
+	return new(big.Int).Neg(U128(i.Neg()).BigInt())
+}
+
+// IsInt64 reports whether value fits into int64.
+func (i I128) IsInt64() bool {
+	return i.Hi == uint64(int64(i.Lo)>>63) //nolint:gosec
+}
+
+func (i I128) IsZero() bool {
+	return i.Hi == 0 && i.Lo == 0
+}
+
+// Sign returns -1 if i < 0, 0 if i == 0 and +1 if i > 0.
+func (i I128) Sign() int {
+	switch {
+	case int64(i.Hi) < 0: //nolint:gosec
+		return -1
+	case i.IsZero():
+		return 0
+	default:
+		return 1
+	}
+}
+
+// Cmp returns -1 if i < j, 0 if i == j and +1 if i > j.
+func (i I128) Cmp(j I128) int {
+	iHi, jHi := int64(i.Hi), int64(j.Hi) //nolint:gosec
+
+	switch {
+	case iHi < jHi:
+		return -1
+	case iHi > jHi:
+		return 1
+	default:
+		return U128{Lo: i.Lo}.Cmp(U128{Lo: j.Lo})
+	}
+}
+
+func (i I128) Neg() I128 {
+	return I128(U128{}.Sub(U128(i)))
+}
+
+func (i I128) Add(j I128) I128 {
+	return I128(U128(i).Add(U128(j)))
+}
+
+func (i I128) Sub(j I128) I128 {
+	return I128(U128(i).Sub(U128(j)))
+}
+
+func (i I128) Mul(j I128) I128 {
+	return I128(U128(i).Mul(U128(j)))
+}
+
+// QuoRem returns quotient and remainder of i / j truncated towards zero, same as for built-in integers.
+// It panics if j is zero.
+func (i I128) QuoRem(j I128) (q, r I128) {
+	q128, r128 := U128(i.abs()).QuoRem(U128(j.abs()))
+	q, r = I128(q128), I128(r128)
+
+	if i.Sign() < 0 != (j.Sign() < 0) {
+		q = q.Neg()
+	}
+	if i.Sign() < 0 {
+		r = r.Neg()
+	}
+
+	return q, r
+}
+
+func (i I128) Quo(j I128) I128 {
+	q, _ := i.QuoRem(j)
+	return q
+}
+
+func (i I128) Rem(j I128) I128 {
+	_, r := i.QuoRem(j)
+	return r
+}
+
+// Returns absolute value. For minimal value of I128 result is same value, which is 2^127 if treated as unsigned.
+func (i I128) abs() I128 {
+	if i.Sign() < 0 {
+		return i.Neg()
+	}
+
+	return i
+}
+
+func (i I128) String() string {
+	if i.IsInt64() {
+		return strconv.FormatInt(int64(i.Lo), 10) //nolint:gosec
+	}
+
+	return i.BigInt().String()
+}
+
+func (i I128) MarshalText() ([]byte, error) {
+	return []byte(i.String()), nil
+}
+
+func (i *I128) UnmarshalText(text []byte) (err error) {
+	*i, err = I128FromString(string(text))
+	return err
+}
+
+// MarshalJSON encodes value as a string, because JSON numbers may loose precision.
+func (i I128) MarshalJSON() ([]byte, error) {
+	return strconv.AppendQuote(nil, i.String()), nil
+}
+
+// UnmarshalJSON accepts both string and number.
+func (i *I128) UnmarshalJSON(data []byte) error {
+	return i.UnmarshalText(unquoteJSONNumber(data))
+}
+
+func (I128) BCSSchema() Format {
+	return Format{Kind: FormatI128}
+}
+
+// ---------------- helpers ----------------
+
+func parseBigInt(s string) (*big.Int, error) {
+	base := 10
+	digits := s
+
+	neg := false
+	if len(digits) > 0 && digits[0] == '-' {
+		neg, digits = true, digits[1:]
+	}
+	if len(digits) > 2 && (digits[:2] == "0x" || digits[:2] == "0X") {
+		base, digits = 16, digits[2:]
+	}
+
+	v, ok := new(big.Int).SetString(digits, base)
+	if !ok || len(digits) == 0 || digits[0] == '+' || digits[0] == '-' {
+		return nil, fmt.Errorf("invalid integer: %q", s)
+	}
+
+	if neg {
+		v.Neg(v)
+	}
+
+	return v, nil
+}
+
+func unquoteJSONNumber(data []byte) []byte {
+	if len(data) >= 2 && data[0] == '"' && data[len(data)-1] == '"' {
+		return data[1 : len(data)-1]
+	}
+
+	return bytes.TrimSpace(data)
+}
@@ -0,0 +1,250 @@
+package bcs_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotaledger/bcs-go"
+)
+
+func TestFixedIntCodec(t *testing.T) {
+	bcs.TestCodecAndBytes(t, bcs.U128{Hi: 0x0102030405060708, Lo: 0x090a0b0c0d0e0f10},
+		[]byte{0x10, 0xf, 0xe, 0xd, 0xc, 0xb, 0xa, 0x9, 0x8, 0x7, 0x6, 0x5, 0x4, 0x3, 0x2, 0x1})
+	bcs.TestCodecAndBytes(t, bcs.U256{Hi: bcs.U128{Hi: 4, Lo: 3}, Lo: bcs.U128{Hi: 2, Lo: 1}},
+		[]byte{1, 0, 0, 0, 0, 0, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0, 3, 0, 0, 0, 0, 0, 0, 0, 4, 0, 0, 0, 0, 0, 0, 0})
+	bcs.TestCodecAndBytes(t, bcs.I128From64(-2),
+		[]byte{0xfe, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff})
+
+	type WithFixedInts struct {
+		A bcs.U128
+		B *bcs.U256
+		C []bcs.I128
+	}
+
+	bcs.TestCodec(t, WithFixedInts{A: bcs.U128From64(1), B: &bcs.U256{}, C: []bcs.I128{bcs.I128From64(-1), bcs.I128From64(1)}})
+
+	// Same bytes as for big.Int
+	bi, _ := new(big.Int).SetString("1770887431076116955186", 10)
+	u, err := bcs.U128FromBig(bi)
+	require.NoError(t, err)
+	require.Equal(t, bcs.MustMarshal(bi), bcs.MustMarshal(&u))
+
+	schema, err := bcs.ReflectSchema[WithFixedInts]()
+	require.NoError(t, err)
+	require.Equal(t, []bcs.NamedFormat{
+		{Name: "A", Format: bcs.Format{Kind: bcs.FormatU128}},
+		{Name: "B", Format: bcs.Format{Kind: bcs.FormatU256}},
+		{Name: "C", Format: bcs.Format{Kind: bcs.FormatSeq, Content: &bcs.Format{Kind: bcs.FormatI128}}},
+	}, schema["WithFixedInts"].Fields)
+}
+
+func TestFixedIntWriteRead(t *testing.T) {
+	e := bcs.NewBytesEncoder()
+	e.WriteU128(bcs.U128{Hi: 1, Lo: 2})
+	e.WriteU256(bcs.U256From64(3))
+	e.WriteI128(bcs.I128From64(-4))
+	require.NoError(t, e.Err())
+
+	d := bcs.NewBytesDecoder(e.Bytes())
+	require.Equal(t, bcs.U128{Hi: 1, Lo: 2}, d.ReadU128())
+	require.Equal(t, bcs.U256From64(3), d.ReadU256())
+	require.Equal(t, bcs.I128From64(-4), d.ReadI128())
+	require.NoError(t, d.Err())
+
+	enc := bcs.NewEncoder(io.Discard)
+	dec := bcs.NewDecoder(bytes.NewReader(make([]byte, 48*101)))
+
+	allocs := testing.AllocsPerRun(100, func() {
+		enc.WriteU128(bcs.U128{Hi: 1, Lo: 2})
+		enc.WriteU256(bcs.U256From64(3))
+		dec.ReadU128()
+		dec.ReadU256()
+	})
+	require.Zero(t, allocs)
+}
+
+var (
+	two128 = new(big.Int).Lsh(big.NewInt(1), 128)
+	two256 = new(big.Int).Lsh(big.NewInt(1), 256)
+)
+
+func randBigInt(r *rand.Rand, bits int) *big.Int {
+	// Mixing small and large values to cover all branches
+	return new(big.Int).Rand(r, new(big.Int).Lsh(big.NewInt(1), uint(1+r.Intn(bits))))
+}
+
+func TestU128Arithmetic(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 1000; i++ {
+		a, b := randBigInt(r, 128), randBigInt(r, 128)
+		ua, ub := toU128(t, a), toU128(t, b)
+
+		require.Equal(t, a.Cmp(b), ua.Cmp(ub))
+		requireBigEqual(t, wrap(new(big.Int).Add(a, b), two128), ua.Add(ub).BigInt())
+		requireBigEqual(t, wrap(new(big.Int).Sub(a, b), two128), ua.Sub(ub).BigInt())
+		requireBigEqual(t, wrap(new(big.Int).Mul(a, b), two128), ua.Mul(ub).BigInt())
+
+		if b.Sign() != 0 {
+			q, rem := ua.QuoRem(ub)
+			requireBigEqual(t, new(big.Int).Quo(a, b), q.BigInt(), "%v / %v", a, b)
+			requireBigEqual(t, new(big.Int).Rem(a, b), rem.BigInt(), "%v %% %v", a, b)
+		}
+
+		n := uint(r.Intn(130))
+		requireBigEqual(t, wrap(new(big.Int).Lsh(a, n), two128), ua.Lsh(n).BigInt())
+		requireBigEqual(t, new(big.Int).Rsh(a, n), ua.Rsh(n).BigInt())
+	}
+
+	require.Panics(t, func() { bcs.U128From64(1).Quo(bcs.U128{}) })
+
+	_, err := bcs.U128FromBig(two128)
+	require.Error(t, err)
+	_, err = bcs.U128FromBig(big.NewInt(-1))
+	require.Error(t, err)
+}
+
+func TestU256Arithmetic(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 1000; i++ {
+		a, b := randBigInt(r, 256), randBigInt(r, 256)
+
+		ua, err := bcs.U256FromBig(a)
+		require.NoError(t, err)
+		ub, err := bcs.U256FromBig(b)
+		require.NoError(t, err)
+
+		requireBigEqual(t, a, ua.BigInt())
+		require.Equal(t, a.Cmp(b), ua.Cmp(ub))
+		requireBigEqual(t, wrap(new(big.Int).Add(a, b), two256), ua.Add(ub).BigInt())
+		requireBigEqual(t, wrap(new(big.Int).Sub(a, b), two256), ua.Sub(ub).BigInt())
+		requireBigEqual(t, wrap(new(big.Int).Mul(a, b), two256), ua.Mul(ub).BigInt())
+
+		if b.Sign() != 0 {
+			q, rem := ua.QuoRem(ub)
+			requireBigEqual(t, new(big.Int).Quo(a, b), q.BigInt(), "%v / %v", a, b)
+			requireBigEqual(t, new(big.Int).Rem(a, b), rem.BigInt(), "%v %% %v", a, b)
+		}
+	}
+
+	_, err := bcs.U256FromBig(two256)
+	require.Error(t, err)
+}
+
+func TestI128Arithmetic(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	half := new(big.Int).Lsh(big.NewInt(1), 127)
+
+	for i := 0; i < 1000; i++ {
+		a, b := randBigInt(r, 127), randBigInt(r, 127)
+		if r.Intn(2) == 0 {
+			a.Neg(a)
+		}
+		if r.Intn(2) == 0 {
+			b.Neg(b)
+		}
+
+		ia, err := bcs.I128FromBig(a)
+		require.NoError(t, err)
+		ib, err := bcs.I128FromBig(b)
+		require.NoError(t, err)
+
+		requireBigEqual(t, a, ia.BigInt())
+		require.Equal(t, a.Sign(), ia.Sign())
+		require.Equal(t, a.Cmp(b), ia.Cmp(ib))
+		requireBigEqual(t, wrapSigned(new(big.Int).Add(a, b), half), ia.Add(ib).BigInt())
+		requireBigEqual(t, wrapSigned(new(big.Int).Sub(a, b), half), ia.Sub(ib).BigInt())
+		requireBigEqual(t, wrapSigned(new(big.Int).Mul(a, b), half), ia.Mul(ib).BigInt())
+
+		if b.Sign() != 0 {
+			q, rem := ia.QuoRem(ib)
+			requireBigEqual(t, new(big.Int).Quo(a, b), q.BigInt(), "%v / %v", a, b)
+			requireBigEqual(t, new(big.Int).Rem(a, b), rem.BigInt(), "%v %% %v", a, b)
+		}
+	}
+
+	minI128, err := bcs.I128FromBig(new(big.Int).Neg(half))
+	require.NoError(t, err)
+	require.Equal(t, bcs.I128{Hi: 1 << 63}, minI128)
+	require.Equal(t, "-170141183460469231731687303715884105728", minI128.String())
+
+	_, err = bcs.I128FromBig(half)
+	require.Error(t, err)
+	_, err = bcs.I128FromBig(new(big.Int).Sub(new(big.Int).Neg(half), big.NewInt(1)))
+	require.Error(t, err)
+}
+
+func TestFixedIntText(t *testing.T) {
+	type Amounts struct {
+		A bcs.U128
+		B bcs.U256
+		C bcs.I128
+	}
+
+	a := Amounts{
+		A: bcs.U128{Hi: 1},
+		B: bcs.U256{Hi: bcs.U128{Hi: 1 << 63}},
+		C: bcs.I128From64(-5),
+	}
+
+	j, err := json.Marshal(a)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"A": "18446744073709551616",
+		"B": "57896044618658097711785492504343953926634992332820282019728792003956564819968",
+		"C": "-5"
+	}`, string(j))
+
+	var decoded Amounts
+	require.NoError(t, json.Unmarshal(j, &decoded))
+	require.Equal(t, a, decoded)
+
+	require.NoError(t, json.Unmarshal([]byte(`{"A": 42, "B": "0xff", "C": -7}`), &decoded))
+	require.Equal(t, Amounts{A: bcs.U128From64(42), B: bcs.U256From64(255), C: bcs.I128From64(-7)}, decoded)
+
+	require.Error(t, json.Unmarshal([]byte(`{"A": "-1"}`), &decoded))
+	require.Error(t, json.Unmarshal([]byte(`{"A": "+1"}`), &decoded))
+	require.Error(t, json.Unmarshal([]byte(`{"A": "abc"}`), &decoded))
+	require.Error(t, json.Unmarshal([]byte(`{"A": ""}`), &decoded))
+
+	u, err := bcs.U128FromString("340282366920938463463374607431768211455")
+	require.NoError(t, err)
+	require.Equal(t, bcs.U128{Hi: ^uint64(0), Lo: ^uint64(0)}, u)
+
+	_, err = bcs.U128FromString("340282366920938463463374607431768211456")
+	require.Error(t, err)
+}
+
+func toU128(t *testing.T, v *big.Int) bcs.U128 {
+	u, err := bcs.U128FromBig(v)
+	require.NoError(t, err)
+	requireBigEqual(t, v, u.BigInt())
+
+	return u
+}
+
+func wrap(v, modulo *big.Int) *big.Int {
+	return v.Mod(v, modulo)
+}
+
+func wrapSigned(v, half *big.Int) *big.Int {
+	modulo := new(big.Int).Lsh(half, 1)
+	v.Mod(v, modulo)
+	if v.Cmp(half) >= 0 {
+		v.Sub(v, modulo)
+	}
+
+	return v
+}
+
+func requireBigEqual(t *testing.T, expected, actual *big.Int, msgAndArgs ...any) {
+	require.Zero(t, expected.Cmp(actual), append([]any{"expected %v, actual %v", expected, actual}, msgAndArgs...)...)
+}
@@ -0,0 +1,109 @@
+package bcs
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/iotaledger/hive.go/constraints"
+)
+
+// RegisterIntEnumType marks integer type EnumType as enum with given values and their names.
+// Such values are encoded as enum variant index (ULEB128) instead of fixed-width integer,
+// which is how unit-only Move/Rust enums are encoded. Decoding fails for values, which are not registered.
+// Values must not be negative.
+//
+// Example:
+//
+//	type Color uint8
+//
+//	const (
+//		Red Color = iota
+//		Green
+//	)
+//
+//	var _ = bcs.RegisterIntEnumType(map[Color]string{Red: "Red", Green: "Green"})
+func RegisterIntEnumType[EnumType constraints.Integer](names map[EnumType]string) struct{} {
+	return RegisterIntEnumTypeIn(DefaultRegistry, names)
+}
+
+// RegisterIntEnumTypeIn registers integer type EnumType as enum in registry r. See RegisterIntEnumType.
+func RegisterIntEnumTypeIn[EnumType constraints.Integer](r *Registry, names map[EnumType]string) struct{} {
+	enumT := reflect.TypeOf((*EnumType)(nil)).Elem()
+	namesByID := make(map[EnumVariantID]string, len(names))
+
+	for v, name := range names {
+		if v < 0 || uint64(v) > math.MaxInt {
+			panic(fmt.Errorf("RegisterIntEnumType: value %v of enum %v cannot be used as variant id", v, enumT))
+		}
+
+		namesByID[EnumVariantID(v)] = name
+	}
+
+	r.RegisterIntEnumType(enumT, namesByID)
+
+	return struct{}{}
+}
+
+// IntEnumName returns name of value of integer enum registered in DefaultRegistry.
+// For unknown values it returns something like "Color(5)". It is useful for implementing String() method.
+func IntEnumName[EnumType constraints.Integer](v EnumType) string {
+	enumT := reflect.TypeOf(v)
+
+	if v >= 0 && uint64(v) <= math.MaxInt {
+		if names, registered := DefaultRegistry.IntEnumVariants(enumT); registered {
+			if name, ok := names[EnumVariantID(v)]; ok {
+				return name
+			}
+		}
+	}
+
+	return fmt.Sprintf("%v(%d)", enumT.Name(), v)
+}
+
+// ParseIntEnum returns value of integer enum registered in DefaultRegistry by its name.
+// It is useful for implementing UnmarshalText() or UnmarshalJSON() methods.
+func ParseIntEnum[EnumType constraints.Integer](name string) (EnumType, error) {
+	enumT := reflect.TypeOf((*EnumType)(nil)).Elem()
+
+	names, registered := DefaultRegistry.IntEnumVariants(enumT)
+	if !registered {
+		return 0, fmt.Errorf("%w: type %v is not registered as integer enum", ErrUnsupportedType, enumT)
+	}
+
+	for id, variantName := range names {
+		if variantName == name {
+			return EnumType(id), nil
+		}
+	}
+
+	return 0, fmt.Errorf("%w: unknown name %q of enum %v, expected one of: %v", ErrInvalidEnumVariant, name, enumT, intEnumVariantsString(names))
+}
+
+// Returns list of variants sorted by ids for error messages.
+func intEnumVariantsString(names map[EnumVariantID]string) string {
+	ids := make([]EnumVariantID, 0, len(names))
+	for id := range names {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	variants := make([]string, len(ids))
+	for i, id := range ids {
+		variants[i] = fmt.Sprintf("%v (%v)", names[id], id)
+	}
+
+	return strings.Join(variants, ", ")
+}
+
+func isIntKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,124 @@
+package bcs_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotaledger/bcs-go"
+)
+
+type IntEnumColor uint16
+
+const (
+	IntEnumRed IntEnumColor = iota
+	IntEnumGreen
+	IntEnumBlue IntEnumColor = 200
+)
+
+var _ = bcs.RegisterIntEnumType(map[IntEnumColor]string{IntEnumRed: "Red", IntEnumGreen: "Green", IntEnumBlue: "Blue"})
+
+func (c IntEnumColor) String() string {
+	return bcs.IntEnumName(c)
+}
+
+type IntEnumSigned int64
+
+var _ = bcs.RegisterIntEnumType(map[IntEnumSigned]string{0: "Zero", 3: "Three"})
+
+type IntEnumStruct struct {
+	Color  IntEnumColor
+	Colors []IntEnumColor
+	Opt    *IntEnumSigned `bcs:"optional"`
+}
+
+func TestIntEnumCodec(t *testing.T) {
+	bcs.TestCodecAndBytes(t, IntEnumRed, []byte{0x0})
+	bcs.TestCodecAndBytes(t, IntEnumGreen, []byte{0x1})
+	bcs.TestCodecAndBytes(t, IntEnumBlue, []byte{0xc8, 0x1})
+	bcs.TestCodecAndBytes(t, IntEnumSigned(3), []byte{0x3})
+
+	signed := IntEnumSigned(3)
+	bcs.TestCodecAndBytes(t, IntEnumStruct{
+		Color:  IntEnumBlue,
+		Colors: []IntEnumColor{IntEnumGreen, IntEnumRed},
+		Opt:    &signed,
+	}, []byte{0xc8, 0x1, 0x2, 0x1, 0x0, 0x1, 0x3})
+}
+
+func TestIntEnumInvalidValues(t *testing.T) {
+	_, err := bcs.Marshal(lo.ToPtr(IntEnumColor(5)))
+	require.ErrorIs(t, err, bcs.ErrInvalidEnumVariant)
+	require.ErrorContains(t, err, "value 5 is not a variant of enum bcs_test.IntEnumColor, expected one of: Red (0), Green (1), Blue (200)")
+
+	bcs.TestEncodeErr(t, IntEnumSigned(-3), "is not a variant of enum")
+
+	_, err = bcs.Unmarshal[IntEnumColor]([]byte{0x2})
+	require.ErrorIs(t, err, bcs.ErrInvalidEnumVariant)
+	require.ErrorContains(t, err, "invalid variant index 2 for enum bcs_test.IntEnumColor, expected one of: Red (0), Green (1), Blue (200)")
+
+	_, err = bcs.Unmarshal[IntEnumColor]([]byte{0xc8})
+	require.ErrorIs(t, err, bcs.ErrUnexpectedEOF)
+}
+
+func TestIntEnumNames(t *testing.T) {
+	require.Equal(t, "Blue", IntEnumBlue.String())
+	require.Equal(t, "IntEnumColor(7)", IntEnumColor(7).String())
+	require.Equal(t, "Three", bcs.IntEnumName(IntEnumSigned(3)))
+	require.Equal(t, "IntEnumSigned(-1)", bcs.IntEnumName(IntEnumSigned(-1)))
+
+	c, err := bcs.ParseIntEnum[IntEnumColor]("Blue")
+	require.NoError(t, err)
+	require.Equal(t, IntEnumBlue, c)
+
+	_, err = bcs.ParseIntEnum[IntEnumColor]("Black")
+	require.ErrorIs(t, err, bcs.ErrInvalidEnumVariant)
+
+	_, err = bcs.ParseIntEnum[uint8]("Black")
+	require.ErrorIs(t, err, bcs.ErrUnsupportedType)
+}
+
+func TestIntEnumRegistration(t *testing.T) {
+	type Local uint8
+
+	require.Panics(t, func() {
+		bcs.RegisterIntEnumType(map[IntEnumColor]string{IntEnumRed: "Red"})
+	})
+	require.Panics(t, func() {
+		bcs.RegisterIntEnumType(map[IntEnumSigned]string{-1: "Negative"})
+	})
+	require.Panics(t, func() {
+		bcs.RegisterIntEnumType(map[Local]string{0: "A", 1: "A"})
+	})
+	require.Panics(t, func() {
+		bcs.RegisterIntEnumType(map[Local]string{0: ""})
+	})
+
+	// Local type is not registered in default registry, so it is encoded as plain integer
+	r := bcs.NewRegistry()
+	bcs.RegisterIntEnumTypeIn(r, map[Local]string{200: "Big"})
+
+	require.Equal(t, []byte{0xc8, 0x1}, encodeWithRegistry(t, r, Local(200)))
+	require.Equal(t, Local(200), decodeWithRegistry[Local](t, r, []byte{0xc8, 0x1}))
+	require.Equal(t, []byte{0xc8}, bcs.MustMarshal(lo.ToPtr(Local(200))))
+
+	r.RemoveEnumType(reflect.TypeOf(Local(0)))
+	require.Equal(t, []byte{0xc8}, encodeWithRegistry(t, r, Local(200)))
+}
+
+func TestIntEnumSchema(t *testing.T) {
+	schema, err := bcs.ReflectSchema[IntEnumStruct]()
+	require.NoError(t, err)
+
+	require.Equal(t, bcs.ContainerFormat{
+		Kind: bcs.ContainerEnum,
+		Variants: map[bcs.EnumVariantID]bcs.VariantFormat{
+			0:   {Name: "Red"},
+			1:   {Name: "Green"},
+			200: {Name: "Blue"},
+		},
+	}, schema["IntEnumColor"])
+	require.Equal(t, bcs.FormatTypeName, schema["IntEnumStruct"].Fields[0].Format.Kind)
+}
@@ -1,89 +1,48 @@
 package bcs
 
 import (
-	"fmt"
 	"reflect"
 )
 
 type EnumVariantID = int
 
+// EnumTypes contains enums registered in DefaultRegistry.
+//
+// Deprecated: direct modification is not thread-safe. Use RegisterEnumType or Registry instead.
 var EnumTypes = make(map[reflect.Type]map[EnumVariantID]reflect.Type)
 
-// NOTE: Registration is not thread-safe for now as it is assumed that all types are registered upon initialization.
 func RegisterEnumTypeVariant[EnumType any](id EnumVariantID, newVariant any) struct{} {
-	enumT := reflect.TypeOf((*EnumType)(nil)).Elem()
-	newVariantT := reflect.TypeOf(newVariant)
-
-	if id < 0 {
-		panic(fmt.Errorf("RegisterEnumType: attempt to register variant type %v of enum %v with negative id %v", newVariantT, enumT, id))
-	}
-
-	if newVariantT.Kind() == reflect.Interface {
-		panic(fmt.Errorf("RegisterEnumType: variant type %v of enum %v is an interface", newVariantT, enumT))
-	}
-
-	if !newVariantT.Implements(enumT) && newVariantT != noneT {
-		panic(fmt.Errorf("RegisterEnumType: variant type %v does not implement enum %v", newVariantT, enumT))
-	}
-
-	registeredVariants, enumRegistered := EnumTypes[enumT]
-
-	if !enumRegistered {
-		panic(fmt.Errorf("RegisterEnumTypeVariant: enum type %v is not registered", enumT))
-	}
-
-	for existingID, registeredVariant := range registeredVariants {
-		if newVariantT == registeredVariant {
-			panic(fmt.Errorf("RegisterEnumType: variant type %v of enum %v is already registered under id %v instead of %v",
-				newVariantT, enumT, existingID, id))
-		}
-	}
-
-	registeredVariants[id] = newVariantT
-
 	// Returnign something just for a convenience of using this function in a single line in global scope like:
 	// var _ = RegisterEnumTypeVariant[EnumType](id, newVariant)
-	return struct{}{}
+	return RegisterEnumTypeVariantIn[EnumType](DefaultRegistry, id, newVariant)
 }
 
 func RegisterEnumTypeWithIDs[EnumType any](variants map[EnumVariantID]any) struct{} {
-	enumT := reflect.TypeOf((*EnumType)(nil)).Elem()
-
-	if enumT.Kind() != reflect.Interface {
-		panic(fmt.Errorf("RegisterEnumType: enum type %v is not an interface", enumT))
-	}
-
-	alreadyRegisteredVariants := EnumTypes[enumT]
-	if alreadyRegisteredVariants != nil {
-		panic(fmt.Errorf("RegisterEnumType: enum type %v is already registered with variants %v", enumT, alreadyRegisteredVariants))
-	}
-
-	registeredSuccessufly := false
-	EnumTypes[enumT] = make(map[EnumVariantID]reflect.Type, len(variants))
-
-	defer func() {
-		if !registeredSuccessufly {
-			delete(EnumTypes, enumT)
-		}
-	}()
+	return RegisterEnumTypeWithIDsIn[EnumType](DefaultRegistry, variants)
+}
 
-	for id, v := range variants {
-		RegisterEnumTypeVariant[EnumType](id, v)
-	}
+// UnknownEnumVariant keeps value of interface enum, which has variant id not known to the decoder.
+// It allows to decode data produced by a newer version of the schema and to encode it back unchanged.
+// To use it, register it (or a type defined as UnknownEnumVariant) using RegisterEnumTypeUnknownVariant.
+//
+// BCS does not encode size of variant, so Payload consists of all remaining bytes of the input.
+// Thus this is useful only when the enum value is the last in the encoded data or when it is wrapped into "bytearr".
+// If anything is decoded after such value, decoding fails with ErrInvalidEnumVariant.
+type UnknownEnumVariant struct {
+	ID      EnumVariantID
+	Payload []byte
+}
 
-	registeredSuccessufly = true
+var unknownEnumVariantT = reflect.TypeOf(UnknownEnumVariant{})
 
-	return struct{}{}
+// RegisterEnumTypeUnknownVariant sets type, which keeps values of EnumType with unregistered variant ids instead of failing.
+// EnumType must be already registered.
+func RegisterEnumTypeUnknownVariant[EnumType any](variant any) struct{} {
+	return RegisterEnumTypeUnknownVariantIn[EnumType](DefaultRegistry, variant)
 }
 
 func RegisterEnumType[EnumType any](variants ...any) struct{} {
-	variantsMap := make(map[EnumVariantID]any, len(variants))
-
-	for i, v := range variants {
-		variantsMap[i] = v
-	}
-
-	return RegisterEnumTypeWithIDs[EnumType](variantsMap)
+	return RegisterEnumTypeIn[EnumType](DefaultRegistry, variants...)
 }
 
 func RegisterEnumType1[EnumType any, Variant1 any]() struct{} {
@@ -2,6 +2,7 @@ package bcs_test
 
 import (
 	"bytes"
+	"reflect"
 	"testing"
 
 	"github.com/samber/lo"
@@ -256,3 +257,88 @@ func TestInfEnumNone(t *testing.T) {
 	type WithInf structWithField[any]
 	bcs.TestEncodeErr(t, WithInf{A: nil})
 }
+
+func TestInterfaceEnumSparseIDs(t *testing.T) {
+	t.Cleanup(func() { maps.Clear(bcs.EnumTypes) })
+
+	bcs.RegisterEnumTypeWithIDs[InfEnumWithMethods](map[bcs.EnumVariantID]any{
+		0: EnumVariant1{},
+		5: EnumVariant2{},
+	})
+
+	bcs.TestCodecAndBytes(t, lo.ToPtr[InfEnumWithMethods](EnumVariant1{A: 42}), []byte{0x0, 0x2a, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0})
+	bcs.TestCodecAndBytes(t, lo.ToPtr[InfEnumWithMethods](EnumVariant2{A: "bar"}), []byte{0x5, 0x3, 0x62, 0x61, 0x72})
+
+	for _, id := range []byte{1, 2, 4, 6, 100} {
+		_, err := bcs.Unmarshal[InfEnumWithMethods]([]byte{id, 0x3, 0x62, 0x61, 0x72})
+		require.ErrorIs(t, err, bcs.ErrInvalidEnumVariant)
+		require.ErrorContains(t, err, "invalid variant index")
+	}
+}
+
+type UnknownInfEnumVariant bcs.UnknownEnumVariant
+
+func (UnknownInfEnumVariant) Dummy() {}
+
+func TestInterfaceEnumUnknownVariant(t *testing.T) {
+	t.Cleanup(func() { bcs.DefaultRegistry.RemoveEnumType(reflect.TypeOf((*InfEnumWithMethods)(nil)).Elem()) })
+
+	require.Panics(t, func() {
+		bcs.RegisterEnumTypeUnknownVariant[InfEnumWithMethods](UnknownInfEnumVariant{})
+	})
+
+	bcs.RegisterEnumType2[InfEnumWithMethods, EnumVariant1, EnumVariant2]()
+
+	require.Panics(t, func() {
+		bcs.RegisterEnumTypeUnknownVariant[InfEnumWithMethods](EnumVariant1{})
+	})
+	require.Panics(t, func() {
+		// Does not implement the enum interface
+		bcs.RegisterEnumTypeUnknownVariant[InfEnumWithMethods](bcs.UnknownEnumVariant{})
+	})
+
+	bcs.RegisterEnumTypeUnknownVariant[InfEnumWithMethods](UnknownInfEnumVariant{})
+
+	require.Panics(t, func() {
+		bcs.RegisterEnumTypeUnknownVariant[InfEnumWithMethods](UnknownInfEnumVariant{})
+	})
+
+	// Payload of unknown variant is the rest of input
+	bcs.TestCodecAndBytes(t, lo.ToPtr[InfEnumWithMethods](UnknownInfEnumVariant{ID: 7, Payload: []byte{1, 2, 3}}), []byte{0x7, 0x1, 0x2, 0x3})
+
+	// So values, which follow the enum, could be decoded only if enum is wrapped into bytearr
+	type WithUnknownVariant struct {
+		A InfEnumWithMethods `bcs:"bytearr"`
+		B int8
+	}
+
+	bcs.TestCodecAndBytes(t, WithUnknownVariant{A: UnknownInfEnumVariant{ID: 7, Payload: []byte{1, 2}}, B: 5},
+		[]byte{0x3, 0x7, 0x1, 0x2, 0x5})
+
+	// Otherwise reading of values, which follow the enum, fails, because the payload took their bytes
+	type WithUnknownVariantNotLast struct {
+		A InfEnumWithMethods
+		B int8
+	}
+
+	_, err := bcs.Unmarshal[WithUnknownVariantNotLast]([]byte{0x7, 0x1, 0x2, 0x5})
+	require.ErrorIs(t, err, bcs.ErrInvalidEnumVariant)
+	require.Contains(t, err.Error(), "unknown enum variant at WithUnknownVariantNotLast.A took all remaining bytes")
+
+	skipDec := bcs.NewBytesDecoder([]byte{0x7, 0x1, 0x2, 0x5})
+	bcs.Skip[WithUnknownVariantNotLast](&skipDec.Decoder)
+	require.ErrorIs(t, skipDec.Err(), bcs.ErrInvalidEnumVariant)
+
+	// Known variants are not affected
+	bcs.TestCodecAndBytes(t, lo.ToPtr[InfEnumWithMethods](EnumVariant2{A: "bar"}), []byte{0x1, 0x3, 0x62, 0x61, 0x72})
+
+	// Unknown variant cannot have id of known variant
+	bcs.TestEncodeErr(t, lo.ToPtr[InfEnumWithMethods](UnknownInfEnumVariant{ID: 1}), "registered")
+
+	d := bcs.NewDecoderWithOpts(bytes.NewReader([]byte{0x7, 0x1, 0x2, 0x3}), bcs.DecoderConfig{
+		Limits: bcs.DecoderLimits{MaxByteLen: 2},
+	})
+	var v InfEnumWithMethods
+	d.Decode(&v)
+	require.ErrorIs(t, d.Err(), bcs.ErrLimitExceeded)
+}
@@ -0,0 +1,94 @@
+package bcs
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// DecoderLimits restricts the resources, which decoder is allowed to use while decoding a payload.
+// This is useful when decoding untrusted data: otherwise a corrupted or malicious payload may declare
+// a collection of billions of elements or a deeply nested value and make decoder to waste memory or stack.
+//
+// Zero value of any limit means that limit is not applied.
+type DecoderLimits struct {
+	// Maximal count of elements in a slice or map. Byte slices are limited by MaxByteLen instead.
+	MaxCollectionLen int
+
+	// Maximal length in bytes of a string, a byte slice or a value marked as "bytearr".
+	MaxByteLen int
+
+	// Maximal nesting depth of decoded values. Each struct, collection, pointer or enum variant adds one level.
+	MaxDepth int
+
+	// Maximal total count of bytes, which decoder is allowed to allocate for decoded collections and strings
+	// of a single top-level value. The budget is reset by each call of Decode, which is not nested into decoding
+	// of another value (e.g. from custom decoder), so a stream of values could be decoded by the same decoder.
+	// This is an estimation based on declared lengths of collections and sizes of their elements.
+	MaxAllocBytes int
+}
+
+// ErrLimitExceeded is matched by any of *LimitError using errors.Is().
+var ErrLimitExceeded = errors.New("decoder limit exceeded")
+
+// LimitError is returned when decoded payload exceeds one of the limits set in DecoderLimits.
+// Its message does not include the path, because it is already reported by DecodeError wrapping it.
+type LimitError struct {
+	// Name of the exceeded limit, e.g. "MaxCollectionLen".
+	Limit string
+	// Path to the value, which exceeded the limit, e.g. "Block.Transactions[3].Payload".
+	Path string
+	// Actual value, which exceeded the limit.
+	Value int
+	// Configured value of the limit.
+	Max int
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%v exceeded: %v > %v", e.Limit, e.Value, e.Max)
+}
+
+func (e *LimitError) Is(target error) bool {
+	return target == ErrLimitExceeded
+}
+
+func (d *Decoder) limitErrorf(name string, value, limit int) error {
+	return d.handleErrorf("%w", &LimitError{Limit: name, Path: d.path.String(), Value: value, Max: limit})
+}
+
+func (d *Decoder) checkCollectionLen(length int) error {
+	if limit := d.cfg.Limits.MaxCollectionLen; limit > 0 && length > limit {
+		return d.limitErrorf("MaxCollectionLen", length, limit)
+	}
+
+	return nil
+}
+
+func (d *Decoder) checkByteLen(length int) error {
+	if limit := d.cfg.Limits.MaxByteLen; limit > 0 && length > limit {
+		return d.limitErrorf("MaxByteLen", length, limit)
+	}
+
+	return nil
+}
+
+// Accounts allocation of count elements of given size against allocations budget.
+func (d *Decoder) chargeAlloc(count, elemSize int) error {
+	limit := d.cfg.Limits.MaxAllocBytes
+	if limit <= 0 || elemSize == 0 {
+		return nil
+	}
+
+	if left := limit - d.allocated; count > left/elemSize {
+		requested := math.MaxInt
+		if count <= (math.MaxInt-d.allocated)/elemSize {
+			requested = d.allocated + count*elemSize
+		}
+
+		return d.limitErrorf("MaxAllocBytes", requested, limit)
+	}
+
+	d.allocated += count * elemSize
+
+	return nil
+}
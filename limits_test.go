@@ -0,0 +1,152 @@
+package bcs_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotaledger/bcs-go"
+)
+
+type LimitsInner struct {
+	Items []int32
+	Names map[string]string
+}
+
+type LimitsOuter struct {
+	ID    uint8
+	Inner []LimitsInner
+	Blob  []byte
+	Raw   int64 `bcs:"bytearr"`
+}
+
+type LimitsRecursive struct {
+	Next *LimitsRecursive `bcs:"optional"`
+}
+
+func decodeWithLimits[V any](b []byte, limits bcs.DecoderLimits) (V, error) {
+	var v V
+	d := bcs.NewDecoderWithOpts(bcs.NewBytesDecoder(b), bcs.DecoderConfig{Limits: limits})
+	d.Decode(&v)
+
+	return v, d.Err()
+}
+
+func requireLimitErr(t *testing.T, err error, limit, path string, value, max int) {
+	require.Error(t, err)
+	require.True(t, errors.Is(err, bcs.ErrLimitExceeded), err.Error())
+
+	var limitErr *bcs.LimitError
+	require.True(t, errors.As(err, &limitErr), err.Error())
+	require.Equal(t, limit, limitErr.Limit)
+	require.Equal(t, path, limitErr.Path)
+	require.Equal(t, value, limitErr.Value)
+	require.Equal(t, max, limitErr.Max)
+	// Path is reported once by DecodeError, not repeated by LimitError
+	require.Equal(t, fmt.Sprintf("%v exceeded: %v > %v", limit, value, max), limitErr.Error())
+}
+
+func TestDecoderLimits(t *testing.T) {
+	v := LimitsOuter{
+		ID: 1,
+		Inner: []LimitsInner{
+			{Items: []int32{1, 2}, Names: map[string]string{"a": "b"}},
+			{Items: []int32{1, 2, 3}, Names: map[string]string{"c": "d", "e": "fffff"}},
+		},
+		Blob: []byte{1, 2, 3, 4, 5, 6},
+		Raw:  10,
+	}
+	vEnc := bcs.MustMarshal(&v)
+
+	// No limits
+	vDec, err := decodeWithLimits[LimitsOuter](vEnc, bcs.DecoderLimits{})
+	require.NoError(t, err)
+	require.Equal(t, v, vDec)
+
+	// Limits, which are not exceeded
+	vDec, err = decodeWithLimits[LimitsOuter](vEnc, bcs.DecoderLimits{
+		MaxCollectionLen: 3,
+		MaxByteLen:       8,
+		MaxDepth:         6,
+		MaxAllocBytes:    1000,
+	})
+	require.NoError(t, err)
+	require.Equal(t, v, vDec)
+
+	_, err = decodeWithLimits[LimitsOuter](vEnc, bcs.DecoderLimits{MaxCollectionLen: 2})
+	requireLimitErr(t, err, "MaxCollectionLen", "LimitsOuter.Inner[1].Items", 3, 2)
+
+	_, err = decodeWithLimits[LimitsOuter](vEnc, bcs.DecoderLimits{MaxByteLen: 4})
+	requireLimitErr(t, err, "MaxByteLen", "LimitsOuter.Inner[1].Names[1].mapValue", 5, 4)
+
+	_, err = decodeWithLimits[LimitsOuter](vEnc, bcs.DecoderLimits{MaxByteLen: 5})
+	requireLimitErr(t, err, "MaxByteLen", "LimitsOuter.Blob", 6, 5)
+
+	_, err = decodeWithLimits[LimitsOuter](vEnc, bcs.DecoderLimits{MaxDepth: 4})
+	requireLimitErr(t, err, "MaxDepth", "LimitsOuter.Inner[0].Items[0]", 5, 4)
+
+	_, err = decodeWithLimits[LimitsOuter](vEnc, bcs.DecoderLimits{MaxAllocBytes: 50})
+	require.Error(t, err)
+	require.True(t, errors.Is(err, bcs.ErrLimitExceeded), err.Error())
+}
+
+func TestDecoderLimitsByteArr(t *testing.T) {
+	v := LimitsOuter{Raw: 10}
+	vEnc := bcs.MustMarshal(&v)
+
+	_, err := decodeWithLimits[LimitsOuter](vEnc, bcs.DecoderLimits{MaxByteLen: 7})
+	requireLimitErr(t, err, "MaxByteLen", "LimitsOuter.Raw", 8, 7)
+}
+
+func TestDecoderLimitsMalformedLength(t *testing.T) {
+	e := bcs.NewBytesEncoder()
+
+	const elemsCount100Billions = 100_000_000_000
+	e.WriteLen(elemsCount100Billions)
+	e.WriteInt64(1)
+
+	_, err := decodeWithLimits[[]int64](e.Bytes(), bcs.DecoderLimits{MaxCollectionLen: 1000})
+	requireLimitErr(t, err, "MaxCollectionLen", "[]int64", elemsCount100Billions, 1000)
+
+	_, err = decodeWithLimits[[]int64](e.Bytes(), bcs.DecoderLimits{MaxAllocBytes: 1 << 20})
+	requireLimitErr(t, err, "MaxAllocBytes", "[]int64", elemsCount100Billions*8, 1<<20)
+
+	_, err = decodeWithLimits[string](e.Bytes(), bcs.DecoderLimits{MaxByteLen: 1000})
+	requireLimitErr(t, err, "MaxByteLen", "string", elemsCount100Billions, 1000)
+
+	_, err = decodeWithLimits[map[int64]int64](e.Bytes(), bcs.DecoderLimits{MaxCollectionLen: 1000})
+	requireLimitErr(t, err, "MaxCollectionLen", "map[int64]int64", elemsCount100Billions, 1000)
+}
+
+func TestDecoderLimitsDepth(t *testing.T) {
+	v := LimitsRecursive{Next: &LimitsRecursive{Next: &LimitsRecursive{}}}
+	vEnc := bcs.MustMarshal(&v)
+
+	vDec, err := decodeWithLimits[LimitsRecursive](vEnc, bcs.DecoderLimits{MaxDepth: 3})
+	require.NoError(t, err)
+	require.Equal(t, v, vDec)
+
+	_, err = decodeWithLimits[LimitsRecursive](vEnc, bcs.DecoderLimits{MaxDepth: 2})
+	requireLimitErr(t, err, "MaxDepth", "LimitsRecursive.Next.Next", 3, 2)
+}
+
+func TestDecoderLimitsAllocBudgetPerValue(t *testing.T) {
+	vEnc := bcs.MustMarshal(&[]string{"aaaa", "bbbb", "cccc"})
+	bigEnc := bcs.MustMarshal(&[]string{"aaaa", "bbbb", "cccc", "dddd", "eeee"})
+
+	stream := append(append(append([]byte{}, vEnc...), vEnc...), bigEnc...)
+	d := bcs.NewDecoderWithOpts(bcs.NewBytesDecoder(stream), bcs.DecoderConfig{
+		Limits: bcs.DecoderLimits{MaxAllocBytes: 3*16 + 12 + 20},
+	})
+
+	// Each top-level value has its own budget, so stream of values within budget is decoded
+	for i := 0; i < 2; i++ {
+		require.Equal(t, []string{"aaaa", "bbbb", "cccc"}, bcs.Decode[[]string](d))
+		require.NoError(t, d.Err())
+	}
+
+	bcs.Decode[[]string](d)
+	require.True(t, errors.Is(d.Err(), bcs.ErrLimitExceeded))
+}
@@ -0,0 +1,5 @@
+//go:build !race
+
+package bcs_test
+
+const raceEnabled = false
@@ -16,7 +16,13 @@ type TypeOptions struct {
 	// TODO: Is this really useful? The engineer can just change type of int to indicate its size.
 	UnderlyingType reflect.Kind
 
-	IsCompactInt         bool
+	// Width of big.Int value. By default big.Int is encoded as u128.
+	BigIntEncoding BigIntEncoding
+
+	IsCompactInt bool
+	// Compact signed integer is ZigZag-encoded, so that small negative values are also short.
+	// Only applicable together with IsCompactInt.
+	IsCompactZigZag      bool
 	InterfaceIsNotEnum   bool
 	ExportAnonymousField bool
 	NilIfEmpty           bool
@@ -56,9 +62,15 @@ func (o *TypeOptions) Update(other TypeOptions) {
 	if other.UnderlyingType != reflect.Invalid {
 		o.UnderlyingType = other.UnderlyingType
 	}
+	if other.BigIntEncoding != BigIntDefault {
+		o.BigIntEncoding = other.BigIntEncoding
+	}
 	if other.IsCompactInt {
 		o.IsCompactInt = true
 	}
+	if other.IsCompactZigZag {
+		o.IsCompactZigZag = true
+	}
 	if other.InterfaceIsNotEnum {
 		o.InterfaceIsNotEnum = true
 	}
@@ -72,21 +84,28 @@ func (o *TypeOptions) Update(other TypeOptions) {
 		if o.ArrayElement == nil {
 			o.ArrayElement = other.ArrayElement
 		} else {
-			o.ArrayElement.Update(*other.ArrayElement)
+			// Nested options may be shared (e.g. cached options of type), so they are copied instead of modifying.
+			merged := *o.ArrayElement
+			merged.Update(*other.ArrayElement)
+			o.ArrayElement = &merged
 		}
 	}
 	if other.MapKey != nil {
 		if o.MapKey == nil {
 			o.MapKey = other.MapKey
 		} else {
-			o.MapKey.Update(*other.MapKey)
+			merged := *o.MapKey
+			merged.Update(*other.MapKey)
+			o.MapKey = &merged
 		}
 	}
 	if other.MapValue != nil {
 		if o.MapValue == nil {
 			o.MapValue = other.MapValue
 		} else {
-			o.MapValue.Update(*other.MapValue)
+			merged := *o.MapValue
+			merged.Update(*other.MapValue)
+			o.MapValue = &merged
 		}
 	}
 }
@@ -110,6 +129,9 @@ type FieldOptions struct {
 	// OmitEmpty bool
 	// ByteOrder    binary.ByteOrder
 	AsByteArray bool
+	// Variant ID of struct enum field. By default it is the index of the field.
+	VariantID    EnumVariantID
+	HasVariantID bool
 }
 
 func (o *FieldOptions) Validate() error {
@@ -123,6 +145,7 @@ func (o *FieldOptions) Validate() error {
 func FieldOptionsFromStruct(structType reflect.Type, tagName string) (_ []FieldOptions, hasTag []bool, err error) {
 	fieldOpts := make([]FieldOptions, structType.NumField())
 	hasTag = make([]bool, structType.NumField())
+	isEnum := structType.Implements(structEnumT)
 
 	for i := 0; i < structType.NumField(); i++ {
 		fieldType := structType.Field(i)
@@ -131,6 +154,10 @@ func FieldOptionsFromStruct(structType reflect.Type, tagName string) (_ []FieldO
 		if err != nil {
 			return nil, nil, fmt.Errorf("field %v: %w", fieldType.Name, err)
 		}
+
+		if fieldOpts[i].HasVariantID && !isEnum {
+			return nil, nil, fmt.Errorf("field %v: variant tag is applicable only to fields of struct enum", fieldType.Name)
+		}
 	}
 
 	return fieldOpts, hasTag, nil
@@ -208,6 +235,14 @@ func FieldOptionsFromTag(a string) (_ FieldOptions, _ error) {
 
 		switch key {
 		case "compact":
+			switch val {
+			case "":
+			case "zigzag":
+				opts.IsCompactZigZag = true
+			default:
+				return FieldOptions{}, fmt.Errorf("invalid compact tag: %s", val)
+			}
+
 			opts.IsCompactInt = true
 		case "type":
 			var err error
@@ -215,6 +250,12 @@ func FieldOptionsFromTag(a string) (_ FieldOptions, _ error) {
 			if err != nil {
 				return FieldOptions{}, fmt.Errorf("invalid undelaying type tag: %s", val)
 			}
+		case "bigint":
+			var err error
+			opts.BigIntEncoding, err = BigIntEncodingFromString(val)
+			if err != nil {
+				return FieldOptions{}, fmt.Errorf("invalid bigint tag: %s", val)
+			}
 		case "len_bytes":
 			bytes, err := strconv.Atoi(val)
 			if err != nil {
@@ -222,6 +263,14 @@ func FieldOptionsFromTag(a string) (_ FieldOptions, _ error) {
 			}
 
 			opts.LenSizeInBytes = LenBytesCount(bytes) //nolint:gosec
+		case "variant":
+			id, err := strconv.Atoi(val)
+			if err != nil || id < 0 {
+				return FieldOptions{}, fmt.Errorf("invalid variant tag: %s", val)
+			}
+
+			opts.VariantID = id
+			opts.HasVariantID = true
 		case "optional":
 			opts.Optional = true
 		case "nil_if_empty":
@@ -281,8 +330,64 @@ func UnderlayingTypeFromString(s string) (reflect.Kind, error) {
 	}
 }
 
+// BigIntEncoding defines how big.Int value is encoded.
+type BigIntEncoding uint8
+
+const (
+	BigIntDefault BigIntEncoding = iota
+	BigIntU64
+	BigIntU128
+	BigIntU256
+	BigIntI128
+)
+
+func BigIntEncodingFromString(s string) (BigIntEncoding, error) {
+	switch s {
+	case "u64":
+		return BigIntU64, nil
+	case "u128":
+		return BigIntU128, nil
+	case "u256":
+		return BigIntU256, nil
+	case "i128":
+		return BigIntI128, nil
+	default:
+		return 0, fmt.Errorf("invalid bigint encoding: %s", s)
+	}
+}
+
+func (b BigIntEncoding) String() string {
+	switch b {
+	case BigIntDefault:
+		return "default"
+	case BigIntU64:
+		return "u64"
+	case BigIntU128:
+		return "u128"
+	case BigIntU256:
+		return "u256"
+	case BigIntI128:
+		return "i128"
+	default:
+		return fmt.Sprintf("BigIntEncoding(%d)", uint8(b))
+	}
+}
+
+// BCSType is implemented by types, which customize their encoding, e.g. to be encoded as compact integers.
+// Options must be same for all values of the type: BCSOptions() is called only once per type on its zero value
+// (or on pointer to zero value, if method has pointer receiver) and the result is cached.
 type BCSType interface {
 	BCSOptions() TypeOptions
 }
 
 var bcsTypeT = reflect.TypeOf((*BCSType)(nil)).Elem()
+
+// Returns options of type t, which implements BCSType.
+// Options are expected to be same for all values of type, so they are taken from zero value.
+func typeOptionsOf(t reflect.Type) TypeOptions {
+	if t.Kind() == reflect.Ptr {
+		return reflect.New(t.Elem()).Interface().(BCSType).BCSOptions()
+	}
+
+	return reflect.Zero(t).Interface().(BCSType).BCSOptions()
+}
@@ -0,0 +1,66 @@
+package bcs_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotaledger/bcs-go"
+)
+
+type CompactNarrowUint struct {
+	A uint8 `bcs:"compact"`
+}
+
+type CompactNarrowInt struct {
+	A int8 `bcs:"compact"`
+}
+
+type CompactNarrowZigZag struct {
+	A int16 `bcs:"compact=zigzag"`
+}
+
+func TestCompactIntOverflow(t *testing.T) {
+	bcs.TestCodecAndBytes(t, CompactNarrowUint{A: math.MaxUint8}, []byte{0xff, 0x1})
+	bcs.TestCodecAndBytes(t, CompactNarrowInt{A: math.MinInt8}, []byte{0x80, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x1})
+	bcs.TestCodecAndBytes(t, CompactNarrowInt{A: math.MaxInt8}, []byte{0x7f})
+	bcs.TestCodecAndBytes(t, CompactNarrowZigZag{A: math.MinInt16}, []byte{0xff, 0xff, 0x3})
+
+	bcs.TestDecodeErr[CompactNarrowUint](t, CompactUint{A: 300}, "value 300 is out of range of type uint8")
+	bcs.TestDecodeErr[CompactNarrowInt](t, CompactInt{A: math.MaxInt8 + 1}, "value 128 is out of range of type int8")
+	bcs.TestDecodeErr[CompactNarrowInt](t, CompactInt{A: math.MinInt8 - 1}, "value -129 is out of range of type int8")
+	bcs.TestDecodeErr[CompactNarrowZigZag](t, CompactZigZagInt{A: math.MaxInt16 + 1}, "value 32768 is out of range of type int16")
+
+	_, err := bcs.Unmarshal[CompactNarrowUint](bcs.MustMarshal(&CompactUint{A: 256}))
+	require.ErrorIs(t, err, bcs.ErrOverflow)
+}
+
+// ULEB128 of 2^63, which does not fit into int.
+var ulebExceedingInt = []byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x1}
+
+func TestEnumIdxOverflow(t *testing.T) {
+	_, err := bcs.Unmarshal[BasicStructEnum](ulebExceedingInt)
+	require.ErrorIs(t, err, bcs.ErrOverflow)
+	require.ErrorContains(t, err, "enum variant index 9223372036854775808 is out of range of type int")
+
+	d := bcs.NewBytesDecoder(ulebExceedingInt)
+	require.Zero(t, d.ReadEnumIdx())
+	require.ErrorIs(t, d.Err(), bcs.ErrOverflow)
+}
+
+func TestLenOverflow(t *testing.T) {
+	_, err := bcs.Unmarshal[[]byte](ulebExceedingInt)
+	require.ErrorIs(t, err, bcs.ErrOverflow)
+	require.ErrorContains(t, err, "length 9223372036854775808 is out of range of type int")
+
+	_, err = bcs.Unmarshal[[]uint32](ulebExceedingInt)
+	require.ErrorIs(t, err, bcs.ErrOverflow)
+
+	_, err = bcs.Unmarshal[map[uint8]uint8](ulebExceedingInt)
+	require.ErrorIs(t, err, bcs.ErrOverflow)
+
+	d := bcs.NewBytesDecoder(ulebExceedingInt)
+	require.Zero(t, d.ReadLen())
+	require.ErrorIs(t, d.Err(), bcs.ErrOverflow)
+}
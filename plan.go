@@ -0,0 +1,161 @@
+package bcs
+
+import (
+	"fmt"
+	"reflect"
+	"unsafe"
+)
+
+// fieldPlan is a compiled step of encoding or decoding of a struct field.
+// Plans of struct fields are built once, when type info is collected, and then cached as part of it.
+// So checks of fields and their options are not repeated for each encoded or decoded value.
+type fieldPlan struct {
+	Index int
+	Name  string
+	Type  reflect.Type
+	Opts  *FieldOptions
+
+	// Field is unexported, but marked for export. Such fields are accessed by their offset.
+	IsUnexported bool
+	Offset       uintptr
+	// Field is of pointer, interface, map or slice type.
+	IsNullable bool
+
+	// Info of type of the field and options of that type merged with options of the field.
+	// Both are nil if info was not known when plan was compiled, then they are collected when the field is reached.
+	Info     *typeInfo
+	TypeOpts *TypeOptions
+
+	// Error in definition of the field. It is reported only when the field is reached, so preceding fields
+	// are processed same way as without plan.
+	Err string
+
+	// Fast paths for fields of basic types without options and customizations. Nil if not applicable.
+	Encode func(e *Encoder, v reflect.Value)
+	Decode func(d *Decoder, v reflect.Value)
+}
+
+// Builds plans of fields of struct t. Fields, which are not encoded, are omitted.
+// Function typeInfoOf returns info of type of field or nil, if it is not known (see Encoder.fieldTypeInfo).
+func compileStructPlan(t reflect.Type, fieldOpts []FieldOptions, fieldHasTag []bool, typeInfoOf func(t reflect.Type) *typeInfo) []fieldPlan {
+	plan := make([]fieldPlan, 0, t.NumField())
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		opts := &fieldOpts[i]
+
+		if opts.Skip {
+			continue
+		}
+
+		f := fieldPlan{
+			Index:  i,
+			Name:   field.Name,
+			Type:   field.Type,
+			Opts:   opts,
+			Offset: field.Offset,
+		}
+
+		if !field.IsExported() {
+			if !opts.ExportAnonymousField {
+				if !fieldHasTag[i] {
+					// Unexported fields are skipped by default if not explicitly marked as exported
+					continue
+				}
+
+				f.Err = fmt.Sprintf("%v: unexported field %v has BCS tag, but is not marked for export", t.Name(), field.Name)
+			}
+
+			f.IsUnexported = true
+		} else if opts.ExportAnonymousField {
+			f.Err = fmt.Sprintf("%v: field %v is already exported, but is marked for export", t.Name(), field.Name)
+		}
+
+		switch field.Type.Kind() {
+		case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+			f.IsNullable = true
+		}
+
+		if f.Info = typeInfoOf(field.Type); f.Info != nil {
+			var typeOpts TypeOptions
+			if f.Info.HasTypeOptions {
+				typeOpts = f.Info.TypeOptions
+			}
+			typeOpts.Update(opts.TypeOptions)
+			f.TypeOpts = &typeOpts
+
+			if enc, dec := basicFieldCodec(field.Type.Kind()); enc != nil && f.Err == "" && !opts.Optional && !opts.AsByteArray &&
+				hasDefaultEncoding(&opts.TypeOptions) && f.Info.RefLevelsCount == 0 && !f.Info.HasCustomizations() {
+				f.Encode, f.Decode = enc, dec
+			}
+		}
+
+		plan = append(plan, f)
+	}
+
+	return plan
+}
+
+// Returns encoder and decoder of value of basic kind. Returns nil for other kinds.
+func basicFieldCodec(k reflect.Kind) (func(e *Encoder, v reflect.Value), func(d *Decoder, v reflect.Value)) {
+	switch k {
+	case reflect.Bool:
+		return func(e *Encoder, v reflect.Value) { e.WriteBool(v.Bool()) },
+			func(d *Decoder, v reflect.Value) { v.SetBool(d.ReadBool()) }
+	case reflect.Int8:
+		return func(e *Encoder, v reflect.Value) { e.WriteInt8(int8(v.Int())) }, //nolint:gosec
+			func(d *Decoder, v reflect.Value) { v.SetInt(int64(d.ReadInt8())) }
+	case reflect.Int16:
+		return func(e *Encoder, v reflect.Value) { e.WriteInt16(int16(v.Int())) }, //nolint:gosec
+			func(d *Decoder, v reflect.Value) { v.SetInt(int64(d.ReadInt16())) }
+	case reflect.Int32:
+		return func(e *Encoder, v reflect.Value) { e.WriteInt32(int32(v.Int())) }, //nolint:gosec
+			func(d *Decoder, v reflect.Value) { v.SetInt(int64(d.ReadInt32())) }
+	case reflect.Int64:
+		return func(e *Encoder, v reflect.Value) { e.WriteInt64(v.Int()) },
+			func(d *Decoder, v reflect.Value) { v.SetInt(d.ReadInt64()) }
+	case reflect.Uint8:
+		return func(e *Encoder, v reflect.Value) { e.WriteUint8(uint8(v.Uint())) }, //nolint:gosec
+			func(d *Decoder, v reflect.Value) { v.SetUint(uint64(d.ReadUint8())) }
+	case reflect.Uint16:
+		return func(e *Encoder, v reflect.Value) { e.WriteUint16(uint16(v.Uint())) }, //nolint:gosec
+			func(d *Decoder, v reflect.Value) { v.SetUint(uint64(d.ReadUint16())) }
+	case reflect.Uint32:
+		return func(e *Encoder, v reflect.Value) { e.WriteUint32(uint32(v.Uint())) }, //nolint:gosec
+			func(d *Decoder, v reflect.Value) { v.SetUint(uint64(d.ReadUint32())) }
+	case reflect.Uint64:
+		return func(e *Encoder, v reflect.Value) { e.WriteUint64(v.Uint()) },
+			func(d *Decoder, v reflect.Value) { v.SetUint(d.ReadUint64()) }
+	case reflect.String:
+		return func(e *Encoder, v reflect.Value) { e.WriteString(v.String()) },
+			func(d *Decoder, v reflect.Value) { v.SetString(d.ReadString()) }
+	default:
+		// Int and Uint are not included, because decoding of them requires overflow checks on 32-bit platforms.
+		return nil, nil
+	}
+}
+
+// Reports whether type t is struct, which plan is being compiled, or pointer to it.
+// Info of such types is not complete yet, so it is not used by plans of their fields.
+func isCompilingStruct(compiling []reflect.Type, t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	for _, c := range compiling {
+		if c == t {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Returns value of the field of struct v.
+func (f *fieldPlan) value(v reflect.Value) reflect.Value {
+	if f.IsUnexported {
+		return reflect.NewAt(f.Type, unsafe.Add(v.Addr().UnsafePointer(), f.Offset)).Elem()
+	}
+
+	return v.Field(f.Index)
+}
@@ -0,0 +1,124 @@
+package bcs_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotaledger/bcs-go"
+)
+
+type PlanShortElems []int64
+
+func (PlanShortElems) BCSOptions() bcs.TypeOptions {
+	return bcs.TypeOptions{ArrayElement: &bcs.ArrayElemOptions{TypeOptions: bcs.TypeOptions{UnderlyingType: reflect.Int16}}}
+}
+
+type PlanWithSharedOpts struct {
+	A PlanShortElems `bcs_elem:"compact"`
+	B PlanShortElems
+}
+
+func TestPlanSharedTypeOptions(t *testing.T) {
+	v := PlanWithSharedOpts{A: PlanShortElems{1, 2}, B: PlanShortElems{3}}
+	enc := []byte{0x2, 0x1, 0x2, 0x1, 0x3, 0x0}
+
+	// Options of field A are merged with options of type, but must not modify options of type, used by field B.
+	bcs.TestCodecAndBytes(t, v, enc)
+	bcs.TestCodecAndBytes(t, v, enc)
+}
+
+type PlanPrimitives struct {
+	B   bool
+	I8  int8
+	I16 int16
+	I32 int32
+	I64 int64
+	U8  uint8
+	U16 uint16
+	U32 uint32
+	U64 uint64
+	S   string
+	c   uint32 `bcs:"export"`
+}
+
+func TestPlanPrimitives(t *testing.T) {
+	bcs.TestCodec(t, PlanPrimitives{B: true, I8: -1, I16: -2, I32: -3, I64: -4, U8: 1, U16: 2, U32: 3, U64: 4, S: "abc", c: 5})
+	bcs.TestCodec(t, &PlanPrimitives{S: "abc", c: 5})
+}
+
+type PlanRecursive struct {
+	A     uint16
+	Next  *PlanRecursive `bcs:"optional"`
+	Items []PlanRecursiveItem
+}
+
+type PlanRecursiveItem struct {
+	Parent *PlanRecursive `bcs:"optional"`
+	B      string
+}
+
+func TestPlanRecursiveTypes(t *testing.T) {
+	v := PlanRecursive{
+		A:     1,
+		Next:  &PlanRecursive{A: 2, Items: []PlanRecursiveItem{}},
+		Items: []PlanRecursiveItem{{Parent: &PlanRecursive{A: 3, Items: []PlanRecursiveItem{}}, B: "a"}, {B: "b"}},
+	}
+
+	bcs.TestCodecAndBytes(t, v, []byte{
+		0x1, 0x0,
+		0x1, 0x2, 0x0, 0x0, 0x0,
+		0x2, 0x1, 0x3, 0x0, 0x0, 0x0, 0x1, 'a', 0x0, 0x1, 'b',
+	})
+	bcs.TestCodec(t, PlanRecursiveItem{Parent: &v, B: "c"})
+}
+
+type PlanWithInvalidNested struct {
+	A uint16
+	E StructEnumWithDuplicateIDs
+}
+
+func TestPlanInvalidNestedType(t *testing.T) {
+	// Error in definition of nested type is reported when the field is reached, not when plan is compiled.
+	_, err := bcs.Marshal(&PlanWithInvalidNested{A: 1, E: StructEnumWithDuplicateIDs{B: lo.ToPtr("aaa")}})
+	require.ErrorIs(t, err, bcs.ErrInvalidOptions)
+
+	var encodeErr *bcs.EncodeError
+	require.ErrorAs(t, err, &encodeErr)
+	require.Equal(t, "PlanWithInvalidNested.E", encodeErr.Path)
+	require.Equal(t, int64(2), encodeErr.Offset)
+
+	_, err = bcs.Unmarshal[PlanWithInvalidNested]([]byte{0x1, 0x0, 0x1, 0x0})
+	require.ErrorIs(t, err, bcs.ErrInvalidOptions)
+
+	var decodeErr *bcs.DecodeError
+	require.ErrorAs(t, err, &decodeErr)
+	require.Equal(t, "PlanWithInvalidNested.E", decodeErr.Path)
+	require.Equal(t, int64(2), decodeErr.Offset)
+}
+
+func BenchmarkEncodeStructs(b *testing.B) {
+	r := newSkipRegistry()
+
+	b.Run("BasicStruct", func(b *testing.B) { benchmarkEncodeIn(b, r, BasicStruct{A: 42, B: "aaa"}) })
+	b.Run("NestedStruct", func(b *testing.B) { benchmarkEncodeIn(b, r, NestedStruct{A: 42, B: BasicStruct{A: 43, B: "aaa"}}) })
+	b.Run("WithUnexported", func(b *testing.B) { benchmarkEncodeIn(b, r, WithUnexported{A: 42, b: 43, c: 44, D: 45}) })
+	b.Run("WithBCSOpts", func(b *testing.B) { benchmarkEncodeIn(b, r, WithBCSOpts{A: 42}) })
+	b.Run("PlanPrimitives", func(b *testing.B) { benchmarkEncodeIn(b, r, PlanPrimitives{S: "abc"}) })
+	b.Run("ShortReadSample", func(b *testing.B) { benchmarkEncodeIn(b, r, shortReadSample()) })
+	b.Run("SkipComplex", func(b *testing.B) { benchmarkEncodeIn(b, r, newSkipComplex()) })
+}
+
+func BenchmarkDecodeStructs(b *testing.B) {
+	r := newSkipRegistry()
+
+	b.Run("BasicStruct", func(b *testing.B) { benchmarkDecodeIn(b, r, BasicStruct{A: 42, B: "aaa"}) })
+	b.Run("NestedStruct", func(b *testing.B) { benchmarkDecodeIn(b, r, NestedStruct{A: 42, B: BasicStruct{A: 43, B: "aaa"}}) })
+	b.Run("WithUnexported", func(b *testing.B) { benchmarkDecodeIn(b, r, WithUnexported{A: 42, b: 43, c: 44, D: 45}) })
+	b.Run("WithBCSOpts", func(b *testing.B) { benchmarkDecodeIn(b, r, WithBCSOpts{A: 42}) })
+	b.Run("PlanPrimitives", func(b *testing.B) { benchmarkDecodeIn(b, r, PlanPrimitives{S: "abc"}) })
+	b.Run("ShortReadSample", func(b *testing.B) { benchmarkDecodeIn(b, r, shortReadSample()) })
+	b.Run("SkipComplex", func(b *testing.B) { benchmarkDecodeIn(b, r, newSkipComplex()) })
+}
@@ -0,0 +1,6 @@
+//go:build race
+
+package bcs_test
+
+// Race detector makes sync.Pool drop items randomly, so pooled objects are allocated again.
+const raceEnabled = true
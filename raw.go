@@ -0,0 +1,91 @@
+package bcs
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Raw keeps encoded bytes of a value, like json.RawMessage does. It allows to decode an envelope and
+// to forward its payload unchanged or to decode it later.
+//
+// Raw is written as is, without length prefix. BCS does not encode size of values, so when decoding, Raw consumes
+// all remaining bytes of the input. Thus it is useful when it is the last value of the input or when it is framed
+// using "bytearr" tag - then it receives exactly the bytes of the value.
+// To capture value of known type anywhere in the input, use RawOf. For values described by schema, see dynamic.DecodeRaw.
+type Raw []byte
+
+func (r *Raw) MarshalBCS(e *Encoder) error {
+	_, _ = e.Write(*r)
+	return nil
+}
+
+func (r *Raw) UnmarshalBCS(d *Decoder) error {
+	b, err := d.readRest()
+	if err != nil {
+		return err
+	}
+
+	*r = b
+
+	return nil
+}
+
+// RawOf keeps encoded bytes of a value of type T. When decoding, its bytes are found by skipping value of type T
+// (see Decoder.SkipType), so unlike Raw it may be placed anywhere. The value itself could be decoded later using Decode()
+// or DecodeWithOpts(). When encoding, bytes are written as is.
+type RawOf[T any] []byte
+
+// NewRawOf encodes v and returns its bytes.
+func NewRawOf[T any](v T) (RawOf[T], error) {
+	return Marshal(&v)
+}
+
+// Decode decodes value kept in r using default config.
+func (r RawOf[T]) Decode() (T, error) {
+	return r.DecodeWithOpts(DecoderConfig{})
+}
+
+// DecodeWithOpts decodes value kept in r using decoder with given config. If r was captured by decoder
+// with custom registry, same registry must be used here to decode values with custom decoders registered in it.
+func (r RawOf[T]) DecodeWithOpts(cfg DecoderConfig) (T, error) {
+	var zero T
+
+	d := NewBytesDecoderWithOpts(r, cfg)
+	v := Decode[T](&d.Decoder)
+	if err := d.Err(); err != nil {
+		return zero, err
+	}
+
+	if d.Len() > 0 {
+		t := reflect.TypeOf((*T)(nil)).Elem()
+
+		return zero, &DecodeError{
+			Kind:   ErrExcessBytes,
+			Path:   rootTypeName(t),
+			Type:   t,
+			Offset: int64(d.Pos()),
+			Err:    fmt.Errorf("excess bytes: %v", d.Len()),
+		}
+	}
+
+	return v, nil
+}
+
+func (r *RawOf[T]) MarshalBCS(e *Encoder) error {
+	_, _ = e.Write(*r)
+	return nil
+}
+
+func (r *RawOf[T]) UnmarshalBCS(d *Decoder) error {
+	b, err := d.CaptureBytes(func() error {
+		Skip[T](d)
+		return d.err
+	})
+	if err != nil {
+		return err
+	}
+
+	*r = b
+
+	return nil
+}
@@ -0,0 +1,109 @@
+package bcs_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotaledger/bcs-go"
+)
+
+type RawPayload struct {
+	A int16
+	B string
+}
+
+type RawEnvelope struct {
+	Kind    uint8
+	Payload bcs.Raw `bcs:"bytearr"`
+	Trailer uint16
+}
+
+type RawTail struct {
+	Kind uint8
+	Rest bcs.Raw
+}
+
+type RawOfEnvelope struct {
+	Kind    uint8
+	Payload bcs.RawOf[RawPayload]
+	Items   []bcs.RawOf[RawPayload]
+	Trailer uint16
+}
+
+func TestRaw(t *testing.T) {
+	payload := bcs.MustMarshal(&RawPayload{A: 1, B: "ab"})
+	require.Equal(t, []byte{0x1, 0x0, 0x2, 'a', 'b'}, payload)
+
+	bcs.TestCodecAndBytes(t, RawEnvelope{Kind: 5, Payload: payload, Trailer: 7},
+		[]byte{0x5, 0x5, 0x1, 0x0, 0x2, 'a', 'b', 0x7, 0x0})
+
+	// Without framing Raw takes all remaining bytes
+	bcs.TestCodecAndBytes(t, RawTail{Kind: 5, Rest: payload}, []byte{0x5, 0x1, 0x0, 0x2, 'a', 'b'})
+	bcs.TestCodecAndBytes(t, RawTail{Kind: 5, Rest: bcs.Raw{}}, []byte{0x5})
+
+	// Raw is written as is, so it does not matter how its bytes were produced
+	env, err := bcs.Unmarshal[RawEnvelope]([]byte{0x5, 0x5, 0x1, 0x0, 0x2, 'a', 'b', 0x7, 0x0})
+	require.NoError(t, err)
+	require.Equal(t, RawPayload{A: 1, B: "ab"}, bcs.MustUnmarshal[RawPayload](env.Payload))
+}
+
+func TestRawOf(t *testing.T) {
+	payload, err := bcs.NewRawOf(RawPayload{A: 1, B: "ab"})
+	require.NoError(t, err)
+	item, err := bcs.NewRawOf(RawPayload{A: 2})
+	require.NoError(t, err)
+
+	env := RawOfEnvelope{Kind: 5, Payload: payload, Items: []bcs.RawOf[RawPayload]{item, payload}, Trailer: 7}
+	bcs.TestCodecAndBytes(t, env, []byte{
+		0x5,
+		0x1, 0x0, 0x2, 'a', 'b',
+		0x2, 0x2, 0x0, 0x0, 0x1, 0x0, 0x2, 'a', 'b',
+		0x7, 0x0,
+	})
+
+	decoded := bcs.MustUnmarshal[RawOfEnvelope](bcs.MustMarshal(&env))
+	v, err := decoded.Items[0].Decode()
+	require.NoError(t, err)
+	require.Equal(t, RawPayload{A: 2}, v)
+
+	_, err = bcs.Unmarshal[RawOfEnvelope]([]byte{0x5, 0x1, 0x0, 0x2, 'a'})
+	require.ErrorIs(t, err, bcs.ErrUnexpectedEOF)
+
+	_, err = bcs.RawOf[RawPayload]{0x1}.Decode()
+	require.Error(t, err)
+}
+
+type RawCustomPayload uint16
+
+type RawOfCustomEnvelope struct {
+	Payload bcs.RawOf[RawCustomPayload]
+	Trailer uint16
+}
+
+func TestRawOfWithRegistry(t *testing.T) {
+	r := bcs.NewRegistry()
+	bcs.AddCustomEncoderTo(r, func(e *bcs.Encoder, v RawCustomPayload) error {
+		e.WriteUint32(uint32(v))
+		return nil
+	})
+	bcs.AddCustomDecoderTo(r, func(d *bcs.Decoder, v *RawCustomPayload) error {
+		*v = RawCustomPayload(d.ReadUint32())
+		return nil
+	})
+
+	// Bounds of captured bytes are found using custom decoder from registry of decoder.
+	d := bcs.NewBytesDecoderWithOpts([]byte{0x5, 0x0, 0x0, 0x0, 0x7, 0x0}, bcs.DecoderConfig{Registry: r})
+	env := bcs.Decode[RawOfCustomEnvelope](&d.Decoder)
+	require.NoError(t, d.Err())
+	require.Equal(t, bcs.RawOf[RawCustomPayload]{0x5, 0x0, 0x0, 0x0}, env.Payload)
+	require.Equal(t, uint16(7), env.Trailer)
+
+	v, err := env.Payload.DecodeWithOpts(bcs.DecoderConfig{Registry: r})
+	require.NoError(t, err)
+	require.Equal(t, RawCustomPayload(5), v)
+
+	// Custom decoder is not registered in default registry.
+	_, err = env.Payload.Decode()
+	require.ErrorIs(t, err, bcs.ErrExcessBytes)
+}
@@ -35,7 +35,7 @@ func TestReadN(t *testing.T) {
 	testReadN(t, maxReadNBufferSize*3, maxReadNBufferSize*3)
 
 	const ramSize1000GB = 1000 * 1024 * 1024 * 1024
-	testReadN(t, maxReadNBufferSize*3, ramSize1000GB, io.EOF)
+	testReadN(t, maxReadNBufferSize*3, ramSize1000GB, io.ErrUnexpectedEOF)
 }
 
 func testReadN(t *testing.T, dataSize, bytesToRead int, expectedErr ...error) {
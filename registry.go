@@ -0,0 +1,478 @@
+package bcs
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// Registry holds custom encoders, custom decoders and enum registrations, which are used by encoders and decoders
+// configured with it. Each registry also has its own cache of type information.
+//
+// Registry inherits registrations of its parent: lookups are done in the registry itself first and then in its ancestors.
+// So a registry may override codecs of its parent without affecting other users of the parent. This allows to isolate
+// registrations of tests or plugins and to have different codecs for the same third-party type in one binary.
+//
+// Registry is safe for concurrent use.
+type Registry struct {
+	parent *Registry
+
+	mu       sync.RWMutex
+	encoders map[reflect.Type]CustomEncoder
+	decoders map[reflect.Type]CustomDecoder
+	enums    map[reflect.Type]map[EnumVariantID]reflect.Type
+	// Enum type -> type, which keeps variants with unregistered ids.
+	unknownVariants map[reflect.Type]reflect.Type
+	// Enum type -> variant id -> name. Only for enums registered with names.
+	enumNames map[reflect.Type]map[EnumVariantID]string
+	// Integer enum type -> value -> name
+	intEnums map[reflect.Type]map[EnumVariantID]string
+	// Incremented on each change of registrations. Used to invalidate cached type information.
+	version atomic.Uint64
+
+	encoderTypeInfoCache *sharedTypeInfoCache
+	decoderTypeInfoCache *sharedTypeInfoCache
+}
+
+// DefaultRegistry is used by encoders and decoders, for which registry is not specified in config.
+// Package-level functions like AddCustomEncoder and RegisterEnumType register into it.
+var DefaultRegistry = newRegistry(nil, CustomEncoders, CustomDecoders, EnumTypes)
+
+// NewRegistry creates an empty registry, which inherits registrations of DefaultRegistry.
+func NewRegistry() *Registry {
+	return DefaultRegistry.NewChild()
+}
+
+// NewChild creates an empty registry, which inherits registrations of r.
+func (r *Registry) NewChild() *Registry {
+	return newRegistry(r, nil, nil, nil)
+}
+
+func newRegistry(
+	parent *Registry,
+	encoders map[reflect.Type]CustomEncoder,
+	decoders map[reflect.Type]CustomDecoder,
+	enums map[reflect.Type]map[EnumVariantID]reflect.Type,
+) *Registry {
+	if encoders == nil {
+		encoders = make(map[reflect.Type]CustomEncoder)
+	}
+	if decoders == nil {
+		decoders = make(map[reflect.Type]CustomDecoder)
+	}
+	if enums == nil {
+		enums = make(map[reflect.Type]map[EnumVariantID]reflect.Type)
+	}
+
+	r := &Registry{
+		parent:          parent,
+		encoders:        encoders,
+		decoders:        decoders,
+		enums:           enums,
+		unknownVariants: make(map[reflect.Type]reflect.Type),
+		enumNames:       make(map[reflect.Type]map[EnumVariantID]string),
+		intEnums:        make(map[reflect.Type]map[EnumVariantID]string),
+	}
+
+	r.encoderTypeInfoCache = newSharedTypeInfoCache(r.Version)
+	r.decoderTypeInfoCache = newSharedTypeInfoCache(r.Version)
+
+	return r
+}
+
+// Parent returns registry, from which r inherits registrations. It is nil for DefaultRegistry.
+func (r *Registry) Parent() *Registry {
+	return r.parent
+}
+
+// Version returns a number, which changes each time registrations of r or of its ancestors change.
+func (r *Registry) Version() uint64 {
+	// Versions only grow, so their sum changes whenever any of them changes.
+	var version uint64
+	for ; r != nil; r = r.parent {
+		version += r.version.Load()
+	}
+
+	return version
+}
+
+// AddCustomEncoder registers encoder for values of type t. It panics if encoder for t is already registered in r.
+// Encoders registered in ancestors of r are overridden.
+func (r *Registry) AddCustomEncoder(t reflect.Type, enc CustomEncoder) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.encoders[t] != nil {
+		panic(fmt.Errorf("custom encoder for type %v is already registered", t))
+	}
+
+	r.encoders[t] = enc
+	r.version.Add(1)
+}
+
+// RemoveCustomEncoder removes encoder for type t from r. Encoders registered in ancestors of r are not affected.
+func (r *Registry) RemoveCustomEncoder(t reflect.Type) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.encoders, t)
+	r.version.Add(1)
+}
+
+// HasCustomEncoder reports whether encoder for values of type t is registered in r or in its ancestors.
+func (r *Registry) HasCustomEncoder(t reflect.Type) bool {
+	return r.customEncoder(t) != nil
+}
+
+// AddCustomDecoder registers decoder for values of type t. It panics if decoder for t is already registered in r.
+// Decoders registered in ancestors of r are overridden.
+func (r *Registry) AddCustomDecoder(t reflect.Type, dec CustomDecoder) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.decoders[t] != nil {
+		panic(fmt.Errorf("custom decoder for type %v is already registered", t))
+	}
+
+	r.decoders[t] = dec
+	r.version.Add(1)
+}
+
+// RemoveCustomDecoder removes decoder for type t from r. Decoders registered in ancestors of r are not affected.
+func (r *Registry) RemoveCustomDecoder(t reflect.Type) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.decoders, t)
+	r.version.Add(1)
+}
+
+// RegisterEnumType registers interface type enumT as enum with given variants.
+// It panics if enum is already registered in r or if variants are invalid.
+// Enum registered in ancestors of r is overridden.
+func (r *Registry) RegisterEnumType(enumT reflect.Type, variants map[EnumVariantID]reflect.Type) {
+	r.registerEnumType(enumT, variants, nil, false)
+}
+
+// Registers enum with optional names of variants.
+// If sameIsAllowed is true, repeated registration of exactly same enum is ignored instead of panicking.
+func (r *Registry) registerEnumType(enumT reflect.Type, variants map[EnumVariantID]reflect.Type, names map[EnumVariantID]string, sameIsAllowed bool) {
+	if enumT.Kind() != reflect.Interface {
+		panic(fmt.Errorf("RegisterEnumType: enum type %v is not an interface", enumT))
+	}
+
+	registered := make(map[EnumVariantID]reflect.Type, len(variants))
+
+	for id, variantT := range variants {
+		checkEnumVariant(enumT, id, variantT, registered)
+		registered[id] = variantT
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if alreadyRegisteredVariants := r.enums[enumT]; alreadyRegisteredVariants != nil {
+		if sameIsAllowed && maps.Equal(alreadyRegisteredVariants, registered) && maps.Equal(r.enumNames[enumT], names) {
+			return
+		}
+
+		panic(fmt.Errorf("RegisterEnumType: enum type %v is already registered with variants %v", enumT, alreadyRegisteredVariants))
+	}
+
+	r.enums[enumT] = registered
+	if len(names) > 0 {
+		r.enumNames[enumT] = names
+	}
+	r.version.Add(1)
+}
+
+// RegisterEnumTypeVariant adds variant to enum type enumT, which is already registered in r.
+func (r *Registry) RegisterEnumTypeVariant(enumT reflect.Type, id EnumVariantID, variantT reflect.Type) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	registeredVariants, enumRegistered := r.enums[enumT]
+	if !enumRegistered {
+		panic(fmt.Errorf("RegisterEnumTypeVariant: enum type %v is not registered", enumT))
+	}
+
+	checkEnumVariant(enumT, id, variantT, registeredVariants)
+
+	if variantT == r.unknownVariants[enumT] {
+		panic(fmt.Errorf("RegisterEnumTypeVariant: variant type %v of enum %v is already registered as unknown variant", variantT, enumT))
+	}
+
+	// Variants map may be used by coders right now, so it is replaced instead of modification.
+	updatedVariants := make(map[EnumVariantID]reflect.Type, len(registeredVariants)+1)
+	for existingID, existingVariantT := range registeredVariants {
+		updatedVariants[existingID] = existingVariantT
+	}
+	updatedVariants[id] = variantT
+
+	r.enums[enumT] = updatedVariants
+	r.version.Add(1)
+}
+
+func checkEnumVariant(enumT reflect.Type, id EnumVariantID, variantT reflect.Type, registeredVariants map[EnumVariantID]reflect.Type) {
+	if variantT == nil {
+		panic(fmt.Errorf("RegisterEnumType: variant type of enum %v with id %v is nil", enumT, id))
+	}
+
+	if id < 0 {
+		panic(fmt.Errorf("RegisterEnumType: attempt to register variant type %v of enum %v with negative id %v", variantT, enumT, id))
+	}
+
+	if variantT.Kind() == reflect.Interface {
+		panic(fmt.Errorf("RegisterEnumType: variant type %v of enum %v is an interface", variantT, enumT))
+	}
+
+	if !variantT.Implements(enumT) && variantT != noneT {
+		panic(fmt.Errorf("RegisterEnumType: variant type %v does not implement enum %v", variantT, enumT))
+	}
+
+	for existingID, registeredVariant := range registeredVariants {
+		if variantT == registeredVariant {
+			panic(fmt.Errorf("RegisterEnumType: variant type %v of enum %v is already registered under id %v instead of %v",
+				variantT, enumT, existingID, id))
+		}
+	}
+}
+
+// RegisterIntEnumType registers integer type enumT as enum with given values and their names.
+// It panics if enum is already registered in r or if names are empty or not unique.
+// Enum registered in ancestors of r is overridden.
+func (r *Registry) RegisterIntEnumType(enumT reflect.Type, names map[EnumVariantID]string) {
+	if !isIntKind(enumT.Kind()) {
+		panic(fmt.Errorf("RegisterIntEnumType: enum type %v is not an integer", enumT))
+	}
+
+	registered := make(map[EnumVariantID]string, len(names))
+	idsByName := make(map[string]EnumVariantID, len(names))
+
+	for id, name := range names {
+		if id < 0 {
+			panic(fmt.Errorf("RegisterIntEnumType: attempt to register value %v of enum %v with negative id", name, enumT))
+		}
+
+		if name == "" {
+			panic(fmt.Errorf("RegisterIntEnumType: value %v of enum %v has empty name", id, enumT))
+		}
+
+		if existingID, duplicate := idsByName[name]; duplicate {
+			panic(fmt.Errorf("RegisterIntEnumType: values %v and %v of enum %v have same name %v", existingID, id, enumT, name))
+		}
+
+		idsByName[name] = id
+		registered[id] = name
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if alreadyRegistered := r.intEnums[enumT]; alreadyRegistered != nil {
+		panic(fmt.Errorf("RegisterIntEnumType: enum type %v is already registered with values %v", enumT, alreadyRegistered))
+	}
+
+	r.intEnums[enumT] = registered
+	r.version.Add(1)
+}
+
+// RemoveEnumType removes registration of enum type enumT from r. Registrations in ancestors of r are not affected.
+func (r *Registry) RemoveEnumType(enumT reflect.Type) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.enums, enumT)
+	delete(r.unknownVariants, enumT)
+	delete(r.enumNames, enumT)
+	delete(r.intEnums, enumT)
+	r.version.Add(1)
+}
+
+// RegisterEnumTypeUnknownVariant sets type, which keeps values of enum type enumT with unregistered variant ids
+// instead of failing. Enum must be already registered in r.
+// Variant type must be UnknownEnumVariant or a type defined as UnknownEnumVariant (e.g. to add methods of enum interface).
+func (r *Registry) RegisterEnumTypeUnknownVariant(enumT reflect.Type, variantT reflect.Type) {
+	if variantT == nil || variantT.Kind() != reflect.Struct || !variantT.ConvertibleTo(unknownEnumVariantT) {
+		panic(fmt.Errorf("RegisterEnumTypeUnknownVariant: type %v of enum %v is not defined as bcs.UnknownEnumVariant", variantT, enumT))
+	}
+
+	if !variantT.Implements(enumT) {
+		panic(fmt.Errorf("RegisterEnumTypeUnknownVariant: type %v does not implement enum %v", variantT, enumT))
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	registeredVariants, enumRegistered := r.enums[enumT]
+	if !enumRegistered {
+		panic(fmt.Errorf("RegisterEnumTypeUnknownVariant: enum type %v is not registered", enumT))
+	}
+
+	if existing := r.unknownVariants[enumT]; existing != nil {
+		panic(fmt.Errorf("RegisterEnumTypeUnknownVariant: enum type %v already has unknown variant %v", enumT, existing))
+	}
+
+	for id, registeredVariant := range registeredVariants {
+		if variantT == registeredVariant {
+			panic(fmt.Errorf("RegisterEnumTypeUnknownVariant: type %v of enum %v is already registered under id %v", variantT, enumT, id))
+		}
+	}
+
+	r.unknownVariants[enumT] = variantT
+	r.version.Add(1)
+}
+
+// EnumVariants returns variants of enum type enumT registered in r or in its ancestors.
+// Returned map must not be modified.
+func (r *Registry) EnumVariants(enumT reflect.Type) (map[EnumVariantID]reflect.Type, bool) {
+	for ; r != nil; r = r.parent {
+		r.mu.RLock()
+		variants, registered := r.enums[enumT]
+		r.mu.RUnlock()
+
+		if registered {
+			return variants, true
+		}
+	}
+
+	return nil, false
+}
+
+// IntEnumVariants returns names of values of integer enum type enumT registered in r or in its ancestors.
+// Returned map must not be modified.
+func (r *Registry) IntEnumVariants(enumT reflect.Type) (map[EnumVariantID]string, bool) {
+	for ; r != nil; r = r.parent {
+		r.mu.RLock()
+		names, registered := r.intEnums[enumT]
+		r.mu.RUnlock()
+
+		if registered {
+			return names, true
+		}
+	}
+
+	return nil, false
+}
+
+// EnumVariantNames returns names of variants of enum type enumT, which were set when enum was registered using EnumBuilder.
+// It is taken from the same registry, in which the enum is registered. Returned map must not be modified.
+func (r *Registry) EnumVariantNames(enumT reflect.Type) map[EnumVariantID]string {
+	for ; r != nil; r = r.parent {
+		r.mu.RLock()
+		_, registered := r.enums[enumT]
+		names := r.enumNames[enumT]
+		r.mu.RUnlock()
+
+		if registered {
+			return names
+		}
+	}
+
+	return nil
+}
+
+// Returns type of unknown variant of enum type enumT or nil if it is not set.
+// It is taken from the same registry, in which the enum is registered.
+func (r *Registry) enumUnknownVariant(enumT reflect.Type) reflect.Type {
+	for ; r != nil; r = r.parent {
+		r.mu.RLock()
+		_, registered := r.enums[enumT]
+		unknownVariantT := r.unknownVariants[enumT]
+		r.mu.RUnlock()
+
+		if registered {
+			return unknownVariantT
+		}
+	}
+
+	return nil
+}
+
+func (r *Registry) customEncoder(t reflect.Type) CustomEncoder {
+	for ; r != nil; r = r.parent {
+		r.mu.RLock()
+		enc := r.encoders[t]
+		r.mu.RUnlock()
+
+		if enc != nil {
+			return enc
+		}
+	}
+
+	return nil
+}
+
+func (r *Registry) customDecoder(t reflect.Type) CustomDecoder {
+	for ; r != nil; r = r.parent {
+		r.mu.RLock()
+		dec := r.decoders[t]
+		r.mu.RUnlock()
+
+		if dec != nil {
+			return dec
+		}
+	}
+
+	return nil
+}
+
+// AddCustomEncoderTo registers encoder for values of type V in registry r.
+func AddCustomEncoderTo[V any](r *Registry, f func(e *Encoder, v V) error) struct{} {
+	r.AddCustomEncoder(reflect.TypeOf((*V)(nil)).Elem(), MakeCustomEncoder(f))
+	return struct{}{}
+}
+
+// RemoveCustomEncoderFrom removes encoder for values of type V from registry r.
+func RemoveCustomEncoderFrom[V any](r *Registry) {
+	r.RemoveCustomEncoder(reflect.TypeOf((*V)(nil)).Elem())
+}
+
+// AddCustomDecoderTo registers decoder for values of type V in registry r.
+func AddCustomDecoderTo[V any](r *Registry, f func(d *Decoder, v *V) error) struct{} {
+	r.AddCustomDecoder(reflect.TypeOf((*V)(nil)).Elem(), MakeCustomDecoder(f))
+	return struct{}{}
+}
+
+// RemoveCustomDecoderFrom removes decoder for values of type V from registry r.
+func RemoveCustomDecoderFrom[V any](r *Registry) {
+	r.RemoveCustomDecoder(reflect.TypeOf((*V)(nil)).Elem())
+}
+
+// RegisterEnumTypeIn registers EnumType as enum in registry r. Ids of variants are their indexes.
+func RegisterEnumTypeIn[EnumType any](r *Registry, variants ...any) struct{} {
+	variantsMap := make(map[EnumVariantID]any, len(variants))
+
+	for i, v := range variants {
+		variantsMap[i] = v
+	}
+
+	return RegisterEnumTypeWithIDsIn[EnumType](r, variantsMap)
+}
+
+// RegisterEnumTypeWithIDsIn registers EnumType as enum with variants of given ids in registry r.
+func RegisterEnumTypeWithIDsIn[EnumType any](r *Registry, variants map[EnumVariantID]any) struct{} {
+	variantTypes := make(map[EnumVariantID]reflect.Type, len(variants))
+
+	for id, v := range variants {
+		variantTypes[id] = reflect.TypeOf(v)
+	}
+
+	r.RegisterEnumType(reflect.TypeOf((*EnumType)(nil)).Elem(), variantTypes)
+
+	return struct{}{}
+}
+
+// RegisterEnumTypeVariantIn adds variant to EnumType, which is already registered in registry r.
+func RegisterEnumTypeVariantIn[EnumType any](r *Registry, id EnumVariantID, newVariant any) struct{} {
+	r.RegisterEnumTypeVariant(reflect.TypeOf((*EnumType)(nil)).Elem(), id, reflect.TypeOf(newVariant))
+	return struct{}{}
+}
+
+// RegisterEnumTypeUnknownVariantIn sets type, which keeps values of EnumType with unregistered variant ids, in registry r.
+func RegisterEnumTypeUnknownVariantIn[EnumType any](r *Registry, variant any) struct{} {
+	r.RegisterEnumTypeUnknownVariant(reflect.TypeOf((*EnumType)(nil)).Elem(), reflect.TypeOf(variant))
+	return struct{}{}
+}
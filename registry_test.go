@@ -0,0 +1,282 @@
+package bcs_test
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/iotaledger/bcs-go"
+)
+
+type RegistryTestType struct {
+	A int64
+}
+
+type RegistryTestEnum interface{}
+
+type RegistryTestInheritedType struct {
+	A int64
+}
+
+func encodeWithRegistry[V any](t *testing.T, r *bcs.Registry, v V) []byte {
+	var buf bytes.Buffer
+	e := bcs.NewEncoderWithOpts(&buf, bcs.EncoderConfig{Registry: r})
+	e.Encode(v)
+	require.NoError(t, e.Err())
+
+	return buf.Bytes()
+}
+
+func decodeWithRegistry[V any](t *testing.T, r *bcs.Registry, b []byte) V {
+	d := bcs.NewDecoderWithOpts(bytes.NewReader(b), bcs.DecoderConfig{Registry: r})
+	v := bcs.Decode[V](d)
+	require.NoError(t, d.Err())
+
+	return v
+}
+
+func TestRegistryIsolation(t *testing.T) {
+	r := bcs.NewRegistry()
+	require.Same(t, bcs.DefaultRegistry, r.Parent())
+
+	bcs.AddCustomEncoderTo(r, func(e *bcs.Encoder, v RegistryTestType) error {
+		e.WriteByte(byte(v.A))
+		return nil
+	})
+	bcs.AddCustomDecoderTo(r, func(d *bcs.Decoder, v *RegistryTestType) error {
+		v.A = int64(d.ReadByte())
+		return nil
+	})
+
+	require.Panics(t, func() {
+		bcs.AddCustomEncoderTo(r, func(e *bcs.Encoder, v RegistryTestType) error { return nil })
+	})
+
+	b := encodeWithRegistry(t, r, RegistryTestType{A: 5})
+	require.Equal(t, []byte{5}, b)
+	require.Equal(t, RegistryTestType{A: 5}, decodeWithRegistry[RegistryTestType](t, r, b))
+
+	// Default registry is not affected
+	bcs.TestCodecAndBytes(t, RegistryTestType{A: 5}, []byte{5, 0, 0, 0, 0, 0, 0, 0})
+
+	bcs.RemoveCustomEncoderFrom[RegistryTestType](r)
+	bcs.RemoveCustomDecoderFrom[RegistryTestType](r)
+	r2 := bcs.NewRegistry()
+	require.Equal(t, []byte{5, 0, 0, 0, 0, 0, 0, 0}, encodeWithRegistry(t, r2, RegistryTestType{A: 5}))
+}
+
+func TestRegistryInheritance(t *testing.T) {
+	t.Cleanup(func() {
+		bcs.RemoveCustomEncoder[RegistryTestInheritedType]()
+		bcs.RemoveCustomDecoder[RegistryTestInheritedType]()
+	})
+
+	parent := bcs.NewRegistry()
+	child := parent.NewChild()
+	sibling := parent.NewChild()
+
+	bcs.AddCustomEncoder(func(e *bcs.Encoder, v RegistryTestInheritedType) error {
+		e.WriteByte(byte(v.A))
+		return nil
+	})
+	bcs.AddCustomEncoderTo(parent, func(e *bcs.Encoder, v RegistryTestInheritedType) error {
+		e.WriteByte(byte(v.A + 1))
+		return nil
+	})
+	bcs.AddCustomEncoderTo(child, func(e *bcs.Encoder, v RegistryTestInheritedType) error {
+		e.WriteByte(byte(v.A + 2))
+		return nil
+	})
+
+	// Registrations of ancestors are visible and could be overridden
+	require.Equal(t, []byte{5}, bcs.MustMarshal(&RegistryTestInheritedType{A: 5}))
+	require.Equal(t, []byte{5}, encodeWithRegistry(t, bcs.NewRegistry(), RegistryTestInheritedType{A: 5}))
+	require.Equal(t, []byte{6}, encodeWithRegistry(t, parent, RegistryTestInheritedType{A: 5}))
+	require.Equal(t, []byte{6}, encodeWithRegistry(t, sibling, RegistryTestInheritedType{A: 5}))
+	require.Equal(t, []byte{7}, encodeWithRegistry(t, child, RegistryTestInheritedType{A: 5}))
+}
+
+func TestRegistryEnum(t *testing.T) {
+	r := bcs.NewRegistry()
+
+	bcs.RegisterEnumTypeIn[RegistryTestEnum](r, int32(0), "")
+	bcs.RegisterEnumTypeVariantIn[RegistryTestEnum](r, 2, RegistryTestType{})
+
+	require.Panics(t, func() {
+		bcs.RegisterEnumTypeIn[RegistryTestEnum](r, int32(0))
+	})
+	require.Panics(t, func() {
+		bcs.RegisterEnumTypeVariantIn[RegistryTestEnum](r, 6, int32(0))
+	})
+	require.Panics(t, func() {
+		bcs.RegisterEnumTypeVariant[RegistryTestEnum](6, int32(0))
+	})
+
+	_, registered := bcs.DefaultRegistry.EnumVariants(reflect.TypeOf((*RegistryTestEnum)(nil)).Elem())
+	require.False(t, registered)
+
+	var v RegistryTestEnum = RegistryTestType{A: 1}
+	b := encodeWithRegistry(t, r, &v)
+	require.Equal(t, []byte{2, 1, 0, 0, 0, 0, 0, 0, 0}, b)
+	require.Equal(t, v, decodeWithRegistry[RegistryTestEnum](t, r, b))
+
+	// Enum is not registered in default registry, so value is encoded as is
+	require.Equal(t, []byte{1, 0, 0, 0, 0, 0, 0, 0}, bcs.MustMarshal(&v))
+
+	schema, err := r.ReflectSchemaOf(reflect.TypeOf((*RegistryTestEnum)(nil)).Elem())
+	require.NoError(t, err)
+	require.Len(t, schema["RegistryTestEnum"].Variants, 3)
+
+	_, err = bcs.ReflectSchema[RegistryTestEnum]()
+	require.Error(t, err)
+}
+
+func TestRegistryConcurrency(t *testing.T) {
+	type Variant[T any] struct {
+		A T
+	}
+
+	g := errgroup.Group{}
+
+	for i := 0; i < 10; i++ {
+		g.Go(func() error {
+			r := bcs.NewRegistry()
+
+			bcs.AddCustomEncoderTo(r, func(e *bcs.Encoder, v RegistryTestType) error {
+				e.WriteByte(byte(v.A + int64(i)))
+				return nil
+			})
+			bcs.RegisterEnumTypeIn[RegistryTestEnum](r, Variant[int8]{}, Variant[string]{})
+
+			for j := 0; j < 100; j++ {
+				var buf bytes.Buffer
+				e := bcs.NewEncoderWithOpts(&buf, bcs.EncoderConfig{Registry: r})
+				var v RegistryTestEnum = Variant[int8]{A: int8(j)}
+				e.Encode(&v)
+				e.Encode(RegistryTestType{A: 1})
+
+				if err := e.Err(); err != nil {
+					return err
+				}
+				if !bytes.Equal(buf.Bytes(), []byte{0, byte(j), byte(1 + i)}) {
+					return fmt.Errorf("unexpected encoding %v in registry %v", buf.Bytes(), i)
+				}
+			}
+
+			return nil
+		})
+	}
+
+	require.NoError(t, g.Wait())
+}
+
+type RegistrySwapTestType struct {
+	A int16
+}
+
+func TestRegistryCodecSwap(t *testing.T) {
+	parent := bcs.NewRegistry()
+	r := parent.NewChild()
+	v := RegistrySwapTestType{A: 5}
+
+	// Encoder is reused to check that it also notices changes of registrations
+	var buf bytes.Buffer
+	e := bcs.NewEncoderWithOpts(&buf, bcs.EncoderConfig{Registry: r})
+	encode := func() []byte {
+		buf.Reset()
+		e.Encode(v)
+		require.NoError(t, e.Err())
+
+		return bytes.Clone(buf.Bytes())
+	}
+
+	require.Equal(t, []byte{5, 0}, encode())
+	require.Equal(t, v, decodeWithRegistry[RegistrySwapTestType](t, r, []byte{5, 0}))
+
+	bcs.AddCustomEncoderTo(r, func(e *bcs.Encoder, v RegistrySwapTestType) error {
+		e.WriteByte(byte(v.A))
+		return nil
+	})
+	bcs.AddCustomDecoderTo(r, func(d *bcs.Decoder, v *RegistrySwapTestType) error {
+		v.A = int16(d.ReadByte())
+		return nil
+	})
+
+	require.Equal(t, []byte{5}, encode())
+	require.Equal(t, v, decodeWithRegistry[RegistrySwapTestType](t, r, []byte{5}))
+
+	bcs.RemoveCustomEncoderFrom[RegistrySwapTestType](r)
+	bcs.RemoveCustomDecoderFrom[RegistrySwapTestType](r)
+
+	require.Equal(t, []byte{5, 0}, encode())
+	require.Equal(t, v, decodeWithRegistry[RegistrySwapTestType](t, r, []byte{5, 0}))
+
+	// Changes of ancestors are also noticed
+	bcs.AddCustomEncoderTo(parent, func(e *bcs.Encoder, v RegistrySwapTestType) error {
+		e.WriteByte(byte(v.A + 1))
+		return nil
+	})
+	require.Equal(t, []byte{6}, encode())
+
+	bcs.RemoveCustomEncoderFrom[RegistrySwapTestType](parent)
+	require.Equal(t, []byte{5, 0}, encode())
+}
+
+func TestDefaultRegistryCodecSwap(t *testing.T) {
+	t.Cleanup(func() { bcs.RemoveCustomEncoder[RegistrySwapTestType]() })
+
+	v := RegistrySwapTestType{A: 5}
+	require.Equal(t, []byte{5, 0}, bcs.MustMarshal(&v))
+
+	bcs.AddCustomEncoder(func(e *bcs.Encoder, v RegistrySwapTestType) error {
+		e.WriteByte(byte(v.A))
+		return nil
+	})
+	require.Equal(t, []byte{5}, bcs.MustMarshal(&v))
+
+	bcs.RemoveCustomEncoder[RegistrySwapTestType]()
+	require.Equal(t, []byte{5, 0}, bcs.MustMarshal(&v))
+}
+
+func TestRegistryCodecSwapConcurrency(t *testing.T) {
+	r := bcs.NewRegistry()
+	v := RegistrySwapTestType{A: 5}
+
+	g := errgroup.Group{}
+
+	for i := 0; i < 10; i++ {
+		g.Go(func() error {
+			for j := 0; j < 100; j++ {
+				var buf bytes.Buffer
+				e := bcs.NewEncoderWithOpts(&buf, bcs.EncoderConfig{Registry: r})
+				e.Encode(v)
+
+				if err := e.Err(); err != nil {
+					return err
+				}
+				if b := buf.Bytes(); !bytes.Equal(b, []byte{5}) && !bytes.Equal(b, []byte{5, 0}) {
+					return fmt.Errorf("unexpected encoding %v", b)
+				}
+			}
+
+			return nil
+		})
+	}
+
+	for j := 0; j < 100; j++ {
+		bcs.AddCustomEncoderTo(r, func(e *bcs.Encoder, v RegistrySwapTestType) error {
+			e.WriteByte(byte(v.A))
+			return nil
+		})
+		bcs.RemoveCustomEncoderFrom[RegistrySwapTestType](r)
+	}
+
+	require.NoError(t, g.Wait())
+
+	// After all changes are done, no stale information is left
+	require.Equal(t, []byte{5, 0}, encodeWithRegistry(t, r, v))
+}
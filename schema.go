@@ -0,0 +1,489 @@
+package bcs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"reflect"
+	"time"
+)
+
+// SchemaRegistry describes named types (structs and enums) in the format of serde-reflection Registry.
+// It could be serialized into YAML or JSON and compared with the one produced by serde-reflection
+// for Rust definitions of the same types.
+type SchemaRegistry map[string]ContainerFormat
+
+type FormatKind string
+
+const (
+	FormatUnit FormatKind = "UNIT"
+	FormatBool FormatKind = "BOOL"
+	FormatI8   FormatKind = "I8"
+	FormatI16  FormatKind = "I16"
+	FormatI32  FormatKind = "I32"
+	FormatI64  FormatKind = "I64"
+	FormatI128 FormatKind = "I128"
+	FormatU8   FormatKind = "U8"
+	FormatU16  FormatKind = "U16"
+	FormatU32  FormatKind = "U32"
+	FormatU64  FormatKind = "U64"
+	FormatU128 FormatKind = "U128"
+	// U256 is not supported by serde-reflection, but is widely used by Move.
+	FormatU256       FormatKind = "U256"
+	FormatStr        FormatKind = "STR"
+	FormatBytes      FormatKind = "BYTES"
+	FormatOption     FormatKind = "OPTION"
+	FormatSeq        FormatKind = "SEQ"
+	FormatMap        FormatKind = "MAP"
+	FormatTuple      FormatKind = "TUPLE"
+	FormatTupleArray FormatKind = "TUPLEARRAY"
+	FormatTypeName   FormatKind = "TYPENAME"
+)
+
+// Format describes encoding of a value.
+type Format struct {
+	Kind FormatKind
+	// Name of container for TYPENAME.
+	Name string
+	// Content of OPTION, SEQ and TUPLEARRAY.
+	Content *Format
+	// Size of TUPLEARRAY.
+	Size int
+	// Key and value of MAP.
+	Key, Value *Format
+	// Elements of TUPLE.
+	Elems []Format
+}
+
+type ContainerKind string
+
+const (
+	ContainerUnitStruct ContainerKind = "UNITSTRUCT"
+	ContainerStruct     ContainerKind = "STRUCT"
+	ContainerEnum       ContainerKind = "ENUM"
+)
+
+// ContainerFormat describes encoding of a named type.
+type ContainerFormat struct {
+	Kind ContainerKind
+	// Fields of STRUCT.
+	Fields []NamedFormat
+	// Variants of ENUM by their indices.
+	Variants map[EnumVariantID]VariantFormat
+}
+
+type NamedFormat struct {
+	Name   string
+	Format Format
+}
+
+// VariantFormat describes enum variant. Variant without value (e.g. bcs.None) has nil Newtype.
+type VariantFormat struct {
+	Name    string
+	Newtype *Format
+}
+
+// SchemaType could be implemented by types with custom encoding to describe it in schema.
+type SchemaType interface {
+	BCSSchema() Format
+}
+
+var schemaTypeT = reflect.TypeOf((*SchemaType)(nil)).Elem()
+
+// ReflectSchema walks type T the same way as encoder does and returns the description of all named types it consists of.
+func ReflectSchema[T any]() (SchemaRegistry, error) {
+	return ReflectSchemaOf(reflect.TypeOf((*T)(nil)).Elem())
+}
+
+// ReflectSchemaOf returns the description of all named types, which the given types consist of.
+func ReflectSchemaOf(types ...reflect.Type) (SchemaRegistry, error) {
+	return DefaultRegistry.ReflectSchemaOf(types...)
+}
+
+// ReflectSchemaOf returns the description of types using custom codecs and enums registered in r.
+func (r *Registry) ReflectSchemaOf(types ...reflect.Type) (SchemaRegistry, error) {
+	b := schemaBuilder{
+		e:        NewEncoderWithOpts(io.Discard, EncoderConfig{Registry: r}),
+		registry: make(SchemaRegistry),
+		types:    make(map[string]reflect.Type),
+	}
+
+	for _, t := range types {
+		if _, err := b.format(t, nil); err != nil {
+			return nil, fmt.Errorf("schema: %w", err)
+		}
+	}
+
+	return b.registry, nil
+}
+
+type schemaBuilder struct {
+	e        *Encoder
+	registry SchemaRegistry
+	types    map[string]reflect.Type
+}
+
+var (
+	bigIntT = reflect.TypeOf(big.Int{})
+	timeT   = reflect.TypeOf(time.Time{})
+)
+
+var intFormats = map[reflect.Kind]FormatKind{
+	reflect.Int8:   FormatI8,
+	reflect.Int16:  FormatI16,
+	reflect.Int32:  FormatI32,
+	reflect.Int64:  FormatI64,
+	reflect.Int:    FormatI64,
+	reflect.Uint8:  FormatU8,
+	reflect.Uint16: FormatU16,
+	reflect.Uint32: FormatU32,
+	reflect.Uint64: FormatU64,
+	reflect.Uint:   FormatU64,
+}
+
+var bigIntFormats = map[BigIntEncoding]FormatKind{
+	BigIntU64:  FormatU64,
+	BigIntU128: FormatU128,
+	BigIntU256: FormatU256,
+	BigIntI128: FormatI128,
+}
+
+//nolint:gocyclo
+func (b *schemaBuilder) format(t reflect.Type, typeOptionsFromTag *TypeOptions) (Format, error) {
+	tInfo, err := b.e.getEncodedTypeInfo(t)
+	if err != nil {
+		return Format{}, err
+	}
+
+	if tInfo.RefLevelsCount == -1 {
+		t = reflect.PointerTo(t)
+	} else {
+		for i := 0; i < tInfo.RefLevelsCount; i++ {
+			t = t.Elem()
+		}
+	}
+
+	if typeOptionsFromTag != nil && typeOptionsFromTag.BigIntEncoding != BigIntDefault {
+		if t != bigIntT {
+			return Format{}, fmt.Errorf("%v: bigint option is applicable only to big.Int", t)
+		}
+
+		return Format{Kind: bigIntFormats[typeOptionsFromTag.BigIntEncoding]}, nil
+	}
+
+	if tInfo.CustomEncoder != nil {
+		return b.customFormat(t)
+	}
+
+	if tInfo.IntEnumNames != nil {
+		return b.container(t, func() (ContainerFormat, error) {
+			return intEnumFormat(tInfo.IntEnumNames), nil
+		})
+	}
+
+	var typeOptions TypeOptions
+	if tInfo.HasTypeOptions {
+		typeOptions = reflect.Zero(t).Interface().(BCSType).BCSOptions()
+	}
+	if typeOptionsFromTag != nil {
+		typeOptions.Update(*typeOptionsFromTag)
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return Format{Kind: FormatBool}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if typeOptions.IsCompactInt {
+			return Format{}, fmt.Errorf("%v: compact integers cannot be described in schema", t)
+		}
+
+		kind := t.Kind()
+		if typeOptions.UnderlyingType != reflect.Invalid {
+			kind = typeOptions.UnderlyingType
+		}
+
+		return Format{Kind: intFormats[kind]}, nil
+	case reflect.String:
+		return Format{Kind: FormatStr}, nil
+	case reflect.Slice, reflect.Array:
+		var elemOpts ArrayElemOptions
+		if typeOptions.ArrayElement != nil {
+			elemOpts = *typeOptions.ArrayElement
+		}
+
+		elem, err := b.elemFormat(t.Elem(), &elemOpts.TypeOptions, elemOpts.AsByteArray)
+		if err != nil {
+			return Format{}, fmt.Errorf("%v: element: %w", t, err)
+		}
+
+		if t.Kind() == reflect.Array {
+			return Format{Kind: FormatTupleArray, Content: &elem, Size: t.Len()}, nil
+		}
+
+		return Format{Kind: FormatSeq, Content: &elem}, nil
+	case reflect.Map:
+		key, err := b.format(t.Key(), typeOptions.MapKey)
+		if err != nil {
+			return Format{}, fmt.Errorf("%v: key: %w", t, err)
+		}
+
+		value, err := b.format(t.Elem(), typeOptions.MapValue)
+		if err != nil {
+			return Format{}, fmt.Errorf("%v: value: %w", t, err)
+		}
+
+		return Format{Kind: FormatMap, Key: &key, Value: &value}, nil
+	case reflect.Struct:
+		return b.container(t, func() (ContainerFormat, error) {
+			if tInfo.IsStructEnum {
+				return b.structEnumFormat(t, &tInfo)
+			}
+
+			return b.structFormat(t, &tInfo)
+		})
+	case reflect.Interface:
+		if typeOptions.InterfaceIsNotEnum {
+			return Format{}, fmt.Errorf("%v: interface, which is not enum, cannot be described in schema", t)
+		}
+
+		variants, registered := b.e.cfg.Registry.EnumVariants(t)
+		if !registered {
+			return Format{}, fmt.Errorf("%v: interface is not registered as enum type", t)
+		}
+
+		return b.container(t, func() (ContainerFormat, error) {
+			return b.interfaceEnumFormat(t, variants)
+		})
+	default:
+		return Format{}, fmt.Errorf("%v: cannot describe unknown type", t)
+	}
+}
+
+func (b *schemaBuilder) elemFormat(t reflect.Type, typeOpts *TypeOptions, asByteArray bool) (Format, error) {
+	if asByteArray {
+		// The value is encoded as a byte array prepended with its length, which is exactly how vector of bytes is encoded.
+		return Format{Kind: FormatSeq, Content: &Format{Kind: FormatU8}}, nil
+	}
+
+	return b.format(t, typeOpts)
+}
+
+func (b *schemaBuilder) customFormat(t reflect.Type) (Format, error) {
+	switch {
+	case t == bigIntT || t == reflect.PointerTo(bigIntT):
+		return Format{Kind: FormatU128}, nil
+	case t == timeT || t == reflect.PointerTo(timeT):
+		return Format{Kind: FormatI64}, nil
+	case t.Implements(schemaTypeT):
+		if t.Kind() == reflect.Ptr {
+			return reflect.New(t.Elem()).Interface().(SchemaType).BCSSchema(), nil
+		}
+		return reflect.Zero(t).Interface().(SchemaType).BCSSchema(), nil
+	case t.Kind() != reflect.Ptr && reflect.PointerTo(t).Implements(schemaTypeT):
+		return reflect.New(t).Interface().(SchemaType).BCSSchema(), nil
+	}
+
+	return Format{}, fmt.Errorf("%v: type has custom encoder, but does not implement bcs.SchemaType", t)
+}
+
+// Registers container of type t under its name and returns reference to it.
+func (b *schemaBuilder) container(t reflect.Type, describe func() (ContainerFormat, error)) (Format, error) {
+	name := t.Name()
+	if name == "" {
+		return Format{}, fmt.Errorf("%v: anonymous types cannot be described in schema", t)
+	}
+
+	ref := Format{Kind: FormatTypeName, Name: name}
+
+	if registeredT, registered := b.types[name]; registered {
+		if registeredT != t {
+			return Format{}, fmt.Errorf("%v: name %v is already used by type %v", t, name, registeredT)
+		}
+
+		// Type is either already described or is being described right now (recursive type).
+		return ref, nil
+	}
+
+	b.types[name] = t
+
+	c, err := describe()
+	if err != nil {
+		return Format{}, err
+	}
+
+	b.registry[name] = c
+
+	return ref, nil
+}
+
+func (b *schemaBuilder) structFormat(t reflect.Type, tInfo *typeInfo) (ContainerFormat, error) {
+	var fields []NamedFormat
+
+	for i := 0; i < t.NumField(); i++ {
+		fieldOpts, hasTag := tInfo.FieldOptions[i], tInfo.FieldHasTag[i]
+		if fieldOpts.Skip {
+			continue
+		}
+
+		fieldType := t.Field(i)
+
+		if !fieldType.IsExported() {
+			if !fieldOpts.ExportAnonymousField {
+				if hasTag {
+					return ContainerFormat{}, fmt.Errorf("%v: unexported field %v has BCS tag, but is not marked for export", t.Name(), fieldType.Name)
+				}
+
+				continue
+			}
+		} else if fieldOpts.ExportAnonymousField {
+			return ContainerFormat{}, fmt.Errorf("%v: field %v is already exported, but is marked for export", t.Name(), fieldType.Name)
+		}
+
+		f, err := b.elemFormat(fieldType.Type, &fieldOpts.TypeOptions, fieldOpts.AsByteArray)
+		if err != nil {
+			return ContainerFormat{}, fmt.Errorf("%v: %v: %w", t.Name(), fieldType.Name, err)
+		}
+
+		switch fieldType.Type.Kind() {
+		case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+			if fieldOpts.Optional {
+				content := f
+				f = Format{Kind: FormatOption, Content: &content}
+			}
+		}
+
+		fields = append(fields, NamedFormat{Name: fieldType.Name, Format: f})
+	}
+
+	if len(fields) == 0 {
+		return ContainerFormat{Kind: ContainerUnitStruct}, nil
+	}
+
+	return ContainerFormat{Kind: ContainerStruct, Fields: fields}, nil
+}
+
+func (b *schemaBuilder) structEnumFormat(t reflect.Type, tInfo *typeInfo) (ContainerFormat, error) {
+	variantIDs := tInfo.EnumVariantIDs
+	variants := make(map[EnumVariantID]VariantFormat, t.NumField())
+
+	for i := 0; i < t.NumField(); i++ {
+		fieldType := t.Field(i)
+
+		if isStructEnumUnitVariant(fieldType.Type) {
+			variants[variantIDs[i]] = VariantFormat{Name: fieldType.Name}
+			continue
+		}
+
+		fieldOpts := &tInfo.FieldOptions[i]
+
+		f, err := b.elemFormat(fieldType.Type, &fieldOpts.TypeOptions, fieldOpts.AsByteArray)
+		if err != nil {
+			return ContainerFormat{}, fmt.Errorf("%v: %v: %w", t.Name(), fieldType.Name, err)
+		}
+
+		variants[variantIDs[i]] = VariantFormat{Name: fieldType.Name, Newtype: &f}
+	}
+
+	return ContainerFormat{Kind: ContainerEnum, Variants: variants}, nil
+}
+
+func intEnumFormat(names map[EnumVariantID]string) ContainerFormat {
+	variants := make(map[EnumVariantID]VariantFormat, len(names))
+
+	for id, name := range names {
+		variants[id] = VariantFormat{Name: name}
+	}
+
+	return ContainerFormat{Kind: ContainerEnum, Variants: variants}
+}
+
+func (b *schemaBuilder) interfaceEnumFormat(t reflect.Type, enumVariants map[EnumVariantID]reflect.Type) (ContainerFormat, error) {
+	variants := make(map[EnumVariantID]VariantFormat, len(enumVariants))
+	names := b.e.cfg.Registry.EnumVariantNames(t)
+
+	for id, variantT := range enumVariants {
+		name, hasName := names[id]
+		if !hasName {
+			name = enumVariantTypeName(variantT)
+		}
+
+		if variantT == noneT {
+			variants[id] = VariantFormat{Name: name}
+			continue
+		}
+
+		f, err := b.format(variantT, nil)
+		if err != nil {
+			return ContainerFormat{}, fmt.Errorf("%v: variant %v: %w", t, variantT, err)
+		}
+
+		variants[id] = VariantFormat{Name: name, Newtype: &f}
+	}
+
+	return ContainerFormat{Kind: ContainerEnum, Variants: variants}, nil
+}
+
+// Converts format into the structure of maps and slices, which is serialized same way as serde serializes it.
+func (f Format) toSerde() any {
+	switch f.Kind {
+	case FormatTypeName:
+		return map[string]any{string(f.Kind): f.Name}
+	case FormatOption, FormatSeq:
+		return map[string]any{string(f.Kind): f.Content.toSerde()}
+	case FormatTupleArray:
+		return map[string]any{string(f.Kind): map[string]any{"CONTENT": f.Content.toSerde(), "SIZE": f.Size}}
+	case FormatMap:
+		return map[string]any{string(f.Kind): map[string]any{"KEY": f.Key.toSerde(), "VALUE": f.Value.toSerde()}}
+	case FormatTuple:
+		elems := make([]any, len(f.Elems))
+		for i := range f.Elems {
+			elems[i] = f.Elems[i].toSerde()
+		}
+		return map[string]any{string(f.Kind): elems}
+	default:
+		return string(f.Kind)
+	}
+}
+
+func (c ContainerFormat) toSerde() any {
+	switch c.Kind {
+	case ContainerStruct:
+		fields := make([]any, len(c.Fields))
+		for i := range c.Fields {
+			fields[i] = map[string]any{c.Fields[i].Name: c.Fields[i].Format.toSerde()}
+		}
+		return map[string]any{string(c.Kind): fields}
+	case ContainerEnum:
+		variants := make(map[EnumVariantID]any, len(c.Variants))
+		for id, v := range c.Variants {
+			if v.Newtype == nil {
+				variants[id] = map[string]any{v.Name: string(FormatUnit)}
+			} else {
+				variants[id] = map[string]any{v.Name: map[string]any{"NEWTYPE": v.Newtype.toSerde()}}
+			}
+		}
+		return map[string]any{string(c.Kind): variants}
+	default:
+		return string(c.Kind)
+	}
+}
+
+func (f Format) MarshalJSON() ([]byte, error) {
+	return json.Marshal(f.toSerde())
+}
+
+// MarshalYAML implements yaml.Marshaler of gopkg.in/yaml.v3.
+func (f Format) MarshalYAML() (any, error) {
+	return f.toSerde(), nil
+}
+
+func (c ContainerFormat) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.toSerde())
+}
+
+// MarshalYAML implements yaml.Marshaler of gopkg.in/yaml.v3.
+func (c ContainerFormat) MarshalYAML() (any, error) {
+	return c.toSerde(), nil
+}
@@ -0,0 +1,228 @@
+package bcs_test
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/exp/maps"
+	"gopkg.in/yaml.v3"
+
+	"github.com/iotaledger/bcs-go"
+)
+
+type SchemaEnum interface{}
+
+type SchemaVariant struct {
+	V uint16
+}
+
+type SchemaStructEnum struct {
+	A *int32
+	B *SchemaVariant
+	C []string
+}
+
+func (SchemaStructEnum) IsBcsEnum() {}
+
+type SchemaNode struct {
+	Name     string
+	Children []SchemaNode
+	Parent   *SchemaNode `bcs:"optional"`
+}
+
+type SchemaStruct struct {
+	A          bool
+	B          int
+	C          int64  `bcs:"type=u8"`
+	D          []byte `bcs:"len_bytes=2"`
+	E          [3]uint16
+	F          map[string]*uint32
+	G          *[]int8 `bcs:"optional"`
+	H          []int64 `bcs:"bytearr"`
+	I          big.Int
+	J          SchemaEnum
+	K          SchemaStructEnum
+	L          SchemaNode
+	Skipped    string `bcs:"-"`
+	unexported int
+	exported   int16 `bcs:"export"`
+}
+
+type SchemaEmpty struct{}
+
+type SchemaCustom struct{}
+
+func (SchemaCustom) MarshalBCS(e *bcs.Encoder) error {
+	return nil
+}
+
+func (*SchemaCustom) BCSSchema() bcs.Format {
+	return bcs.Format{Kind: bcs.FormatU128}
+}
+
+const expectedSchemaYAML = `SchemaEnum:
+    ENUM:
+        0:
+            None: UNIT
+        1:
+            uint32:
+                NEWTYPE: U32
+        3:
+            SchemaVariant:
+                NEWTYPE:
+                    TYPENAME: SchemaVariant
+SchemaNode:
+    STRUCT:
+        - Name: STR
+        - Children:
+            SEQ:
+                TYPENAME: SchemaNode
+        - Parent:
+            OPTION:
+                TYPENAME: SchemaNode
+SchemaStruct:
+    STRUCT:
+        - A: BOOL
+        - B: I64
+        - C: U8
+        - D:
+            SEQ: U8
+        - E:
+            TUPLEARRAY:
+                CONTENT: U16
+                SIZE: 3
+        - F:
+            MAP:
+                KEY: STR
+                VALUE: U32
+        - G:
+            OPTION:
+                SEQ: I8
+        - H:
+            SEQ: U8
+        - I: U128
+        - J:
+            TYPENAME: SchemaEnum
+        - K:
+            TYPENAME: SchemaStructEnum
+        - L:
+            TYPENAME: SchemaNode
+        - exported: I16
+SchemaStructEnum:
+    ENUM:
+        0:
+            A:
+                NEWTYPE: I32
+        1:
+            B:
+                NEWTYPE:
+                    TYPENAME: SchemaVariant
+        2:
+            C:
+                NEWTYPE:
+                    SEQ: STR
+SchemaVariant:
+    STRUCT:
+        - V: U16
+`
+
+func TestReflectSchema(t *testing.T) {
+	t.Cleanup(func() { maps.Clear(bcs.EnumTypes) })
+
+	bcs.RegisterEnumTypeWithIDs[SchemaEnum](map[bcs.EnumVariantID]any{
+		0: bcs.None{},
+		1: uint32(0),
+		3: &SchemaVariant{},
+	})
+
+	schema, err := bcs.ReflectSchema[SchemaStruct]()
+	require.NoError(t, err)
+
+	yamlBytes, err := yaml.Marshal(schema)
+	require.NoError(t, err)
+	require.Equal(t, expectedSchemaYAML, string(yamlBytes))
+
+	jsonBytes, err := json.Marshal(schema)
+	require.NoError(t, err)
+
+	require.JSONEq(t, `{
+		"SchemaEnum": {"ENUM": {"0": {"None": "UNIT"}, "1": {"uint32": {"NEWTYPE": "U32"}}, "3": {"SchemaVariant": {"NEWTYPE": {"TYPENAME": "SchemaVariant"}}}}},
+		"SchemaNode": {"STRUCT": [{"Name": "STR"}, {"Children": {"SEQ": {"TYPENAME": "SchemaNode"}}}, {"Parent": {"OPTION": {"TYPENAME": "SchemaNode"}}}]},
+		"SchemaStruct": {"STRUCT": [
+			{"A": "BOOL"}, {"B": "I64"}, {"C": "U8"}, {"D": {"SEQ": "U8"}}, {"E": {"TUPLEARRAY": {"CONTENT": "U16", "SIZE": 3}}},
+			{"F": {"MAP": {"KEY": "STR", "VALUE": "U32"}}}, {"G": {"OPTION": {"SEQ": "I8"}}}, {"H": {"SEQ": "U8"}}, {"I": "U128"},
+			{"J": {"TYPENAME": "SchemaEnum"}}, {"K": {"TYPENAME": "SchemaStructEnum"}}, {"L": {"TYPENAME": "SchemaNode"}}, {"exported": "I16"}
+		]},
+		"SchemaStructEnum": {"ENUM": {"0": {"A": {"NEWTYPE": "I32"}}, "1": {"B": {"NEWTYPE": {"TYPENAME": "SchemaVariant"}}}, "2": {"C": {"NEWTYPE": {"SEQ": "STR"}}}}},
+		"SchemaVariant": {"STRUCT": [{"V": "U16"}]}
+	}`, string(jsonBytes))
+
+	schema, err = bcs.ReflectSchema[[]SchemaNode]()
+	require.NoError(t, err)
+	require.Equal(t, bcs.SchemaRegistry{
+		"SchemaNode": {
+			Kind: bcs.ContainerStruct,
+			Fields: []bcs.NamedFormat{
+				{Name: "Name", Format: bcs.Format{Kind: bcs.FormatStr}},
+				{Name: "Children", Format: bcs.Format{Kind: bcs.FormatSeq, Content: &bcs.Format{Kind: bcs.FormatTypeName, Name: "SchemaNode"}}},
+				{Name: "Parent", Format: bcs.Format{Kind: bcs.FormatOption, Content: &bcs.Format{Kind: bcs.FormatTypeName, Name: "SchemaNode"}}},
+			},
+		},
+	}, schema)
+
+	schema, err = bcs.ReflectSchema[SchemaEmpty]()
+	require.NoError(t, err)
+	require.Equal(t, bcs.SchemaRegistry{"SchemaEmpty": {Kind: bcs.ContainerUnitStruct}}, schema)
+
+	yamlBytes, err = yaml.Marshal(schema)
+	require.NoError(t, err)
+	require.Equal(t, "SchemaEmpty: UNITSTRUCT\n", string(yamlBytes))
+
+	schema, err = bcs.ReflectSchema[map[SchemaCustom]SchemaCustom]()
+	require.NoError(t, err)
+	require.Empty(t, schema)
+}
+
+func TestReflectSchemaErrors(t *testing.T) {
+	type Anonymous struct {
+		A struct{}
+	}
+
+	type Compact struct {
+		A uint64 `bcs:"compact"`
+	}
+
+	type NotEnum struct {
+		A any `bcs:"not_enum"`
+	}
+
+	type BadStructEnum struct {
+		B int
+		SchemaStructEnum
+	}
+
+	type Custom struct {
+		A SchemaCustom
+		B BasicWithCustomCodec
+	}
+
+	_, err := bcs.ReflectSchema[Anonymous]()
+	require.ErrorContains(t, err, "anonymous types cannot be described in schema")
+
+	_, err = bcs.ReflectSchema[Compact]()
+	require.ErrorContains(t, err, "compact integers cannot be described in schema")
+
+	_, err = bcs.ReflectSchema[NotEnum]()
+	require.ErrorContains(t, err, "interface, which is not enum, cannot be described in schema")
+
+	_, err = bcs.ReflectSchema[SchemaEnum]()
+	require.ErrorContains(t, err, "interface is not registered as enum type")
+
+	_, err = bcs.ReflectSchema[BadStructEnum]()
+	require.ErrorContains(t, err, "field B of enum bcs_test.BadStructEnum is of non-nullable type int")
+
+	_, err = bcs.ReflectSchema[Custom]()
+	require.ErrorContains(t, err, "type has custom encoder, but does not implement bcs.SchemaType")
+}
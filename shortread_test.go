@@ -0,0 +1,150 @@
+package bcs_test
+
+import (
+	"bytes"
+	"io"
+	"math/big"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotaledger/bcs-go"
+)
+
+type ShortReadNested struct {
+	A int16
+	B string
+}
+
+type ShortReadSample struct {
+	U8      uint8
+	U16     uint16
+	U32     uint32
+	U64     uint64
+	I64     int64
+	Compact uint64 `bcs:"compact"`
+	Bool    bool
+	Str     string
+	Bytes   []byte
+	Arr     [5]byte
+	Big     *big.Int
+	U128    bcs.U128
+	U256    bcs.U256
+	Time    time.Time
+	Opt     *ShortReadNested `bcs:"optional"`
+	Nested  []ShortReadNested
+	Map     map[string]int32
+	ByteArr ShortReadNested `bcs:"bytearr"`
+	Large   []byte
+}
+
+func shortReadSample() ShortReadSample {
+	large := make([]byte, 3000)
+	for i := range large {
+		large[i] = byte(i)
+	}
+
+	return ShortReadSample{
+		U8:      1,
+		U16:     0x0102,
+		U32:     0x01020304,
+		U64:     0x0102030405060708,
+		I64:     -2,
+		Compact: 300,
+		Bool:    true,
+		Str:     "hello",
+		Bytes:   []byte{1, 2, 3},
+		Arr:     [5]byte{5, 4, 3, 2, 1},
+		Big:     big.NewInt(1234567890),
+		U128:    bcs.U128{Hi: 1, Lo: 2},
+		U256:    bcs.U256From64(3),
+		Time:    time.Unix(0, 123456789),
+		Opt:     &ShortReadNested{A: 4, B: "opt"},
+		Nested:  []ShortReadNested{{A: 1, B: "a"}, {A: 2, B: "b"}},
+		Map:     map[string]int32{"x": 1, "y": 2},
+		ByteArr: ShortReadNested{A: 5, B: "bytearr"},
+		Large:   large,
+	}
+}
+
+func TestShortReads(t *testing.T) {
+	v := shortReadSample()
+	b := bcs.MustMarshal(&v)
+
+	readers := map[string]func() io.Reader{
+		"OneByteReader": func() io.Reader { return iotest.OneByteReader(bytes.NewReader(b)) },
+		"HalfReader":    func() io.Reader { return iotest.HalfReader(bytes.NewReader(b)) },
+		"DataErrReader": func() io.Reader { return iotest.DataErrReader(bytes.NewReader(b)) },
+		"Pipe": func() io.Reader {
+			r, w := io.Pipe()
+			go func() {
+				// Writing in small chunks, so each read returns only a part of requested bytes.
+				for i := 0; i < len(b); i += 7 {
+					if _, err := w.Write(b[i:min(i+7, len(b))]); err != nil {
+						return
+					}
+				}
+				_ = w.Close()
+			}()
+
+			return r
+		},
+	}
+
+	for name, newReader := range readers {
+		t.Run(name, func(t *testing.T) {
+			decoded, err := bcs.UnmarshalStream[ShortReadSample](newReader())
+			require.NoError(t, err)
+			requireBigEqual(t, v.Big, decoded.Big)
+			decoded.Big = v.Big
+			require.True(t, v.Time.Equal(decoded.Time))
+			decoded.Time = v.Time
+			require.Equal(t, v, decoded)
+
+			d := bcs.NewDecoder(newReader())
+			require.Equal(t, v.U8, d.ReadUint8())
+			require.Equal(t, v.U16, d.ReadUint16())
+			require.Equal(t, v.U32, d.ReadUint32())
+			require.Equal(t, v.U64, d.ReadUint64())
+			require.Equal(t, v.I64, d.ReadInt64())
+			require.Equal(t, v.Compact, d.ReadCompactUint64())
+			require.NoError(t, d.Err())
+		})
+	}
+}
+
+func TestTruncatedInput(t *testing.T) {
+	v := shortReadSample()
+	v.Large = v.Large[:10]
+	b := bcs.MustMarshal(&v)
+
+	for i := 0; i < len(b); i++ {
+		_, err := bcs.UnmarshalStream[ShortReadSample](iotest.OneByteReader(bytes.NewReader(b[:i])))
+		require.ErrorIs(t, err, io.ErrUnexpectedEOF, "length %v", i)
+		require.ErrorIs(t, err, bcs.ErrUnexpectedEOF, "length %v", i)
+
+		_, err = bcs.Unmarshal[ShortReadSample](b[:i])
+		require.ErrorIs(t, err, io.ErrUnexpectedEOF, "length %v", i)
+	}
+}
+
+func TestDecodeUint128ShortReads(t *testing.T) {
+	v, _ := new(big.Int).SetString("1770887431076116955186", 10)
+	b := bcs.MustMarshal(v)
+
+	decoded, err := bcs.DecodeUint128(iotest.OneByteReader(bytes.NewReader(b)))
+	require.NoError(t, err)
+	requireBigEqual(t, v, decoded)
+
+	decoded, err = bcs.DecodeUint128(iotest.HalfReader(bytes.NewReader(b)))
+	require.NoError(t, err)
+	requireBigEqual(t, v, decoded)
+
+	_, err = bcs.DecodeUint128(bytes.NewReader(b[:10]))
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	_, err = bcs.DecodeUint128(bytes.NewReader(nil))
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
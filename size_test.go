@@ -0,0 +1,146 @@
+package bcs_test
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotaledger/bcs-go"
+)
+
+type SizeFixed struct {
+	A uint8
+	B int64
+	C [3]uint16
+	D bool
+	e int32 // not encoded
+}
+
+type SizeNested struct {
+	X uint32 `bcs:"compact"`
+}
+
+type SizeCustom struct {
+	V uint16
+}
+
+func (s *SizeCustom) MarshalBCS(e *bcs.Encoder) error {
+	e.WriteString("custom")
+	e.WriteUint16(s.V)
+	return nil
+}
+
+func (s *SizeCustom) UnmarshalBCS(d *bcs.Decoder) error {
+	_ = d.ReadString()
+	s.V = d.ReadUint16()
+	return nil
+}
+
+type SizeComplex struct {
+	Fixed     SizeFixed
+	Hashes    [][32]byte
+	Str       string
+	Compact   uint64       `bcs:"compact"`
+	Narrow    int64        `bcs:"type=i16"`
+	Optional  *uint32      `bcs:"optional"`
+	Missing   *uint32      `bcs:"optional"`
+	ByteArr   []SizeNested `bcs:"bytearr"`
+	ElemBytes []SizeNested `bcs_elem:"bytearr"`
+	Map       map[string][]byte
+	Custom    SizeCustom
+	Time      time.Time
+	BigInt    *big.Int `bcs:"bigint=u64"`
+	Enum      SizeStructEnum
+}
+
+type SizeStructEnum struct {
+	A *uint8
+	B *SizeFixed
+}
+
+func (SizeStructEnum) IsBcsEnum() {}
+
+func TestEncodedSize(t *testing.T) {
+	size, err := bcs.EncodedSize(&SizeFixed{})
+	require.NoError(t, err)
+	require.Equal(t, 1+8+6+1, size)
+
+	size, err = bcs.EncodedSize(&[100][32]byte{})
+	require.NoError(t, err)
+	require.Equal(t, 3200, size)
+
+	hashes := make([][32]byte, 200)
+	v := SizeComplex{
+		Hashes:    hashes,
+		Str:       "hello",
+		Compact:   1 << 20,
+		Narrow:    -5,
+		Optional:  new(uint32),
+		ByteArr:   []SizeNested{{X: 1}, {X: 300}},
+		ElemBytes: []SizeNested{{X: 1 << 30}, {}},
+		Map:       map[string][]byte{"a": {1, 2}, "bcd": make([]byte, 200)},
+		Custom:    SizeCustom{V: 5},
+		Time:      time.Unix(100, 0),
+		BigInt:    big.NewInt(1000),
+		Enum:      SizeStructEnum{B: &SizeFixed{}},
+	}
+
+	b := bcs.MustMarshal(&v)
+
+	size, err = bcs.EncodedSize(&v)
+	require.NoError(t, err)
+	require.Equal(t, len(b), size)
+
+	var anyV any = v
+	size, err = bcs.EncodedSize(&anyV)
+	require.NoError(t, err)
+	require.Equal(t, len(b), size)
+}
+
+func TestEncodedSizeErrors(t *testing.T) {
+	_, err := bcs.EncodedSize(&SizeComplex{})
+	require.ErrorIs(t, err, bcs.ErrNilValue)
+
+	_, err = bcs.EncodedSize(&SizeStructEnum{})
+	require.ErrorIs(t, err, bcs.ErrInvalidEnumVariant)
+
+	_, err = bcs.EncodedSize(&struct {
+		V int64 `bcs:"type=i8"`
+	}{V: 1000})
+	require.ErrorIs(t, err, bcs.ErrOverflow)
+}
+
+func TestEncoderSize(t *testing.T) {
+	e := bcs.NewCountingEncoder()
+	e.WriteString("hello")
+	e.Encode(&SizeFixed{})
+	require.NoError(t, e.Err())
+	require.Equal(t, 6+16, e.Size())
+
+	be := bcs.NewBytesEncoder()
+	be.WriteString("hello")
+	be.Encode(&SizeFixed{})
+	require.NoError(t, be.Err())
+	require.Equal(t, len(be.Bytes()), be.Size())
+}
+
+func TestEncodedSizeFixedNoAllocs(t *testing.T) {
+	v := make([][32]byte, 10000)
+	e := bcs.NewCountingEncoder()
+	e.Encode(&v)
+	require.NoError(t, e.Err())
+	require.Equal(t, 2+32*10000, e.Size())
+
+	structs := make([]SizeFixed, 10000)
+	e.Encode(&structs)
+	require.NoError(t, e.Err())
+
+	// Elements of fixed size are not visited one by one
+	allocs := testing.AllocsPerRun(10, func() {
+		e.Encode(&v)
+		e.Encode(&structs)
+	})
+	require.Zero(t, allocs)
+}
@@ -0,0 +1,454 @@
+package bcs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/samber/lo"
+)
+
+// Skip advances decoder past encoded value of type T without decoding it into Go value.
+// See Decoder.SkipType.
+func Skip[T any](d *Decoder) {
+	d.SkipType(reflect.TypeOf((*T)(nil)).Elem())
+}
+
+// SkipType advances decoder past encoded value of type t without decoding it into Go value.
+// The type is walked same way as when decoding: tags, enums, optional values, compact integers and "bytearr" are honored.
+// Lengths are read and bytes are discarded, so no memory is allocated for the skipped data.
+// Values of "bytearr" fields are skipped by their length without looking into them.
+//
+// Values are checked only as much as it is needed to find their bounds, e.g. variant indexes of enums are validated,
+// but canonical encoding is not checked even in strict mode.
+// Values of types with custom decoders cannot be skipped without decoding, so they are decoded and discarded.
+func (d *Decoder) SkipType(t reflect.Type) {
+	if d.err != nil {
+		return
+	}
+
+	pathLen := len(d.path)
+	defer func() { d.path = d.path[:pathLen] }()
+
+	if pathLen == 0 {
+		d.typeInfoCache.Refresh()
+		d.path.pushField(rootTypeName(t))
+	}
+
+	if err := d.skipValue(t, nil, nil, nil); err != nil {
+		_ = d.handleErrorf("skipping %v: %w", t, err)
+		return
+	}
+}
+
+// Skips value of type t. Info of its type and merged options could be passed same way as for Encoder.encodeValue.
+//
+//nolint:gocyclo,funlen
+func (d *Decoder) skipValue(t reflect.Type, typeOptionsFromTag *TypeOptions, tInfo *typeInfo, mergedTypeOptions *TypeOptions) (err error) {
+	if limit := d.cfg.Limits.MaxDepth; limit > 0 && d.depth >= limit {
+		return d.limitErrorf("MaxDepth", d.depth+1, limit)
+	}
+
+	d.depth++
+	defer func() {
+		d.depth--
+		if err != nil && d.failure != nil && d.failure.Type == nil {
+			d.failure.Type = t
+		}
+	}()
+
+	if tInfo == nil {
+		ti, err := d.getEncodedTypeInfo(t)
+		if err != nil {
+			return err
+		}
+
+		tInfo = &ti
+	}
+
+	if tInfo.CustomDecoder != nil || (typeOptionsFromTag != nil && typeOptionsFromTag.BigIntEncoding != BigIntDefault) {
+		// Format of such values is not known, so they have to be decoded.
+		return d.decodeValue(reflect.New(t).Elem(), typeOptionsFromTag, tInfo, mergedTypeOptions)
+	}
+
+	for i := 0; i < tInfo.RefLevelsCount; i++ {
+		t = t.Elem()
+	}
+
+	if tInfo.IntEnumNames != nil {
+		id := d.ReadEnumIdx()
+		if d.err != nil {
+			return d.err
+		}
+
+		if _, ok := tInfo.IntEnumNames[id]; !ok {
+			return d.kindErrorf(ErrInvalidEnumVariant, "invalid variant index %v for enum %v, expected one of: %v", id, t, intEnumVariantsString(tInfo.IntEnumNames))
+		}
+
+		return nil
+	}
+
+	var typeOptions TypeOptions
+	if mergedTypeOptions != nil {
+		typeOptions = *mergedTypeOptions
+	} else {
+		if tInfo.HasTypeOptions {
+			typeOptions = tInfo.TypeOptions
+		}
+		if typeOptionsFromTag != nil {
+			typeOptions.Update(*typeOptionsFromTag)
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		d.ReadBool()
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int,
+		reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint:
+		if typeOptions.IsCompactInt {
+			d.ReadCompactUint64()
+			break
+		}
+
+		kind := t.Kind()
+		if typeOptions.UnderlyingType != reflect.Invalid {
+			kind = typeOptions.UnderlyingType
+		}
+
+		err = d.discard(kindBits(kind) / 8)
+	case reflect.String:
+		length := d.ReadLen()
+		if d.err == nil && d.checkByteLen(length) == nil {
+			err = d.discard(length)
+		}
+	case reflect.Slice:
+		err = d.skipSlice(t, typeOptions)
+	case reflect.Array:
+		err = d.skipArray(t, t.Len(), typeOptions)
+	case reflect.Map:
+		err = d.skipMap(t, typeOptions)
+	case reflect.Struct:
+		if tInfo.IsStructEnum {
+			err = d.skipStructEnum(t, tInfo)
+		} else {
+			err = d.skipStruct(tInfo)
+		}
+	case reflect.Interface:
+		err = d.skipInterface(t, !typeOptions.InterfaceIsNotEnum)
+	default:
+		return d.kindErrorf(ErrUnsupportedType, "%v: cannot skip unknown type", t)
+	}
+
+	if err != nil {
+		return d.handleErrorf("%v: %w", t, err)
+	}
+	if d.err != nil {
+		return d.handleErrorf("%v: %w", t, d.err)
+	}
+
+	return nil
+}
+
+func (d *Decoder) skipSlice(t reflect.Type, typeOpts TypeOptions) error {
+	length := d.ReadLen()
+	if d.err != nil {
+		return d.err
+	}
+
+	switch typeOpts.LenSizeInBytes {
+	case 0:
+	case Len2Bytes:
+		if length > 0xFFFF {
+			return d.kindErrorf(ErrOverflow, "array size exceeds 2 bytes: %v", length)
+		}
+	case Len4Bytes:
+		if uint64(length) > 0xFFFFFFFF {
+			return d.kindErrorf(ErrOverflow, "array size exceeds 4 bytes: %v", length)
+		}
+	default:
+		return d.kindErrorf(ErrInvalidOptions, "invalid array size type: %v", typeOpts.LenSizeInBytes)
+	}
+
+	if t.Elem().Kind() == reflect.Uint8 {
+		if err := d.checkByteLen(length); err != nil {
+			return err
+		}
+	} else if err := d.checkCollectionLen(length); err != nil {
+		return err
+	}
+
+	return d.skipArray(t, length, typeOpts)
+}
+
+func (d *Decoder) skipArray(t reflect.Type, n int, typeOpts TypeOptions) error {
+	elemType := t.Elem()
+
+	tInfo, err := d.getEncodedTypeInfo(elemType)
+	if err != nil {
+		return d.handleErrorf("element: %w", err)
+	}
+
+	var elemOpts ArrayElemOptions
+	if typeOpts.ArrayElement != nil {
+		elemOpts = *typeOpts.ArrayElement
+	}
+
+	if elemOpts.AsByteArray {
+		for i := 0; i < n; i++ {
+			if err := d.skipByteArray(); err != nil {
+				return d.handleErrorf("[%v]: %w", i, err)
+			}
+		}
+
+		return nil
+	}
+
+	if !tInfo.HasCustomizations() && elemType.Kind() == reflect.Uint8 {
+		return d.discard(n)
+	}
+
+	d.path.pushIdx(0)
+
+	for i := 0; i < n; i++ {
+		d.path.setIdx(i)
+		if err := d.skipValue(elemType, &elemOpts.TypeOptions, &tInfo, nil); err != nil {
+			return d.handleErrorf("[%v]: %w", i, err)
+		}
+	}
+
+	d.path.pop()
+
+	return nil
+}
+
+func (d *Decoder) skipMap(t reflect.Type, typeOpts TypeOptions) error {
+	length := d.ReadLen()
+	if d.err != nil {
+		return d.err
+	}
+
+	switch typeOpts.LenSizeInBytes {
+	case 0:
+	case Len2Bytes:
+		if length > 0xFFFF {
+			return d.kindErrorf(ErrOverflow, "map size exceeds 2 bytes: %v", length)
+		}
+	case Len4Bytes:
+		if uint64(length) > 0xFFFFFFFF {
+			return d.kindErrorf(ErrOverflow, "map size exceeds 4 bytes: %v", length)
+		}
+	default:
+		return d.kindErrorf(ErrInvalidOptions, "invalid map size type: %v", typeOpts.LenSizeInBytes)
+	}
+
+	if err := d.checkCollectionLen(length); err != nil {
+		return err
+	}
+
+	keyTypeInfo, err := d.getEncodedTypeInfo(t.Key())
+	if err != nil {
+		return d.handleErrorf("key: %w", err)
+	}
+
+	valueTypeInfo, err := d.getEncodedTypeInfo(t.Elem())
+	if err != nil {
+		return d.handleErrorf("value: %w", err)
+	}
+
+	d.path.pushIdx(0)
+	d.path.pushField("")
+
+	for i := 0; i < length; i++ {
+		d.path[len(d.path)-2].idx = i
+
+		d.path.setField("mapKey")
+		if err := d.skipValue(t.Key(), typeOpts.MapKey, &keyTypeInfo, nil); err != nil {
+			return d.handleErrorf("key: %w", err)
+		}
+
+		d.path.setField("mapValue")
+		if err := d.skipValue(t.Elem(), typeOpts.MapValue, &valueTypeInfo, nil); err != nil {
+			return d.handleErrorf("value: %w", err)
+		}
+	}
+
+	d.path.pop()
+	d.path.pop()
+
+	return nil
+}
+
+func (d *Decoder) skipStruct(tInfo *typeInfo) error {
+	for i := range tInfo.StructPlan {
+		f := &tInfo.StructPlan[i]
+
+		if f.Err != "" {
+			return d.kindErrorf(ErrInvalidOptions, "%v", f.Err)
+		}
+
+		d.path.pushField(f.Name)
+
+		if f.IsNullable && f.Opts.Optional {
+			hasValue := d.ReadOptionalFlag()
+			if d.err != nil {
+				return d.handleErrorf("%v: %w", f.Name, d.err)
+			}
+
+			if !hasValue {
+				d.path.pop()
+				continue
+			}
+		}
+
+		var err error
+
+		if f.Opts.AsByteArray {
+			err = d.skipByteArray()
+		} else {
+			err = d.skipValue(f.Type, &f.Opts.TypeOptions, f.Info, f.TypeOpts)
+		}
+
+		if err != nil {
+			return d.handleErrorf("%v: %w", f.Name, err)
+		}
+
+		d.path.pop()
+	}
+
+	return nil
+}
+
+func (d *Decoder) skipStructEnum(t reflect.Type, tInfo *typeInfo) error {
+	variantIdx := d.ReadEnumIdx()
+	if d.err != nil {
+		return d.err
+	}
+
+	fieldIdx := lo.IndexOf(tInfo.EnumVariantIDs, variantIdx)
+	if fieldIdx == -1 {
+		return d.kindErrorf(ErrInvalidEnumVariant, "invalid variant index %v for enum %v", variantIdx, t)
+	}
+
+	d.path.pushField(t.Field(fieldIdx).Name)
+
+	fieldOpts := &tInfo.FieldOptions[fieldIdx]
+
+	var err error
+
+	if fieldOpts.AsByteArray {
+		err = d.skipByteArray()
+	} else {
+		err = d.skipValue(t.Field(fieldIdx).Type, &fieldOpts.TypeOptions, nil, nil)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	d.path.pop()
+
+	return nil
+}
+
+func (d *Decoder) skipInterface(t reflect.Type, couldBeEnum bool) error {
+	variants, registered := d.cfg.Registry.EnumVariants(t)
+	if !couldBeEnum || !registered {
+		return d.kindErrorf(ErrUnsupportedType, "interface type %v is not registered as enum, so its values cannot be skipped", t)
+	}
+
+	variantIdx := d.ReadEnumIdx()
+	if d.err != nil {
+		return d.err
+	}
+
+	variantT, known := variants[variantIdx]
+	if !known {
+		if d.cfg.Registry.enumUnknownVariant(t) == nil {
+			return d.kindErrorf(ErrInvalidEnumVariant, "invalid variant index %v for enum %v", variantIdx, t)
+		}
+
+		// Payload of unknown variant takes all remaining bytes.
+		if _, err := d.readRest(); err != nil {
+			return err
+		}
+
+		d.restTakenBy = d.path.String()
+
+		return nil
+	}
+
+	if variantT == noneT {
+		return nil
+	}
+
+	if err := d.skipValue(variantT, nil, nil, nil); err != nil {
+		return d.handleErrorf("%v: %w", variantT, err)
+	}
+
+	return nil
+}
+
+// Skips value written with "bytearr" option by its length.
+func (d *Decoder) skipByteArray() error {
+	length := d.ReadLen()
+	if d.err != nil {
+		return d.handleErrorf("bytearr: %w", d.err)
+	}
+
+	if err := d.checkByteLen(length); err != nil {
+		return err
+	}
+
+	return d.discard(length)
+}
+
+// Reads and discards n bytes.
+func (d *Decoder) discard(n int) error {
+	if d.err != nil {
+		return d.err
+	}
+	if n == 0 {
+		return nil
+	}
+
+	// bytes.Reader of BytesDecoder: just moving the position.
+	if r, ok := d.r.(interface {
+		io.Seeker
+		Len() int
+	}); ok && r.Len() >= n {
+		if _, err := r.Seek(int64(n), io.SeekCurrent); err != nil {
+			return d.setErr(nil, err)
+		}
+
+		d.offset += int64(n)
+
+		return nil
+	}
+
+	read, err := io.CopyN(io.Discard, d.r, int64(n))
+	d.offset += read
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+
+		return d.setReadErr(fmt.Errorf("skipping %v bytes: %w", n, err))
+	}
+
+	return nil
+}
+
+// Returns size in bits of fixed-size integer kind.
+func kindBits(k reflect.Kind) int {
+	switch k {
+	case reflect.Int8, reflect.Uint8:
+		return 8
+	case reflect.Int16, reflect.Uint16:
+		return 16
+	case reflect.Int32, reflect.Uint32:
+		return 32
+	default:
+		return 64
+	}
+}
@@ -0,0 +1,200 @@
+package bcs_test
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotaledger/bcs-go"
+)
+
+type SkipInner struct {
+	A int16
+	B string
+}
+
+type SkipEnum interface{}
+
+type SkipStructEnum struct {
+	A *int32
+	B *SkipInner
+	C *bcs.None
+}
+
+func (SkipStructEnum) IsBcsEnum() {}
+
+type SkipComplex struct {
+	Bool       bool
+	Int        int
+	Compact    uint64 `bcs:"compact"`
+	Narrow     int64  `bcs:"type=i16"`
+	Str        string
+	Bytes      []byte
+	Array      [3]uint16
+	Inner      SkipInner
+	InnerPtr   *SkipInner
+	Optional   *SkipInner  `bcs:"optional"`
+	OptionalNo *SkipInner  `bcs:"optional"`
+	ByteArr    SkipInner   `bcs:"bytearr"`
+	Slice      []SkipInner `bcs:"len_bytes=2"`
+	Map        map[string]SkipInner
+	Enum       SkipEnum
+	NoneEnum   SkipEnum
+	StructEnum SkipStructEnum
+	Ignored    int `bcs:"-"`
+	unexported int
+}
+
+func newSkipComplex() SkipComplex {
+	return SkipComplex{
+		Bool:       true,
+		Int:        -5,
+		Compact:    300,
+		Narrow:     -7,
+		Str:        "hello",
+		Bytes:      []byte{1, 2, 3},
+		Array:      [3]uint16{1, 2, 3},
+		Inner:      SkipInner{A: 1, B: "a"},
+		InnerPtr:   &SkipInner{A: 2, B: "b"},
+		Optional:   &SkipInner{A: 3, B: "c"},
+		ByteArr:    SkipInner{A: 4, B: "d"},
+		Slice:      []SkipInner{{A: 5}, {B: "e"}},
+		Map:        map[string]SkipInner{"x": {A: 6}, "y": {B: "f"}},
+		Enum:       SkipInner{A: 7, B: "g"},
+		NoneEnum:   bcs.None{},
+		StructEnum: SkipStructEnum{B: &SkipInner{A: 8}},
+	}
+}
+
+// Enum is registered in separate registry, so it does not affect other tests.
+func newSkipRegistry() *bcs.Registry {
+	r := bcs.NewRegistry()
+	bcs.RegisterEnumTypeIn[SkipEnum](r, SkipInner{}, bcs.None{})
+
+	return r
+}
+
+func TestSkip(t *testing.T) {
+	r := newSkipRegistry()
+	v := newSkipComplex()
+
+	e := bcs.NewBytesEncoderWithOpts(bcs.EncoderConfig{Registry: r})
+	e.Encode(&v)
+	e.Encode(uint32(0xDEADBEEF))
+	require.NoError(t, e.Err())
+
+	d := bcs.NewBytesDecoderWithOpts(e.Bytes(), bcs.DecoderConfig{Registry: r})
+	bcs.Skip[SkipComplex](&d.Decoder)
+	require.NoError(t, d.Err())
+	require.Equal(t, uint32(0xDEADBEEF), bcs.Decode[uint32](&d.Decoder))
+	require.NoError(t, d.Err())
+	require.Zero(t, d.Len())
+
+	// Same using generic reader and reflect type
+	d2 := bcs.NewDecoderWithOpts(bytesReader(e.Bytes()), bcs.DecoderConfig{Registry: r})
+	d2.SkipType(reflect.TypeOf(v))
+	require.NoError(t, d2.Err())
+	require.Equal(t, uint32(0xDEADBEEF), bcs.Decode[uint32](d2))
+	require.NoError(t, d2.Err())
+}
+
+func TestSkipByteArray(t *testing.T) {
+	type WithBlob struct {
+		Blob  SkipInner `bcs:"bytearr"`
+		After uint8
+	}
+
+	// Contents of blob are not looked into, so it could even be invalid for the field type.
+	b := []byte{0x3, 0xFF, 0xFF, 0xFF, 0x9}
+
+	d := bcs.NewBytesDecoder(b)
+	bcs.Skip[WithBlob](&d.Decoder)
+	require.NoError(t, d.Err())
+	require.Zero(t, d.Len())
+
+	_, err := bcs.Unmarshal[WithBlob](b)
+	require.Error(t, err)
+}
+
+func TestSkipStructEnumVariantOptions(t *testing.T) {
+	values := []StructEnumWithVariantOpts{
+		{A: lo.ToPtr[uint64](300)},
+		{B: &[]int16{1, 2}},
+		{C: &SkipInner{A: 1, B: "a"}},
+	}
+
+	for _, v := range values {
+		e := bcs.NewBytesEncoder()
+		e.Encode(&v)
+		e.Encode(uint8(0x9))
+		require.NoError(t, e.Err())
+
+		d := bcs.NewBytesDecoder(e.Bytes())
+		bcs.Skip[StructEnumWithVariantOpts](&d.Decoder)
+		require.NoError(t, d.Err())
+		require.Equal(t, uint8(0x9), bcs.Decode[uint8](&d.Decoder))
+		require.Zero(t, d.Len())
+	}
+}
+
+func TestSkipErrors(t *testing.T) {
+	b := bcs.MustMarshal(&SkipInner{A: 1, B: "abc"})
+
+	d := bcs.NewBytesDecoder(b[:len(b)-1])
+	bcs.Skip[SkipInner](&d.Decoder)
+	require.ErrorIs(t, d.Err(), io.ErrUnexpectedEOF)
+
+	d2 := bcs.NewDecoder(bytesReader(b[:len(b)-1]))
+	bcs.Skip[SkipInner](d2)
+	require.ErrorIs(t, d2.Err(), io.ErrUnexpectedEOF)
+
+	d = bcs.NewBytesDecoder([]byte{0x5})
+	bcs.Skip[SkipStructEnum](&d.Decoder)
+	require.ErrorIs(t, d.Err(), bcs.ErrInvalidEnumVariant)
+
+	d = bcs.NewBytesDecoder([]byte{0x0})
+	bcs.Skip[any](&d.Decoder)
+	require.ErrorIs(t, d.Err(), bcs.ErrUnsupportedType)
+}
+
+func TestSkipNoAllocs(t *testing.T) {
+	r := newSkipRegistry()
+	small := newSkipComplex()
+	big := newSkipComplex()
+	big.Str = strings.Repeat("a", 1000)
+	big.Bytes = make([]byte, 1000)
+	big.Slice = make([]SkipInner, 1000)
+	big.Map = make(map[string]SkipInner)
+	for i := 0; i < 1000; i++ {
+		big.Map[strconv.Itoa(i)] = SkipInner{B: "b"}
+	}
+
+	skipAllocs := func(v SkipComplex) float64 {
+		e := bcs.NewBytesEncoderWithOpts(bcs.EncoderConfig{Registry: r})
+		e.Encode(&v)
+		require.NoError(t, e.Err())
+
+		b := e.Bytes()
+		return testing.AllocsPerRun(10, func() {
+			d := bcs.NewBytesDecoderWithOpts(b, bcs.DecoderConfig{Registry: r})
+			bcs.Skip[SkipComplex](&d.Decoder)
+			require.NoError(t, d.Err())
+		})
+	}
+
+	// Only decoder itself allocates, skipped data does not.
+	require.Equal(t, skipAllocs(small), skipAllocs(big))
+}
+
+// Hides all methods of bytes.Reader except Read.
+type readerOnly struct{ io.Reader }
+
+func bytesReader(b []byte) io.Reader {
+	return readerOnly{bytes.NewReader(b)}
+}
@@ -0,0 +1,84 @@
+package bcs
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// MaxSequenceLength is the maximal length of a sequence allowed by BCS specification.
+const MaxSequenceLength = 1<<31 - 1
+
+// MaxEnumVariantIdx is the maximal enum variant index allowed by BCS specification.
+const MaxEnumVariantIdx = 1<<32 - 1
+
+// ErrNonCanonical is matched by any of *NonCanonicalError using errors.Is().
+var ErrNonCanonical = errors.New("non-canonical encoding")
+
+// NonCanonicalError is returned by decoder in strict mode when payload is a valid, but not the only possible
+// encoding of a value. E.g. ULEB128 with redundant trailing zero bytes or map with unsorted keys.
+// Same as for LimitError, its message does not include the path.
+type NonCanonicalError struct {
+	// Path to the value, which was encoded in non-canonical form, e.g. "Block.Transactions[3].Payload".
+	Path string
+	// Description of the violation.
+	Reason string
+}
+
+func (e *NonCanonicalError) Error() string {
+	return fmt.Sprintf("%v: %v", ErrNonCanonical, e.Reason)
+}
+
+func (e *NonCanonicalError) Is(target error) bool {
+	return target == ErrNonCanonical
+}
+
+func (d *Decoder) nonCanonicalErrorf(format string, args ...interface{}) error {
+	return d.handleErrorf("%w", &NonCanonicalError{Path: d.path.String(), Reason: fmt.Sprintf(format, args...)})
+}
+
+// CaptureBytes returns bytes read from the input by dec(). It could be used to keep encoded bytes
+// of a value while decoding it, e.g. to forward them or to calculate their hash.
+func (d *Decoder) CaptureBytes(dec func() error) ([]byte, error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	b, err := d.captureBytes(dec)
+	if err != nil {
+		return nil, err
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	return b, nil
+}
+
+// Captures bytes read by dec() from the stream.
+func (d *Decoder) captureBytes(dec func() error) ([]byte, error) {
+	origStream := d.r
+	defer func() { d.r = origStream }() // for case of panic/error
+
+	var captured bytes.Buffer
+	d.r = io.TeeReader(origStream, &captured)
+
+	if err := dec(); err != nil {
+		return nil, err
+	}
+
+	return captured.Bytes(), nil
+}
+
+// Checks that keys of map entries are sorted by their encoded bytes and are unique.
+func (d *Decoder) checkMapKeysOrder(prevKey, key []byte) error {
+	switch bytes.Compare(prevKey, key) {
+	case 0:
+		return d.nonCanonicalErrorf("duplicate map key %x", key)
+	case 1:
+		return d.nonCanonicalErrorf("map keys are not sorted: %x goes after %x", key, prevKey)
+	}
+
+	return nil
+}
@@ -0,0 +1,118 @@
+package bcs_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotaledger/bcs-go"
+)
+
+type StrictStruct struct {
+	A uint64 `bcs:"compact"`
+	B map[string][]int16
+	C *StrictStructEnum `bcs:"optional"`
+}
+
+type StrictStructEnum struct {
+	A *int8
+	B *string
+}
+
+func (StrictStructEnum) IsBcsEnum() {}
+
+func decodeStrict[V any](b []byte) (V, error) {
+	var v V
+	d := bcs.NewDecoderWithOpts(bcs.NewBytesDecoder(b), bcs.DecoderConfig{Strict: true})
+	d.Decode(&v)
+
+	return v, d.Err()
+}
+
+// Checks that payload is accepted by default, but rejected in strict mode.
+func testNonCanonical[V any](t *testing.T, b []byte, expectedPath string) {
+	_, err := bcs.Unmarshal[V](b)
+	require.NoError(t, err)
+
+	_, err = decodeStrict[V](b)
+	require.Error(t, err)
+	require.True(t, errors.Is(err, bcs.ErrNonCanonical), err.Error())
+
+	var nonCanonicalErr *bcs.NonCanonicalError
+	require.True(t, errors.As(err, &nonCanonicalErr))
+	require.Equal(t, expectedPath, nonCanonicalErr.Path)
+	require.True(t, strings.HasPrefix(nonCanonicalErr.Error(), bcs.ErrNonCanonical.Error()), nonCanonicalErr.Error())
+}
+
+func TestStrictAcceptsCanonical(t *testing.T) {
+	v := StrictStruct{
+		A: 300,
+		B: map[string][]int16{"b": {1, 2}, "a": {3}, "": {}},
+		C: &StrictStructEnum{B: new(string)},
+	}
+
+	vDec, err := decodeStrict[StrictStruct](bcs.MustMarshal(&v))
+	require.NoError(t, err)
+	require.Equal(t, v, vDec)
+}
+
+func TestStrictNonMinimalULEB128(t *testing.T) {
+	testNonCanonical[[]byte](t, []byte{0x81, 0x00, 0x1}, "[]uint8")
+	testNonCanonical[[]byte](t, []byte{0x80, 0x80, 0x00}, "[]uint8")
+	testNonCanonical[string](t, []byte{0x82, 0x80, 0x00, 'a', 'b'}, "string")
+
+	compact := []byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x00}
+	testNonCanonical[StrictStruct](t, append(compact, 0, 0), "StrictStruct.A")
+
+	testNonCanonical[StrictStructEnum](t, []byte{0x80, 0x00, 0x5}, "StrictStructEnum")
+}
+
+func TestStrictLengthOverflow(t *testing.T) {
+	e := bcs.NewBytesEncoder()
+	e.WriteLen(bcs.MaxSequenceLength + 1)
+
+	_, err := decodeStrict[[]byte](e.Bytes())
+	require.ErrorIs(t, err, bcs.ErrNonCanonical)
+
+	// Not strict decoder will fail too, but with another error
+	_, err = bcs.Unmarshal[[]byte](e.Bytes())
+	require.Error(t, err)
+	require.NotErrorIs(t, err, bcs.ErrNonCanonical)
+}
+
+func TestStrictEnumIdxOverflow(t *testing.T) {
+	e := bcs.NewBytesEncoder()
+	e.WriteEnumIdx(bcs.MaxEnumVariantIdx + 1)
+
+	_, err := decodeStrict[StrictStructEnum](e.Bytes())
+	require.ErrorIs(t, err, bcs.ErrNonCanonical)
+}
+
+func TestStrictUnsortedMap(t *testing.T) {
+	testNonCanonical[map[uint8]bool](t, []byte{0x2, 0x2, 0x1, 0x1, 0x0}, "map[uint8]bool[1].mapKey")
+	testNonCanonical[map[string]bool](t, []byte{0x2, 0x1, 'b', 0x1, 0x1, 'a', 0x0}, "map[string]bool[1].mapKey")
+
+	// Nested map
+	testNonCanonical[StrictStruct](t, []byte{
+		0x0,
+		0x2, 0x1, 'b', 0x0, 0x1, 'a', 0x0,
+		0x0,
+	}, "StrictStruct.B[1].mapKey")
+}
+
+func TestStrictDuplicateMapKey(t *testing.T) {
+	_, err := decodeStrict[map[uint8]bool]([]byte{0x2, 0x1, 0x1, 0x1, 0x0})
+	require.ErrorIs(t, err, bcs.ErrNonCanonical)
+	require.Contains(t, err.Error(), "duplicate map key")
+
+	// Non-strict decoder keeps the last value
+	v, err := bcs.Unmarshal[map[uint8]bool]([]byte{0x2, 0x1, 0x1, 0x1, 0x0})
+	require.NoError(t, err)
+	require.Equal(t, map[uint8]bool{1: false}, v)
+}
+
+func TestStrictInvalidUTF8(t *testing.T) {
+	testNonCanonical[string](t, []byte{0x2, 0xff, 0xfe}, "string")
+}
@@ -6,4 +6,48 @@ type Enum interface {
 	IsBcsEnum()
 }
 
-var structEnumT = reflect.TypeOf((*Enum)(nil)).Elem()
+var (
+	structEnumT  = reflect.TypeOf((*Enum)(nil)).Elem()
+	emptyStructT = reflect.TypeOf(struct{}{})
+)
+
+// Returns variant ids of fields of struct enum. By default it is index of the field, but it could be
+// overridden using "variant" tag.
+// Also checks, that all fields are nullable, because otherwise it is impossible to detect which variant is set.
+func structEnumVariantIDs(t reflect.Type, tagName string, kindErrorf func(kind error, format string, args ...interface{}) error) ([]EnumVariantID, error) {
+	fieldOpts, _, err := FieldOptionsFromStruct(t, tagName)
+	if err != nil {
+		return nil, kindErrorf(ErrInvalidOptions, "parsing struct enum fields options: %v: %w", t, err)
+	}
+
+	ids := make([]EnumVariantID, t.NumField())
+	fieldsByID := make(map[EnumVariantID]string, t.NumField())
+
+	for i := 0; i < t.NumField(); i++ {
+		fieldType := t.Field(i)
+
+		switch fieldType.Type.Kind() {
+		case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+		default:
+			return nil, kindErrorf(ErrUnsupportedType, "field %v of enum %v is of non-nullable type %v", fieldType.Name, t, fieldType.Type)
+		}
+
+		ids[i] = i
+		if fieldOpts[i].HasVariantID {
+			ids[i] = fieldOpts[i].VariantID
+		}
+
+		if prevField, duplicate := fieldsByID[ids[i]]; duplicate {
+			return nil, kindErrorf(ErrInvalidOptions, "fields %v and %v of enum %v have same variant id %v", prevField, fieldType.Name, t, ids[i])
+		}
+
+		fieldsByID[ids[i]] = fieldType.Name
+	}
+
+	return ids, nil
+}
+
+// Unit variant of struct enum has no payload. It is represented by field of type *struct{} or *None.
+func isStructEnumUnitVariant(t reflect.Type) bool {
+	return t.Kind() == reflect.Ptr && (t.Elem() == emptyStructT || t.Elem() == noneT)
+}
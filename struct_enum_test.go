@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/samber/lo"
+	"github.com/stretchr/testify/require"
 	"golang.org/x/exp/maps"
 
 	"github.com/iotaledger/bcs-go"
@@ -71,3 +72,97 @@ func TestStructEnumWithInfEnumVariant(t *testing.T) {
 
 	bcs.TestEncodeErr(t, StructEnumWithNullableVariants{B: 123})
 }
+
+type StructEnumWithIDs struct {
+	A *int32 `bcs:"variant=5"`
+	B *string
+	C *struct{} `bcs:"variant=7"`
+	D *bcs.None
+}
+
+func (StructEnumWithIDs) IsBcsEnum() {}
+
+func TestStructEnumWithIDs(t *testing.T) {
+	bcs.TestCodecAndBytes(t, StructEnumWithIDs{A: lo.ToPtr[int32](10)}, []byte{0x5, 0xa, 0x0, 0x0, 0x0})
+	bcs.TestCodecAndBytes(t, StructEnumWithIDs{B: lo.ToPtr("aaa")}, []byte{0x1, 0x3, 0x61, 0x61, 0x61})
+	bcs.TestCodecAndBytes(t, StructEnumWithIDs{C: &struct{}{}}, []byte{0x7})
+	bcs.TestCodecAndBytes(t, StructEnumWithIDs{D: &bcs.None{}}, []byte{0x3})
+
+	bcs.TestDecodeErr[StructEnumWithIDs](t, uint8(0), "invalid variant index 0 for enum bcs_test.StructEnumWithIDs")
+	bcs.TestDecodeErr[StructEnumWithIDs](t, uint8(2), "invalid variant index 2 for enum bcs_test.StructEnumWithIDs")
+
+	_, err := bcs.Unmarshal[StructEnumWithIDs]([]byte{0x0})
+	require.ErrorIs(t, err, bcs.ErrInvalidEnumVariant)
+
+	schema, err := bcs.ReflectSchema[StructEnumWithIDs]()
+	require.NoError(t, err)
+	require.Equal(t, map[bcs.EnumVariantID]bcs.VariantFormat{
+		5: {Name: "A", Newtype: &bcs.Format{Kind: bcs.FormatI32}},
+		1: {Name: "B", Newtype: &bcs.Format{Kind: bcs.FormatStr}},
+		7: {Name: "C"},
+		3: {Name: "D"},
+	}, schema["StructEnumWithIDs"].Variants)
+}
+
+type StructEnumWithVariantOpts struct {
+	A *uint64    `bcs:"compact"`
+	B *[]int16   `bcs:"len_bytes=2,variant=3"`
+	C *SkipInner `bcs:"bytearr"`
+}
+
+func (StructEnumWithVariantOpts) IsBcsEnum() {}
+
+func TestStructEnumVariantOptions(t *testing.T) {
+	// Options from tags of variant fields are applied same way as for fields of regular structs
+	bcs.TestCodecAndBytes(t, StructEnumWithVariantOpts{A: lo.ToPtr[uint64](300)}, []byte{0x0, 0xac, 0x2})
+	bcs.TestCodecAndBytes(t, StructEnumWithVariantOpts{B: &[]int16{1, 2}}, []byte{0x3, 0x2, 0x1, 0x0, 0x2, 0x0})
+	bcs.TestCodecAndBytes(t, StructEnumWithVariantOpts{C: &SkipInner{A: 1, B: "a"}}, []byte{0x2, 0x4, 0x1, 0x0, 0x1, 0x61})
+
+	// Schema is also affected by options of variants
+	_, err := bcs.ReflectSchema[StructEnumWithVariantOpts]()
+	require.ErrorContains(t, err, "A: uint64: compact integers cannot be described in schema")
+}
+
+type StructEnumWithDuplicateIDs struct {
+	A *int32 `bcs:"variant=1"`
+	B *string
+}
+
+func (StructEnumWithDuplicateIDs) IsBcsEnum() {}
+
+type StructEnumWithNonNullableField struct {
+	A *int32
+	B int32
+}
+
+func (StructEnumWithNonNullableField) IsBcsEnum() {}
+
+type StructWithVariantTag struct {
+	A *int32 `bcs:"variant=1"`
+}
+
+func TestStructEnumInvalidDefinition(t *testing.T) {
+	// Definition of enum is checked even if the value could be encoded
+	_, err := bcs.Marshal(&StructEnumWithDuplicateIDs{B: lo.ToPtr("aaa")})
+	require.ErrorIs(t, err, bcs.ErrInvalidOptions)
+	require.ErrorContains(t, err, "fields A and B of enum bcs_test.StructEnumWithDuplicateIDs have same variant id 1")
+
+	_, err = bcs.Unmarshal[StructEnumWithDuplicateIDs]([]byte{0x1, 0x0})
+	require.ErrorIs(t, err, bcs.ErrInvalidOptions)
+
+	_, err = bcs.Marshal(&StructEnumWithNonNullableField{A: lo.ToPtr[int32](10)})
+	require.ErrorIs(t, err, bcs.ErrUnsupportedType)
+	require.ErrorContains(t, err, "field B of enum bcs_test.StructEnumWithNonNullableField is of non-nullable type int32")
+
+	_, err = bcs.Unmarshal[StructEnumWithNonNullableField]([]byte{0x0, 0xa, 0x0, 0x0, 0x0})
+	require.ErrorIs(t, err, bcs.ErrUnsupportedType)
+
+	bcs.TestEncodeErr(t, StructWithVariantTag{A: lo.ToPtr[int32](10)}, "variant tag is applicable only to fields of struct enum")
+	bcs.TestEncodeErr(t, StructEnumWithIDs{}, "no options are set")
+	bcs.TestEncodeErr(t, StructEnumWithIDs{C: &struct{}{}, D: &bcs.None{}}, "multiple options are set")
+
+	_, err = bcs.Marshal(&struct {
+		A *int32 `bcs:"variant=-1"`
+	}{})
+	require.ErrorContains(t, err, "invalid variant tag: -1")
+}
@@ -315,6 +315,15 @@ func TestStructWithByteArr(t *testing.T) {
 	bcs.TestCodecAndBytes(t, WithByteArrByte{A: []byte{1, 2, 3}}, []byte{0x3, 0x1, 0x1, 0x1, 0x2, 0x1, 0x3})
 }
 
+type WithElemOpts struct {
+	A []int64   `bcs_elem:"compact"`
+	B [2]uint32 `bcs_elem:"type=u8"`
+}
+
+func TestStructWithElemOpts(t *testing.T) {
+	bcs.TestCodecAndBytes(t, WithElemOpts{A: []int64{1, 300}, B: [2]uint32{3, 4}}, []byte{0x2, 0x1, 0xac, 0x2, 0x3, 0x4})
+}
+
 type InfOptional struct {
 	A InfEnum1 `bcs:"optional,not_enum"`
 }
@@ -350,6 +359,27 @@ func TestSTructWithBCSOpts(t *testing.T) {
 	bcs.TestCodecAndBytes(t, &WithBCSOptsOverride{A: 42}, []byte{0x2A})
 }
 
+// Options depend on value, but they are always taken from zero value of the type.
+type ValueDependentOpts uint32
+
+func (v ValueDependentOpts) BCSOptions() bcs.TypeOptions {
+	if v != 0 {
+		return bcs.TypeOptions{UnderlyingType: reflect.Uint8}
+	}
+
+	return bcs.TypeOptions{IsCompactInt: true}
+}
+
+type WithValueDependentOpts struct {
+	A ValueDependentOpts
+	B ValueDependentOpts
+}
+
+func TestBCSOptionsAreTakenFromZeroValue(t *testing.T) {
+	bcs.TestCodecAndBytes(t, WithValueDependentOpts{A: 300, B: 1}, []byte{0xac, 0x2, 0x1})
+	bcs.TestCodecAndBytes(t, &WithValueDependentOpts{A: 0, B: 128}, []byte{0x0, 0x80, 0x1})
+}
+
 type CompactInt struct {
 	A int64 `bcs:"compact"`
 }
@@ -358,6 +388,40 @@ type CompactUint struct {
 	A uint64 `bcs:"compact"`
 }
 
+type CompactZigZagInt struct {
+	A int64 `bcs:"compact=zigzag"`
+}
+
+type CompactZigZagInt8 struct {
+	A int8 `bcs:"compact=zigzag"`
+}
+
+type CompactZigZagUint struct {
+	A uint64 `bcs:"compact=zigzag"`
+}
+
+type CompactZigZagInvalid struct {
+	A int64 `bcs:"compact=other"`
+}
+
+type ZigZagInt int32
+
+func (ZigZagInt) BCSOptions() bcs.TypeOptions {
+	return bcs.TypeOptions{IsCompactInt: true, IsCompactZigZag: true}
+}
+
+type WithZigZagOpts struct {
+	A ZigZagInt
+	B ZigZagInt    `bcs:"compact"`
+	C CompactInt32 `bcs:"compact=zigzag"`
+}
+
+type CompactInt32 int32
+
+func (CompactInt32) BCSOptions() bcs.TypeOptions {
+	return bcs.TypeOptions{IsCompactInt: true}
+}
+
 type IntWithLessBytes struct {
 	A int64 `bcs:"type=i16"`
 }
@@ -384,6 +448,23 @@ func TestIntTypeConversion(t *testing.T) {
 	bcs.TestCodecAndBytes(t, CompactInt{A: math.MaxInt64}, []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f})
 	bcs.TestCodecAndBytes(t, CompactInt{A: math.MinInt64}, []byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x1})
 
+	bcs.TestCodecAndBytes(t, CompactZigZagInt{A: 0}, []byte{0x0})
+	bcs.TestCodecAndBytes(t, CompactZigZagInt{A: -1}, []byte{0x1})
+	bcs.TestCodecAndBytes(t, CompactZigZagInt{A: 1}, []byte{0x2})
+	bcs.TestCodecAndBytes(t, CompactZigZagInt{A: -64}, []byte{0x7f})
+	bcs.TestCodecAndBytes(t, CompactZigZagInt{A: 64}, []byte{0x80, 0x1})
+	bcs.TestCodecAndBytes(t, CompactZigZagInt{A: 70000}, []byte{0xe0, 0xc5, 0x8})
+	bcs.TestCodecAndBytes(t, CompactZigZagInt{A: -70000}, []byte{0xdf, 0xc5, 0x8})
+	bcs.TestCodecAndBytes(t, CompactZigZagInt{A: math.MaxInt64}, []byte{0xfe, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x1})
+	bcs.TestCodecAndBytes(t, CompactZigZagInt{A: math.MinInt64}, []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x1})
+	bcs.TestCodecAndBytes(t, CompactZigZagInt8{A: math.MinInt8}, []byte{0xff, 0x1})
+	bcs.TestCodecAndBytes(t, CompactZigZagInt8{A: math.MaxInt8}, []byte{0xfe, 0x1})
+	bcs.TestCodecAndBytes(t, WithZigZagOpts{A: -2, B: 1, C: -3}, []byte{0x3, 0x2, 0x5})
+
+	bcs.TestEncodeErr(t, CompactZigZagUint{A: 1})
+	bcs.TestDecodeErr[CompactZigZagUint](t, []byte{0x1})
+	bcs.TestEncodeErr(t, CompactZigZagInvalid{A: 1})
+
 	bcs.TestCodecAndBytes(t, CompactUint{A: 1}, []byte{0x1})
 	bcs.TestCodecAndBytes(t, CompactUint{A: 70000}, []byte{0xf0, 0xa2, 0x4})
 	bcs.TestCodecAndBytes(t, CompactUint{A: math.MaxUint64}, []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x1})
@@ -17,6 +17,10 @@ func TestCodec[V any](t *testing.T, v V, decodeInto ...V) []byte {
 	vEnc, err := Marshal(&v)
 	require.NoError(t, err, "%#v", v)
 
+	size, err := EncodedSize(&v)
+	require.NoError(t, err, "%#v", v)
+	require.Equal(t, len(vEnc), size, "encoded size")
+
 	var vDec V
 	if len(decodeInto) == 0 {
 		vDec, err = Unmarshal[V](vEnc)
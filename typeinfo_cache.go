@@ -2,86 +2,105 @@ package bcs
 
 import (
 	"reflect"
+	"sync"
 	"sync/atomic"
-
-	"github.com/samber/lo"
 )
 
 // Upon serialization the types are checked for the presence of customizations. This make take significant time.
 // To improve that, the type information is stored in cache.
-// To avoid global mutex locks when reading/writing cache, atomic swapping is used instead.
-// It works like that:
-// * When coder is created, it get pointer to the current version of cache.
-// * Current version of cache is only read, never written, so multiple encoders can read it at the same time.
-// * When coder is done with coding, it creates new cache with updated information.
-// * Then it atomically swaps the pointer to the new cache with the pointer to the current cache.
-// * Multiple coders may update cache, thus overwritting modifications of each other. But it is not a problem, because
-//   the info is only extended by them, so eventually cache will have information about all types.
-
-func newSharedTypeInfoCache() *sharedTypeInfoCache {
-	var c sharedTypeInfoCache
-	c.entries.Store(&map[reflect.Type]typeInfo{})
+// To avoid global mutex locks when reading/writing cache, the entries are kept in sync.Map:
+// * Reading of entries, which are already in cache, is lock-free, so multiple coders can read it at the same time.
+// * When coder collects information about new type, it adds it into the shared cache right away. Adding is cheap
+//   and does not copy existing entries, so it stays cheap even with thousands of types and many concurrent coders.
+// * Multiple coders may collect the information about the same type at the same time. Only the first added entry is kept,
+//   others are same anyway.
+// Type information depends on registrations of custom codecs and enums. So each generation of cache is marked with
+// the version of registry it was collected for. When registrations change, the generation is replaced with an empty one.
+
+func newSharedTypeInfoCache(version func() uint64) *sharedTypeInfoCache {
+	c := sharedTypeInfoCache{version: version}
+	c.entries.Store(newTypeInfoCacheEntries(version()))
 
 	return &c
 }
 
 type sharedTypeInfoCache struct {
-	entries atomic.Pointer[map[reflect.Type]typeInfo]
+	version func() uint64
+	entries atomic.Pointer[typeInfoCacheEntries]
+}
+
+func newTypeInfoCacheEntries(version uint64) *typeInfoCacheEntries {
+	return &typeInfoCacheEntries{version: version}
+}
+
+type typeInfoCacheEntries struct {
+	version uint64
+	// reflect.Type -> *typeInfo
+	entries sync.Map
 }
 
 func (c *sharedTypeInfoCache) Get() localTypeInfoCache {
 	return newLocalTypeInfoCache(c)
 }
 
-func newLocalTypeInfoCache(shared *sharedTypeInfoCache) localTypeInfoCache {
-	return localTypeInfoCache{
-		sharedCache:      shared,
-		prevCacheEntries: *shared.entries.Load(),
-		newCacheEntries:  make(map[reflect.Type]typeInfo),
+// Returns entries collected for given version of registry. If shared entries were collected for previous version,
+// they are replaced with empty ones.
+func (c *sharedTypeInfoCache) load(version uint64) *typeInfoCacheEntries {
+	for {
+		current := c.entries.Load()
+
+		switch {
+		case current.version == version:
+			return current
+		case current.version > version:
+			// Registrations have changed since version was read, so entries collected for it are not shared.
+			return newTypeInfoCacheEntries(version)
+		}
+
+		// Shared cache was collected for previous registrations, so its entries are dropped.
+		fresh := newTypeInfoCacheEntries(version)
+		if c.entries.CompareAndSwap(current, fresh) {
+			return fresh
+		}
 	}
 }
 
-type localTypeInfoCache struct {
-	sharedCache      *sharedTypeInfoCache
-	prevCacheEntries map[reflect.Type]typeInfo
-	newCacheEntries  map[reflect.Type]typeInfo
-}
+func newLocalTypeInfoCache(shared *sharedTypeInfoCache) localTypeInfoCache {
+	version := shared.version()
 
-func (c *localTypeInfoCache) Get(t reflect.Type) (typeInfo, bool) {
-	if cached, isCached := c.prevCacheEntries[t]; isCached {
-		return cached, true
-	}
-	if cached, isCached := c.newCacheEntries[t]; isCached {
-		return cached, true
+	return localTypeInfoCache{
+		sharedCache: shared,
+		version:     version,
+		entries:     shared.load(version),
 	}
-
-	return typeInfo{}, false
 }
 
-func (c *localTypeInfoCache) Add(t reflect.Type, ti typeInfo) {
-	c.newCacheEntries[t] = ti
+type localTypeInfoCache struct {
+	sharedCache *sharedTypeInfoCache
+	version     uint64
+	entries     *typeInfoCacheEntries
 }
 
-func (c *localTypeInfoCache) Save() {
-	if len(c.newCacheEntries) == 0 {
+// Refresh drops all entries if registrations have changed since they were collected.
+func (c *localTypeInfoCache) Refresh() {
+	version := c.sharedCache.version()
+	if version == c.version {
 		return
 	}
 
-	// Refreshing shared version of cache in case it was extended by somebody else while we were working on our local copy.
-	// This is not mandatory, but may be useful in cases e.g. when two coders are used in parallel on two independent sets of types.
-	// In that case without this line they would overwrite each others cache entries on every save.
-	// Still, even with this line there is a teeny-tiny chance of that happening, but on a long run its not a problem.
-	c.prevCacheEntries = *c.sharedCache.entries.Load()
+	c.version = version
+	c.entries = c.sharedCache.load(version)
+}
 
-	for k, v := range c.prevCacheEntries {
-		c.newCacheEntries[k] = v
+func (c *localTypeInfoCache) Get(t reflect.Type) (typeInfo, bool) {
+	if cached, isCached := c.entries.entries.Load(t); isCached {
+		return *cached.(*typeInfo), true
 	}
 
-	c.sharedCache.entries.Store(lo.ToPtr(c.newCacheEntries)) // NOTE: This is SUPER imporatant to use lo.ToPtr instead of &. Otherwise the pointer to field will be taken, which will cause data races.
+	return typeInfo{}, false
+}
 
-	// This local cache may be reused (e.g. multiple calls to Encode for one Encoder). But we cannot continue
-	// writing to c.newCacheEntries, because it is now shared with other coders, others may read from it.
-	// But we can safely continue using it for reading.
-	c.prevCacheEntries = c.newCacheEntries
-	c.newCacheEntries = make(map[reflect.Type]typeInfo)
+func (c *localTypeInfoCache) Add(t reflect.Type, ti typeInfo) {
+	// Entry is not overwritten if it was already added by another coder, so readers keep seeing the same entry.
+	c.entries.entries.LoadOrStore(t, &ti)
 }
@@ -1,39 +1,47 @@
 package bcs
 
 import (
+	"fmt"
 	"reflect"
+	"sync/atomic"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 	"golang.org/x/sync/errgroup"
 )
 
-func TestTypeInfoCacheConcurrency(t *testing.T) {
-	encoderGlobalTypeInfoCache.entries.Store(&map[reflect.Type]typeInfo{})
-	decoderGlobalTypeInfoCache.entries.Store(&map[reflect.Type]typeInfo{})
+func resetTypeInfoCaches(r *Registry) {
+	r.encoderTypeInfoCache.entries.Store(newTypeInfoCacheEntries(r.Version()))
+	r.decoderTypeInfoCache.entries.Store(newTypeInfoCacheEntries(r.Version()))
+}
 
-	type TestStruct[T any] struct {
-		A T
-	}
+type typeInfoCacheTestStruct[T any] struct {
+	A T
+}
 
-	encode := func() error {
-		e := NewBytesEncoder()
+func encodeDecodeTypeInfoCacheTestStructs(t testing.TB) {
+	e := NewBytesEncoder()
+
+	e.MustEncode(typeInfoCacheTestStruct[int32]{A: 10})
+	e.MustEncode(typeInfoCacheTestStruct[string]{A: "aaa"})
+	e.MustEncode(typeInfoCacheTestStruct[[]byte]{A: []byte{1, 2, 3}})
+	require.NoError(t, e.Err())
 
-		e.MustEncode(TestStruct[int32]{A: 10})
-		e.MustEncode(TestStruct[string]{A: "aaa"})
-		e.MustEncode(TestStruct[[]byte]{A: []byte{1, 2, 3}})
-		require.NoError(t, e.Err())
+	b := e.Bytes()
 
-		b := e.Bytes()
+	d := NewBytesDecoder(b)
+	Decode[typeInfoCacheTestStruct[int32]](&d.Decoder)
+	Decode[typeInfoCacheTestStruct[string]](&d.Decoder)
+	Decode[typeInfoCacheTestStruct[[]byte]](&d.Decoder)
+	require.NoError(t, d.Err())
+}
 
-		d := NewBytesDecoder(b)
-		Decode[TestStruct[int32]](&d.Decoder)
-		Decode[TestStruct[string]](&d.Decoder)
-		Decode[TestStruct[[]byte]](&d.Decoder)
-		require.NoError(t, d.Err())
+func TestTypeInfoCacheConcurrency(t *testing.T) {
+	resetTypeInfoCaches(DefaultRegistry)
 
-		encoderGlobalTypeInfoCache.entries.Store(&map[reflect.Type]typeInfo{})
-		decoderGlobalTypeInfoCache.entries.Store(&map[reflect.Type]typeInfo{})
+	encode := func() error {
+		encodeDecodeTypeInfoCacheTestStructs(t)
+		resetTypeInfoCaches(DefaultRegistry)
 
 		return nil
 	}
@@ -47,3 +55,170 @@ func TestTypeInfoCacheConcurrency(t *testing.T) {
 	err := g.Wait()
 	require.NoError(t, err)
 }
+
+// Creates n distinct struct types, which differ only by names of fields.
+func makeTypeInfoCacheTestTypes(n int) []reflect.Type {
+	types := make([]reflect.Type, n)
+	for i := range types {
+		types[i] = reflect.StructOf([]reflect.StructField{
+			{Name: "A", Type: reflect.TypeOf(uint32(0))},
+			{Name: fmt.Sprintf("B%d", i), Type: reflect.TypeOf("")},
+		})
+	}
+
+	return types
+}
+
+func encodeDecodeOfType(t testing.TB, r *Registry, typ reflect.Type) {
+	v := reflect.New(typ)
+	v.Elem().Field(0).SetUint(42)
+	v.Elem().Field(1).SetString(typ.Field(1).Name)
+
+	e := NewBytesEncoderWithOpts(EncoderConfig{Registry: r})
+	e.Encode(v.Interface())
+	require.NoError(t, e.Err())
+
+	res := reflect.New(typ)
+	d := NewBytesDecoderWithOpts(e.Bytes(), DecoderConfig{Registry: r})
+	d.Decode(res.Interface())
+	require.NoError(t, d.Err())
+	require.Equal(t, v.Interface(), res.Interface())
+}
+
+func TestTypeInfoCacheManyTypes(t *testing.T) {
+	r := NewRegistry()
+	types := makeTypeInfoCacheTestTypes(500)
+
+	g := errgroup.Group{}
+
+	for i := 0; i < 16; i++ {
+		g.Go(func() error {
+			for j := range types {
+				encodeDecodeOfType(t, r, types[(i*31+j)%len(types)])
+			}
+
+			return nil
+		})
+	}
+
+	require.NoError(t, g.Wait())
+
+	// All types are cached, so none of them is lost due to concurrent updates.
+	for _, c := range []*sharedTypeInfoCache{r.encoderTypeInfoCache, r.decoderTypeInfoCache} {
+		entries := c.entries.Load()
+		require.Equal(t, r.Version(), entries.version)
+
+		for _, typ := range types {
+			// Values are passed by pointer, so info is cached for pointer type.
+			_, isCached := entries.entries.Load(reflect.PointerTo(typ))
+			require.True(t, isCached, typ)
+		}
+	}
+}
+
+type typeInfoCacheCustomized uint16
+
+func TestTypeInfoCacheRegistrationChanges(t *testing.T) {
+	r := NewRegistry()
+	types := makeTypeInfoCacheTestTypes(50)
+	customT := reflect.TypeOf(typeInfoCacheCustomized(0))
+
+	var stop atomic.Bool
+
+	registrations := errgroup.Group{}
+	registrations.Go(func() error {
+		for !stop.Load() {
+			r.AddCustomEncoder(customT, func(e *Encoder, v reflect.Value) error {
+				e.WriteUint32(uint32(v.Uint()))
+				return nil
+			})
+			r.RemoveCustomEncoder(customT)
+		}
+
+		return nil
+	})
+
+	g := errgroup.Group{}
+
+	for i := 0; i < 8; i++ {
+		g.Go(func() error {
+			for j := 0; j < 300; j++ {
+				encodeDecodeOfType(t, r, types[(i+j)%len(types)])
+
+				// Depending on registrations value is encoded either by default or by custom encoder.
+				e := NewBytesEncoderWithOpts(EncoderConfig{Registry: r})
+				e.Encode(typeInfoCacheCustomized(j))
+				if e.Err() != nil {
+					return e.Err()
+				}
+				if l := len(e.Bytes()); l != 2 && l != 4 {
+					return fmt.Errorf("unexpected length of encoded value: %v", l)
+				}
+			}
+
+			return nil
+		})
+	}
+
+	require.NoError(t, g.Wait())
+	stop.Store(true)
+	require.NoError(t, registrations.Wait())
+
+	// Entries collected for previous registrations are not used.
+	r.AddCustomEncoder(customT, func(e *Encoder, v reflect.Value) error {
+		e.WriteUint32(uint32(v.Uint()))
+		return nil
+	})
+
+	e := NewBytesEncoderWithOpts(EncoderConfig{Registry: r})
+	e.Encode(typeInfoCacheCustomized(5))
+	require.NoError(t, e.Err())
+	require.Equal(t, []byte{5, 0, 0, 0}, e.Bytes())
+}
+
+func BenchmarkTypeInfoCacheConcurrency(b *testing.B) {
+	b.Run("cached", func(b *testing.B) {
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				encodeDecodeTypeInfoCacheTestStructs(b)
+			}
+		})
+	})
+
+	// Each iteration starts with empty cache, as in TestTypeInfoCacheConcurrency.
+	b.Run("reset", func(b *testing.B) {
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				encodeDecodeTypeInfoCacheTestStructs(b)
+				resetTypeInfoCaches(DefaultRegistry)
+			}
+		})
+	})
+}
+
+func BenchmarkTypeInfoCacheManyTypes(b *testing.B) {
+	types := makeTypeInfoCacheTestTypes(2000)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		// New registry has empty cache, so each type is added into it.
+		r := NewRegistry()
+
+		g := errgroup.Group{}
+
+		for k := 0; k < 8; k++ {
+			g.Go(func() error {
+				for j := range types {
+					encodeDecodeOfType(b, r, types[(k*251+j)%len(types)])
+				}
+
+				return nil
+			})
+		}
+
+		require.NoError(b, g.Wait())
+	}
+}
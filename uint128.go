@@ -24,6 +24,57 @@ func init() {
 	})
 }
 
+// Encodes big.Int using width selected by "bigint" option.
+func (e *Encoder) encodeBigInt(v *big.Int, enc BigIntEncoding) error {
+	switch enc {
+	case BigIntU64:
+		if !v.IsUint64() {
+			return fmt.Errorf("%s is out of range of Uint64", v.String())
+		}
+		e.WriteUint64(v.Uint64())
+	case BigIntDefault, BigIntU128:
+		u, err := U128FromBig(v)
+		if err != nil {
+			return err
+		}
+		e.WriteU128(u)
+	case BigIntU256:
+		u, err := U256FromBig(v)
+		if err != nil {
+			return err
+		}
+		e.WriteU256(u)
+	case BigIntI128:
+		i, err := I128FromBig(v)
+		if err != nil {
+			return err
+		}
+		e.WriteI128(i)
+	default:
+		return fmt.Errorf("unknown bigint encoding %v", enc)
+	}
+
+	return e.err
+}
+
+// Decodes big.Int using width selected by "bigint" option.
+func (d *Decoder) decodeBigInt(v *big.Int, enc BigIntEncoding) error {
+	switch enc {
+	case BigIntU64:
+		v.SetUint64(d.ReadUint64())
+	case BigIntDefault, BigIntU128:
+		v.Set(d.ReadU128().BigInt())
+	case BigIntU256:
+		v.Set(d.ReadU256().BigInt())
+	case BigIntI128:
+		v.Set(d.ReadI128().BigInt())
+	default:
+		return fmt.Errorf("unknown bigint encoding %v", enc)
+	}
+
+	return d.err
+}
+
 func EncodeUint128(v *big.Int, w io.Writer) error {
 	if err := checkUint128(v); err != nil {
 		return fmt.Errorf("checking Uint128 validity: %w", err)
@@ -70,12 +121,12 @@ func checkUint128(bigI *big.Int) error {
 
 func DecodeUint128(r io.Reader) (*big.Int, error) {
 	buf := make([]byte, 16)
-	n, err := r.Read(buf)
-	if err != nil {
-		return nil, err
-	}
-	if n != 16 {
-		return nil, fmt.Errorf("got unexpected number of bytes for Uint128: expected %v, got %v", len(buf), n)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+
+		return nil, fmt.Errorf("reading Uint128: %w", err)
 	}
 
 	lo := binary.LittleEndian.Uint64(buf[0:8])
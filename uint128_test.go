@@ -1,6 +1,7 @@
 package bcs_test
 
 import (
+	"bytes"
 	"math/big"
 	"testing"
 
@@ -28,3 +29,100 @@ func testUint128Codec(t *testing.T, v string, expectSuccess bool, expectedBytes
 		bcs.TestEncodeErr(t, &bi)
 	}
 }
+
+type BigIntWidths struct {
+	A big.Int  `bcs:"bigint=u64"`
+	B *big.Int `bcs:"bigint=u256"`
+	C big.Int  `bcs:"bigint=i128"`
+	D big.Int
+	E []big.Int          `bcs_elem:"bigint=u64"`
+	F map[uint8]*big.Int `bcs_value:"bigint=i128"`
+	G [1]big.Int         `bcs_elem:"bigint=u128"`
+	H map[string]big.Int `bcs_value:"bigint=u256"`
+}
+
+func TestBigIntWidth(t *testing.T) {
+	v := BigIntWidths{
+		A: *big.NewInt(1),
+		B: big.NewInt(2),
+		C: *big.NewInt(-3),
+		D: *big.NewInt(4),
+		E: []big.Int{*big.NewInt(5)},
+		F: map[uint8]*big.Int{1: big.NewInt(-6)},
+		G: [1]big.Int{*big.NewInt(7)},
+		H: map[string]big.Int{"a": *big.NewInt(8)},
+	}
+
+	expected := []byte{1, 0, 0, 0, 0, 0, 0, 0}
+	expected = append(expected, 2)
+	expected = append(expected, make([]byte, 31)...)
+	expected = append(expected, 0xfd)
+	expected = append(expected, bytes.Repeat([]byte{0xff}, 15)...)
+	expected = append(expected, 4)
+	expected = append(expected, make([]byte, 15)...)
+	expected = append(expected, 1, 5, 0, 0, 0, 0, 0, 0, 0)
+	expected = append(expected, 1, 1, 0xfa)
+	expected = append(expected, bytes.Repeat([]byte{0xff}, 15)...)
+	expected = append(expected, 7)
+	expected = append(expected, make([]byte, 15)...)
+	expected = append(expected, 1, 1, 'a', 8)
+	expected = append(expected, make([]byte, 31)...)
+
+	bcs.TestCodecAndBytes(t, v, expected)
+
+	schema, err := bcs.ReflectSchema[BigIntWidths]()
+	require.NoError(t, err)
+	require.Equal(t, []bcs.NamedFormat{
+		{Name: "A", Format: bcs.Format{Kind: bcs.FormatU64}},
+		{Name: "B", Format: bcs.Format{Kind: bcs.FormatU256}},
+		{Name: "C", Format: bcs.Format{Kind: bcs.FormatI128}},
+		{Name: "D", Format: bcs.Format{Kind: bcs.FormatU128}},
+		{Name: "E", Format: bcs.Format{Kind: bcs.FormatSeq, Content: &bcs.Format{Kind: bcs.FormatU64}}},
+		{Name: "F", Format: bcs.Format{Kind: bcs.FormatMap, Key: &bcs.Format{Kind: bcs.FormatU8}, Value: &bcs.Format{Kind: bcs.FormatI128}}},
+		{Name: "G", Format: bcs.Format{Kind: bcs.FormatTupleArray, Content: &bcs.Format{Kind: bcs.FormatU128}, Size: 1}},
+		{Name: "H", Format: bcs.Format{Kind: bcs.FormatMap, Key: &bcs.Format{Kind: bcs.FormatStr}, Value: &bcs.Format{Kind: bcs.FormatU256}}},
+	}, schema["BigIntWidths"].Fields)
+}
+
+func TestBigIntWidthRange(t *testing.T) {
+	type U64 struct {
+		V big.Int `bcs:"bigint=u64"`
+	}
+	type U256 struct {
+		V big.Int `bcs:"bigint=u256"`
+	}
+	type I128 struct {
+		V big.Int `bcs:"bigint=i128"`
+	}
+
+	maxU64 := new(big.Int).SetUint64(^uint64(0))
+	bcs.TestCodec(t, U64{V: *maxU64})
+	bcs.TestEncodeErr(t, U64{V: *new(big.Int).Add(maxU64, big.NewInt(1))})
+	bcs.TestEncodeErr(t, U64{V: *big.NewInt(-1)})
+
+	maxU256 := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+	bcs.TestCodec(t, U256{V: *maxU256})
+	bcs.TestEncodeErr(t, U256{V: *new(big.Int).Add(maxU256, big.NewInt(1))})
+	bcs.TestEncodeErr(t, U256{V: *big.NewInt(-1)})
+
+	minI128 := new(big.Int).Neg(new(big.Int).Lsh(big.NewInt(1), 127))
+	maxI128 := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 127), big.NewInt(1))
+	bcs.TestCodec(t, I128{V: *minI128})
+	bcs.TestCodec(t, I128{V: *maxI128})
+	bcs.TestEncodeErr(t, I128{V: *new(big.Int).Sub(minI128, big.NewInt(1))})
+	bcs.TestEncodeErr(t, I128{V: *new(big.Int).Add(maxI128, big.NewInt(1))})
+
+	// Decoded values are always within the range of selected width
+	decoded, err := bcs.Unmarshal[I128](bytes.Repeat([]byte{0xff}, 16))
+	require.NoError(t, err)
+	require.Equal(t, int64(-1), decoded.V.Int64())
+
+	type NotBigInt struct {
+		V uint64 `bcs:"bigint=u64"`
+	}
+	bcs.TestEncodeErr(t, NotBigInt{})
+	bcs.TestDecodeErr[NotBigInt](t, uint64(0))
+
+	_, err = bcs.FieldOptionsFromTag("bigint=u512")
+	require.Error(t, err)
+}
@@ -0,0 +1,110 @@
+package bcs_test
+
+import (
+	"bytes"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotaledger/bcs-go"
+)
+
+type ZeroCopySample struct {
+	Bytecode []byte
+	Name     string
+	Proof    [][]byte
+	Nested   ZeroCopyNested `bcs:"bytearr"`
+	Hash     [4]byte
+	Rest     bcs.Raw
+}
+
+type ZeroCopyNested struct {
+	Data []byte
+}
+
+// Checks that b is located inside of input.
+func requireReferences(t *testing.T, input, b []byte) {
+	start := uintptr(unsafe.Pointer(unsafe.SliceData(input)))
+	p := uintptr(unsafe.Pointer(unsafe.SliceData(b)))
+	require.True(t, p >= start && p+uintptr(len(b)) <= start+uintptr(len(input)), "value is not a view of input")
+}
+
+func TestZeroCopy(t *testing.T) {
+	v := ZeroCopySample{
+		Bytecode: bytes.Repeat([]byte{1, 2, 3}, 1000),
+		Name:     "module",
+		Proof:    [][]byte{{4, 5}, {6}},
+		Nested:   ZeroCopyNested{Data: []byte{7, 8, 9}},
+		Hash:     [4]byte{1, 2, 3, 4},
+		Rest:     bcs.Raw{10, 11},
+	}
+	b := bcs.MustMarshal(&v)
+
+	d := bcs.NewBytesDecoderWithOpts(b, bcs.DecoderConfig{ZeroCopy: true})
+	decoded := bcs.Decode[ZeroCopySample](&d.Decoder)
+	require.NoError(t, d.Err())
+	require.Zero(t, d.Len())
+	require.Equal(t, v, decoded)
+
+	requireReferences(t, b, decoded.Bytecode)
+	requireReferences(t, b, unsafe.Slice(unsafe.StringData(decoded.Name), len(decoded.Name)))
+	requireReferences(t, b, decoded.Proof[0])
+	requireReferences(t, b, decoded.Proof[1])
+	requireReferences(t, b, decoded.Nested.Data)
+	requireReferences(t, b, decoded.Rest)
+
+	// Appending must not overwrite the input
+	_ = append(decoded.Proof[0], 0xFF)
+	require.Equal(t, []byte{6}, decoded.Proof[1])
+
+	// Without the option values are copied
+	d = bcs.NewBytesDecoder(b)
+	decoded = bcs.Decode[ZeroCopySample](&d.Decoder)
+	require.NoError(t, d.Err())
+	require.Equal(t, v, decoded)
+	b[2] = 0xFF // first byte of Bytecode
+	require.Equal(t, v, decoded)
+}
+
+func TestZeroCopyNoAllocs(t *testing.T) {
+	e := bcs.NewBytesEncoder()
+	e.WriteBytes(bytes.Repeat([]byte{1}, 100000))
+	e.WriteString("hello")
+	b := e.Bytes()
+
+	const runs = 10
+	decoders := make([]*bcs.BytesDecoder, runs+1)
+	for i := range decoders {
+		decoders[i] = bcs.NewBytesDecoderWithOpts(b, bcs.DecoderConfig{ZeroCopy: true})
+	}
+
+	i := 0
+	allocs := testing.AllocsPerRun(runs, func() {
+		d := decoders[i]
+		i++
+
+		_ = d.ReadBytes()
+		_ = d.ReadString()
+	})
+	require.Zero(t, allocs)
+
+	for _, d := range decoders {
+		require.NoError(t, d.Err())
+		require.Zero(t, d.Len())
+	}
+}
+
+func TestZeroCopyErrors(t *testing.T) {
+	d := bcs.NewBytesDecoderWithOpts([]byte{0x5, 'a', 'b'}, bcs.DecoderConfig{ZeroCopy: true})
+	_ = d.ReadString()
+	require.ErrorIs(t, d.Err(), bcs.ErrUnexpectedEOF)
+
+	d = bcs.NewBytesDecoderWithOpts([]byte{0x2, 0xFF, 0xFE}, bcs.DecoderConfig{ZeroCopy: true, Strict: true})
+	_ = d.ReadString()
+	require.ErrorIs(t, d.Err(), bcs.ErrNonCanonical)
+
+	d = bcs.NewBytesDecoderWithOpts([]byte{0x3, 1, 2, 3}, bcs.DecoderConfig{ZeroCopy: true, Limits: bcs.DecoderLimits{MaxByteLen: 2}})
+	_ = d.ReadBytes()
+	require.ErrorIs(t, d.Err(), bcs.ErrLimitExceeded)
+}